@@ -4,74 +4,266 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/RibunLoc/WebPersonalBackend/api-gateway/handler"
 	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/openapi"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc"
 )
 
 type App struct {
+	cfg     Config                  // cấu hình lúc khởi động (port không đổi khi reload)
+	current atomic.Pointer[backend] // router + kết nối gRPC đang phục vụ
+
+	reloadMu sync.Mutex
+	status   reloadStatus
+
+	// trạng thái rate limit giữ qua các lần reload
+	rateStore  gwmw.RateStore
+	idemStore  gwmw.IdempotencyStore // Idempotency-Key, cũng giữ qua các lần reload
+	cacheStore gwmw.CacheStore       // response cache, purge qua /admin/cache/purge
+	redis      *redis.Client         // nil nếu không store nào dùng redis
+
+	// breaker + retry budget của từng upstream, cũng giữ qua các lần reload
+	resilience *client.Resilience
+	tls        *platform.CertReloader // cert TLS tới backend, nil = plaintext
+	https      *httpsSetup            // nil = chỉ phục vụ HTTP
+}
+
+// backend là một "thế hệ" router + client; reload dựng thế hệ mới rồi swap
+type backend struct {
 	cfg            Config
 	router         http.Handler
+	spec           *openapi.Document // tài liệu /openapi.json của router này
 	AuthHandler    *handler.AuthProxy
 	ContactHandler *handler.ContactProxy
+	transcoder     http.Handler // REST /v1/* -> gRPC theo google.api.http
+	inflight       inflight
+	health         *platform.Health     // /readyz: grpc.health.v1 của từng backend
+	ips            *platform.IPResolver // IP client theo TRUSTED_PROXIES
+
+	authConn      *grpc.ClientConn
+	contactConn   *grpc.ClientConn
+	routes        *RouteTable                 // route khai báo (nil nếu không cấu hình)
+	upstreamConns map[string]*grpc.ClientConn // kết nối gRPC của route table
 
 	// closers
 	closeAuthGRPC    func() error
 	closeContactGRPC func() error
+	closeUpstreams   []func() error
 }
 
 func New(ctx context.Context, cfg Config) (*App, error) {
-	authGRPC, closeFn, err := client.NewAuthGRPC(cfg.AuthGRPCAddr)
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	app := &App{
+		cfg:        cfg,
+		rateStore:  gwmw.NewMemoryStore(),
+		idemStore:  gwmw.NewMemoryIdempotencyStore(),
+		cacheStore: gwmw.NewMemoryCacheStore(cfg.CacheMaxEntries, cfg.CacheMaxBytes),
+		resilience: client.NewResilience(cfg.Resilience),
+	}
+	if cfg.RateLimitStore == "redis" || cfg.IdempotencyStore == "redis" || cfg.CacheStore == "redis" {
+		app.redis = redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Username: cfg.RedisUsername,
+			Password: cfg.RedisPassword,
+		})
+	}
+	if cfg.RateLimitStore == "redis" {
+		app.rateStore = gwmw.NewRedisStore(app.redis)
+	}
+	if cfg.IdempotencyStore == "redis" {
+		app.idemStore = gwmw.NewRedisIdempotencyStore(app.redis)
+	}
+	if cfg.CacheStore == "redis" {
+		app.cacheStore = gwmw.NewRedisCacheStore(app.redis)
+	}
+	var err error
+	if cfg.UpstreamTLS.Enabled() {
+		if app.tls, err = platform.NewCertReloader(cfg.UpstreamTLS); err != nil {
+			return nil, err
+		}
+	}
+	if app.https, err = app.newHTTPS(); err != nil {
+		return nil, err
+	}
+	b, err := app.newBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	app.current.Store(b)
+	app.status.record(cfg, nil)
+	return app, nil
+}
+
+func (a *App) newBackend(cfg Config) (*backend, error) {
+	ips, err := platform.LoadIPResolver(cfg.TrustedProxies, cfg.TrustedProxiesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// remote_ip của request transcode luôn lấy từ IP client thật
+	ipOverride := grpc.WithChainUnaryInterceptor(client.ClientIPInterceptor("remote_ip"))
+
+	authConn, err := client.DialBalanced("auth", cfg.AuthGRPCAddr, cfg.Balancing,
+		a.resilience.DialOption("auth"), a.grpcCreds(cfg.AuthGRPCIdentity))
 	if err != nil {
 		return nil, fmt.Errorf("connect auth gRPC: %w", err)
 	}
+	authGRPC := client.NewAuthGRPCConn(authConn)
+	authGRPC.Timeout = cfg.AuthTimeout
 
-	contactGRPC, closeContact, err := client.NewContactGRPC(cfg.ContactGRPCAddr)
+	contactConn, err := client.DialBalanced("contact", cfg.ContactGRPCAddr, cfg.Balancing,
+		ipOverride, a.resilience.DialOption("contact"), a.grpcCreds(cfg.ContactGRPCIdentity))
 	if err != nil {
+		_ = authConn.Close()
 		return nil, fmt.Errorf("connect contact gRPC: %w", err)
 	}
+	contactGRPC := client.NewContactGRPCConn(contactConn)
+	contactGRPC.Timeout = cfg.ContactTimeout
 
-	app := &App{
+	authProxy := handler.NewAuthProxy(authGRPC, cfg.AuthHTTPBase)
+	authProxy.HTTP.Timeout = cfg.AuthHTTPTimeout
+	authProxy.HTTP.Transport = a.resilience.Transport("auth-http", authProxy.HTTP.Transport)
+	authProxy.Session = cfg.Session
+
+	if cfg.Session.Enabled {
+		cfg.CSRF.Identity = cfg.Session.ID // token CSRF gắn với sid của phiên cookie
+	}
+	b := &backend{
 		cfg:              cfg,
-		AuthHandler:      handler.NewAuthProxy(authGRPC, cfg.AuthHTTPBase),
+		ips:              ips,
+		AuthHandler:      authProxy,
 		ContactHandler:   handler.NewContactProxy(contactGRPC),
-		closeAuthGRPC:    closeFn,
-		closeContactGRPC: closeContact,
+		authConn:         authConn,
+		contactConn:      contactConn,
+		closeAuthGRPC:    authConn.Close,
+		closeContactGRPC: contactConn.Close,
 	}
-	app.loadRoutes()
-	return app, nil
+
+	if b.transcoder, err = newTranscoder(authConn, contactConn, cfg.AuthTimeout, cfg.ContactTimeout); err != nil {
+		_ = b.close()
+		return nil, err
+	}
+
+	if cfg.RoutesFile != "" {
+		if b.routes, err = LoadRouteTable(cfg.RoutesFile); err != nil {
+			_ = b.close()
+			return nil, err
+		}
+		if b.upstreamConns, b.closeUpstreams, err = b.routes.dialUpstreams(func(name string, u Upstream) (*grpc.ClientConn, error) {
+			return client.DialBalanced(name, u.GRPC, cfg.Balancing, a.resilience.DialOption(name), a.grpcCreds(u.Identity))
+		}); err != nil {
+			_ = b.close()
+			return nil, err
+		}
+	}
+
+	b.health = b.newHealth()
+	if a.redis != nil {
+		// Redis lỗi thì rate limit cho request đi qua, nên chỉ là check optional
+		b.health.Add(platform.HealthCheck{
+			Name:     "redis",
+			Optional: true,
+			Check:    func(ctx context.Context) error { return a.redis.Ping(ctx).Err() },
+		})
+	}
+	if err := a.loadRoutes(b); err != nil {
+		_ = b.close()
+		return nil, err
+	}
+	return b, nil
 }
 
-func (a *App) Start(ctx context.Context) error {
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", a.cfg.ServerPort),
-		Handler: a.router,
+// grpcCreds: TLS tới backend nếu UPSTREAM_TLS_* được cấu hình (ghi đè insecure mặc định của client.Dial)
+func (a *App) grpcCreds(identity string) grpc.DialOption {
+	if a.tls == nil {
+		return grpc.EmptyDialOption{}
+	}
+	if identity == "" {
+		return grpc.WithTransportCredentials(a.tls.ClientCredentials())
+	}
+	return grpc.WithTransportCredentials(a.tls.ClientCredentials(identity))
+}
+
+// ServeHTTP chuyển request cho thế hệ hiện tại và đếm request đang xử lý
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for {
+		b := a.current.Load()
+		if b.inflight.acquire() {
+			defer b.inflight.release()
+			b.router.ServeHTTP(w, r)
+			return
+		}
+		// thế hệ này vừa bị thay, lấy lại thế hệ mới
 	}
-	errCh := make(chan error, 1)
+}
 
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+func (a *App) Start(ctx context.Context) error {
+	lc := platform.NewLifecycle(a.cfg.ShutdownTimeout)
+	plain := a.newServer(a.cfg.ServerPort, a)
+	if a.https != nil {
+		plain.Handler = a.https.plain
+		srv := a.newServer(a.cfg.HTTPSPort, a)
+		srv.TLSConfig = a.https.tls
+		lc.AddHTTPS("https", srv)
+		if a.https.watch != nil {
+			lc.Go(a.https.watch)
 		}
-		close(errCh)
-	}()
+	}
+	lc.AddHTTP("http", plain)
+	lc.Go(a.watchReload)
+	if a.tls != nil {
+		lc.Go(a.tls.Watch)
+	}
+	lc.OnStop("backends", func(context.Context) error { return a.cleanup() })
+	if a.redis != nil {
+		lc.OnStop("redis", func(context.Context) error { return a.redis.Close() })
+	}
+	return lc.Run(ctx)
+}
 
-	select {
-	case err := <-errCh:
-		return err
-	case <-ctx.Done():
-		_ = server.Shutdown(ctx)
-		_ = a.cleanup()
-		return nil
+// newServer: không đặt ReadTimeout/WriteTimeout; thời hạn nằm ở từng upstream (AUTH_TIMEOUT,
+// CONTACT_TIMEOUT cho cả route viết tay lẫn /v1, AUTH_HTTP_TIMEOUT, timeout của route trong ROUTES_FILE)
+func (a *App) newServer(port uint16, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           h,
+		ReadHeaderTimeout: a.cfg.ReadHeaderTimeout,
+		IdleTimeout:       a.cfg.IdleTimeout,
+		MaxHeaderBytes:    1 << 20,
 	}
 }
 
 func (a *App) cleanup() error {
-	if a.closeAuthGRPC != nil {
-		_ = a.closeAuthGRPC()
+	if b := a.current.Load(); b != nil {
+		return b.close()
 	}
-	if a.closeContactGRPC != nil {
-		_ = a.closeContactGRPC()
+	return nil
+}
+
+func (b *backend) close() error {
+	if b.closeAuthGRPC != nil {
+		_ = b.closeAuthGRPC()
+	}
+	if b.closeContactGRPC != nil {
+		_ = b.closeContactGRPC()
+	}
+	for _, c := range b.closeUpstreams {
+		_ = c()
 	}
 	return nil
 }
+
+// GET /admin/upstreams: trạng thái breaker và retry budget của từng upstream
+func (a *App) upstreamsHandler(w http.ResponseWriter, r *http.Request) {
+	util.JSON(w, http.StatusOK, a.resilience.Snapshot())
+}
@@ -0,0 +1,119 @@
+package application
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	authv1 "github.com/RibunLoc/WebPersonalBackend/gen/auth/v1"
+	contactv1 "github.com/RibunLoc/WebPersonalBackend/gen/contact/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// newTestApp dựng App từ một bộ biến cấu hình cố định (không đọc env của process).
+// Backend gRPC trỏ tới địa chỉ không có server: kết nối lazy nên router vẫn dựng được.
+func newTestApp(t *testing.T, vars map[string]string) *App {
+	t.Helper()
+	env := map[string]string{
+		"AUTH_GRPC_ADDR":    "127.0.0.1:1",
+		"CONTACT_GRPC_ADDR": "127.0.0.1:1",
+		"AUTH_HTTP_BASE":    "http://127.0.0.1:1",
+		"JWT_SECRET_KEY":    "test-secret",
+		"ADMIN_TOKEN":       "admin-token",
+	}
+	for k, v := range vars {
+		env[k] = v
+	}
+	cfg := configFrom("", func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	a, err := New(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = a.cleanup() })
+	return a
+}
+
+func (a *App) serve(r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.ServeHTTP(readerFromRecorder{w}, r)
+	return w
+}
+
+// readerFromRecorder: chi WrapResponseWriter (HTTP/1) giả định writer gốc có ReadFrom như của net/http
+type readerFromRecorder struct{ *httptest.ResponseRecorder }
+
+func (w readerFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+// fakeBackend là auth + contact gRPC chạy trong test, đếm số lần được gọi
+type fakeBackend struct {
+	authv1.UnimplementedUserServiceServer
+	contactv1.UnimplementedContactServiceServer
+
+	Addr        string
+	Health      *health.Server
+	logins      atomic.Int32
+	submit      atomic.Int32
+	requestID   atomic.Value // x-request-id của lời gọi gần nhất
+	traceparent atomic.Value // traceparent của lời gọi gần nhất
+	deadline    atomic.Value // thời hạn còn lại (time.Duration) của lời gọi gần nhất
+}
+
+func startFakeBackend(t *testing.T) *fakeBackend {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeBackend{Addr: lis.Addr().String(), Health: health.NewServer()}
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, f.Health)
+	authv1.RegisterUserServiceServer(s, f)
+	contactv1.RegisterContactServiceServer(s, f)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return f
+}
+
+func (f *fakeBackend) vars() map[string]string {
+	return map[string]string{"AUTH_GRPC_ADDR": f.Addr, "CONTACT_GRPC_ADDR": f.Addr}
+}
+
+func (f *fakeBackend) recordDeadline(ctx context.Context) {
+	var left time.Duration // 0 = không có thời hạn
+	if dl, ok := ctx.Deadline(); ok {
+		left = time.Until(dl)
+	}
+	f.deadline.Store(left)
+}
+
+func (f *fakeBackend) Login(ctx context.Context, in *authv1.LoginRequest) (*authv1.LoginResponse, error) {
+	f.logins.Add(1)
+	f.recordDeadline(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("cache-control", "no-store", "x-internal-node", "auth-1"))
+	return &authv1.LoginResponse{Token: "jwt-token", User: &authv1.UserResponse{Id: "u1", Email: in.GetEmail()}}, nil
+}
+
+func (f *fakeBackend) Submit(ctx context.Context, _ *contactv1.ContactRequest) (*contactv1.ContactResponse, error) {
+	f.submit.Add(1)
+	f.recordDeadline(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-request-id")) > 0 {
+		f.requestID.Store(md.Get("x-request-id")[0])
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("traceparent")) > 0 {
+		f.traceparent.Store(md.Get("traceparent")[0])
+	}
+	return &contactv1.ContactResponse{Status: "ok"}, nil
+}
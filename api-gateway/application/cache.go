@@ -0,0 +1,61 @@
+package application
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+)
+
+// cache dựng middleware cache response; ttl/vary khác rỗng thì thay giá trị mặc định
+// (route table: cache_ttl, cache_vary). Store dùng chung nên purge áp dụng cho mọi route.
+// Request mang Authorization hoặc cookie phiên không đi qua cache.
+func (a *App) cache(b *backend, ttl time.Duration, vary []string) func(http.Handler) http.Handler {
+	c := b.cfg.Cache
+	c.Store = a.cacheStore
+	if ttl > 0 {
+		c.DefaultTTL = ttl
+	}
+	if len(vary) > 0 {
+		c.VaryHeaders = vary
+	}
+	c.Bypass = func(r *http.Request) bool {
+		if r.Header.Get("Authorization") != "" {
+			return true
+		}
+		for _, name := range b.cfg.CSRF.AuthCookies {
+			if _, err := r.Cookie(name); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return c.Middleware
+}
+
+// CachePurgeInput là body của POST /admin/cache/purge
+type CachePurgeInput struct {
+	// tiền tố path (vd. /v1/contacts) hoặc URL đầy đủ; rỗng = xoá toàn bộ cache
+	Prefix string `json:"prefix" validate:"max=2048"`
+}
+
+type CachePurgeResult struct {
+	Purged int `json:"purged"`
+}
+
+func (a *App) cachePurgeHandler(w http.ResponseWriter, r *http.Request) {
+	var in CachePurgeInput
+	if !util.BindJSON(w, r, &in) {
+		return
+	}
+	n, err := a.cacheStore.Purge(r.Context(), gwmw.CachePurgePrefix(in.Prefix))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "cache purge failed", "prefix", in.Prefix, "error", err)
+		util.Error(w, http.StatusInternalServerError, "cache purge failed")
+		return
+	}
+	slog.InfoContext(r.Context(), "cache purged", "prefix", in.Prefix, "purged", n)
+	util.JSON(w, http.StatusOK, CachePurgeResult{Purged: n})
+}
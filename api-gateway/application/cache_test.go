@@ -0,0 +1,112 @@
+package application
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+)
+
+// TestCacheHonoursForwardedHeaders: header cache của upstream (HTTP và gRPC) đi qua forwarder tới
+// cache, nên response private / Set-Cookie / Vary lạ không bị trả cho người dùng khác
+func TestCacheHonoursForwardedHeaders(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/cookie":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			w.Header().Set("Set-Cookie", "visitor=1; Path=/")
+		case "/vary-cookie":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			w.Header().Set("Vary", "Cookie")
+		case "/lang":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, r.URL.Path+" "+r.Header.Get("Accept-Language"))
+	}))
+	t.Cleanup(upstream.Close)
+	f := startFakeBackend(t)
+
+	routes := filepath.Join(t.TempDir(), "routes.yaml")
+	yaml := fmt.Sprintf(`
+upstreams:
+  pages: { http: %q }
+  auth:  { grpc: %q }
+routes:
+  - { method: GET, path: /pages/public, upstream: pages, upstream_path: /public, cache: true }
+  - { method: GET, path: /pages/private, upstream: pages, upstream_path: /private, cache: true }
+  - { method: GET, path: /pages/cookie, upstream: pages, upstream_path: /cookie, cache: true }
+  - { method: GET, path: /pages/vary-cookie, upstream: pages, upstream_path: /vary-cookie, cache: true }
+  - { method: GET, path: /pages/lang, upstream: pages, upstream_path: /lang, cache: true, cache_vary: [Accept-Language] }
+  - { method: GET, path: /grpc/login, upstream: auth, grpc_method: userpb.UserService/Login, cache: true }
+`, upstream.URL, f.Addr)
+	if err := os.WriteFile(routes, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// CACHE_DEFAULT_TTL: nếu header của upstream bị rơi mất thì mọi response đều bị cache
+	a := newTestApp(t, map[string]string{"GATEWAY_ROUTES_FILE": routes, "CACHE_DEFAULT_TTL": "1m"})
+
+	get := func(path, lang string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if lang != "" {
+			r.Header.Set("Accept-Language", lang)
+		}
+		return a.serve(r)
+	}
+	for _, tt := range []struct {
+		path   string
+		stored bool
+	}{
+		{"/pages/public", true},
+		{"/pages/private", false},
+		{"/pages/cookie", false},
+		{"/pages/vary-cookie", false},
+	} {
+		get(tt.path, "")
+		w := get(tt.path, "")
+		want := map[bool]string{true: "HIT", false: "MISS"}[tt.stored]
+		if w.Code != http.StatusOK || w.Header().Get(gwmw.CacheHeader) != want {
+			t.Errorf("%s: %d X-Cache %q, want %s", tt.path, w.Code, w.Header().Get(gwmw.CacheHeader), want)
+		}
+		if tt.path == "/pages/cookie" && w.Header().Get("Set-Cookie") == "" {
+			t.Errorf("%s: Set-Cookie of the upstream not forwarded", tt.path)
+		}
+	}
+
+	for _, lang := range []string{"vi", "en", "vi", "en"} {
+		if w := get("/pages/lang", lang); w.Body.String() != "/lang "+lang {
+			t.Errorf("Accept-Language %s: body %q", lang, w.Body)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["/lang"] != 2 {
+		t.Errorf("/lang called %d times, want one per language", calls["/lang"])
+	}
+
+	// gRPC: metadata cache-control: no-store của Login tới được cache
+	for range 2 {
+		if w := get("/grpc/login", ""); w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-store" {
+			t.Fatalf("/grpc/login: %d Cache-Control %q body %s", w.Code, w.Header().Get("Cache-Control"), w.Body)
+		}
+	}
+	if n := f.logins.Load(); n != 2 {
+		t.Errorf("no-store gRPC response served from cache (backend called %d times)", n)
+	}
+}
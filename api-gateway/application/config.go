@@ -1,47 +1,600 @@
 package application
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/handler"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	ServerPort      uint16
+	HTTPSPort       uint16 // cổng HTTPS khi bật TLS (HTTPS_PORT, mặc định 8443)
 	AuthGRPCAddr    string
 	AuthHTTPBase    string
 	ContactGRPCAddr string
 	JWTSecret       string
+
+	// Các giá trị dưới đây có thể đổi khi reload (SIGHUP / file thay đổi)
+	CORSPublic      gwmw.CORS     // /auth, /contact
+	CORSAPI         gwmw.CORS     // /api (cần JWT)
+	CORSAdmin       gwmw.CORS     // /admin
+	AuthTimeout     time.Duration // timeout gọi gRPC auth
+	ContactTimeout  time.Duration // timeout gọi gRPC contact
+	AuthHTTPTimeout time.Duration // timeout forward HTTP sang auth-service
+
+	// Chọn instance khi địa chỉ gRPC là danh sách / dns:/// / file:// (GRPC_LB_POLICY,
+	// GRPC_HEALTH_CHECK, GRPC_RESOLVER_REFRESH)
+	Balancing client.Balancing
+
+	RoutesFile      string        // bảng route YAML (rỗng = chỉ dùng route viết sẵn)
+	ConfigFile      string        // file .env được đọc lại khi reload
+	ConfigWatch     time.Duration // chu kỳ kiểm tra file thay đổi (0 = tắt)
+	DrainTimeout    time.Duration // thời gian chờ request cũ xong trước khi đóng kết nối cũ
+	ShutdownTimeout time.Duration // thời gian drain request khi dừng process (SHUTDOWN_TIMEOUT)
+	AdminToken      string        // bearer token cho /admin (rỗng = tắt)
+	MetricsToken    string        // bearer token cho /metrics (rỗng = không yêu cầu)
+
+	// Chế độ cookie cho frontend trình duyệt: SESSION_COOKIES=true thì /auth/login đặt cookie HttpOnly
+	// thay vì trả token (SESSION_ACCESS_COOKIE, SESSION_REFRESH_COOKIE, SESSION_ACCESS_TTL,
+	// SESSION_REFRESH_TTL, SESSION_COOKIE_DOMAIN, SESSION_COOKIE_SECURE, SESSION_COOKIE_SAMESITE)
+	Session handler.Session
+
+	// Nén response theo Accept-Encoding (COMPRESSION=false để tắt, COMPRESSION_MIN_SIZE byte,
+	// COMPRESSION_TYPES) và weak ETag + 304 cho GET (ETAG=false để tắt)
+	Compression bool
+	Compress    gwmw.Compress
+	ETag        bool
+
+	// Cache response GET công khai theo Cache-Control của upstream (HTTP_CACHE=false để tắt).
+	// CACHE_STORE memory | redis, CACHE_MAX_ENTRIES / CACHE_MAX_BYTES (memory) chỉ đọc lúc khởi động;
+	// CACHE_DEFAULT_TTL khi upstream không gửi Cache-Control (0 = không cache),
+	// CACHE_STALE_WHILE_REVALIDATE, CACHE_VARY_HEADERS (header đưa vào cache key)
+	HTTPCache       bool
+	Cache           gwmw.Cache
+	CacheStore      string
+	CacheMaxEntries int
+	CacheMaxBytes   int
+
+	// Header bảo mật cho mọi response (SECURITY_CSP, SECURITY_FRAME_OPTIONS, SECURITY_REFERRER_POLICY; "off" = tắt)
+	Security gwmw.SecurityHeaders
+	// CSRF cho client xác thực bằng cookie: CSRF_SECRET (mặc định suy ra từ JWT_SECRET_KEY),
+	// CSRF_COOKIE_NAME, CSRF_COOKIE_DOMAIN, CSRF_COOKIE_SECURE (mặc định theo SESSION_COOKIE_SECURE),
+	// CSRF_AUTH_COOKIES (mặc định cookie của Session), CSRF_TRUSTED_ORIGINS
+	// (mặc định origin của CORS public + api)
+	CSRF gwmw.CSRF
+
+	// Proxy tin cậy (CIDR, phân tách bằng dấu phẩy) và file danh sách CIDR (vd. cloudflare-ips.txt).
+	// Chỉ request tới từ các địa chỉ này mới được đọc X-Forwarded-For / CF-Connecting-IP / X-Real-IP.
+	TrustedProxies     string
+	TrustedProxiesFile string
+
+	// Rate limit route viết sẵn: "N/duration" hoặc "off" (RATE_LIMIT_LOGIN, ...)
+	RateLimitLogin     string
+	RateLimitRegister  string
+	RateLimitContact   string
+	RateLimitAlgorithm gwmw.RateAlgorithm // mặc định cho mọi limiter (RATE_LIMIT_ALGORITHM)
+	// API key hợp lệ cho rate_limit_key: api_key (API_KEYS, phân tách bằng dấu phẩy; đọc qua secret provider)
+	APIKeys gwmw.APIKeys
+
+	// Giới hạn body (byte): BODY_LIMIT_DEFAULT cho mọi route, các route viết sẵn có giới hạn riêng
+	// (BODY_LIMIT_LOGIN, ...); route trong GATEWAY_ROUTES_FILE dùng max_body_bytes
+	BodyLimitDefault  int64
+	BodyLimitLogin    int64
+	BodyLimitRegister int64
+	BodyLimitContact  int64
+
+	// Nơi lưu trạng thái rate limit, chỉ đọc lúc khởi động:
+	// memory (mỗi instance riêng) | redis (dùng chung giữa các instance)
+	RateLimitStore string
+	// Idempotency-Key cho POST (IDEMPOTENCY_STORE memory | redis, chỉ đọc lúc khởi động;
+	// IDEMPOTENCY_TTL thời gian giữ response, IDEMPOTENCY_LOCK_TTL thời gian giữ key khi đang xử lý)
+	IdempotencyStore   string
+	IdempotencyTTL     time.Duration
+	IdempotencyLockTTL time.Duration
+	RedisAddr          string
+	RedisUsername      string
+	RedisPassword      string
+
+	// HTTPS, chỉ đọc lúc khởi động. TLS_CERT_FILE + TLS_KEY_FILE (đọc lại khi file đổi) hoặc
+	// ACME_DOMAINS bật HTTPS trên HTTPSPort; khi đó GATEWAY_PORT redirect sang HTTPS nếu HTTPSRedirect.
+	TLS               platform.TLSOptions
+	ACME              ACMEConfig
+	HTTPSRedirect     bool          // HTTPS_REDIRECT, mặc định true
+	HSTS              gwmw.HSTS     // HSTS_MAX_AGE (giây, 0 = tắt), HSTS_INCLUDE_SUBDOMAINS, HSTS_PRELOAD
+	ReadHeaderTimeout time.Duration // HTTP_READ_HEADER_TIMEOUT
+	IdleTimeout       time.Duration // HTTP_IDLE_TIMEOUT (keep-alive)
+
+	// TLS/mTLS tới backend gRPC, chỉ đọc lúc khởi động (cert được đọc lại khi file đổi):
+	// UPSTREAM_TLS_CA_FILE, _CERT_FILE + _KEY_FILE (client cert cho mTLS), _ALLOWED_SANS
+	UpstreamTLS platform.TLSOptions
+	// Identity (SAN) mong đợi của từng backend khi dùng TLS; rỗng = kiểm tra hostname của địa chỉ
+	AuthGRPCIdentity    string
+	ContactGRPCIdentity string
+
+	// Breaker / retry / hedging cho upstream, chỉ đọc lúc khởi động
+	// (BREAKER_*, RETRY_*, HEDGE_DELAY, UPSTREAM_IDEMPOTENT_METHODS)
+	Resilience client.ResilienceConfig
 }
 
 func LoadConfig() Config {
-	_ = godotenv.Load()
+	path := configFilePath()
+	f, err := readEnvFile(path)
+	if err != nil {
+		slog.Warn("config file ignored", "file", path, "error", err)
+	}
+	f.apply()
+	return configFromEnv(path)
+}
+
+// ReloadConfig đọc lại file cấu hình và dựng Config mới mà chưa đụng tới process env;
+// gọi envFile.apply sau khi cấu hình mới đã được dùng thành công.
+func ReloadConfig(path string) (Config, envFile, error) {
+	f, err := readEnvFile(path)
+	if err != nil {
+		return Config{}, f, err
+	}
+	cfg := configFrom(path, f.lookup)
+	return cfg, f, cfg.validate()
+}
+
+// fileEnvKeys là các biến do file cấu hình đặt vào process env (lần load / reload gần nhất).
+// Biến có sẵn trong môi trường thật của process luôn thắng file, giống godotenv.Load.
+var (
+	fileEnvMu   sync.Mutex
+	fileEnvKeys = map[string]bool{}
+)
+
+// envFile là nội dung file .env đã parse, chưa áp vào process env
+type envFile struct {
+	values map[string]string
+}
+
+// readEnvFile đọc file .env; file không tồn tại = không có biến nào
+func readEnvFile(path string) (envFile, error) {
+	values, err := godotenv.Read(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return envFile{values: map[string]string{}}, nil
+	}
+	if err != nil {
+		return envFile{values: map[string]string{}}, fmt.Errorf("read %s: %w", path, err)
+	}
+	return envFile{values: values}, nil
+}
+
+// lookup: env thật của process trước, rồi tới file (bỏ qua giá trị file cũ đã áp)
+func (f envFile) lookup(key string) (string, bool) {
+	fileEnvMu.Lock()
+	fromFile := fileEnvKeys[key]
+	fileEnvMu.Unlock()
+	if v, ok := os.LookupEnv(key); ok && !fromFile {
+		return v, true
+	}
+	v, ok := f.values[key]
+	return v, ok
+}
+
+// apply đưa file vào process env và xoá các biến file trước đã đặt mà file này không còn
+func (f envFile) apply() {
+	fileEnvMu.Lock()
+	defer fileEnvMu.Unlock()
+	for k := range fileEnvKeys {
+		if _, ok := f.values[k]; !ok {
+			_ = os.Unsetenv(k)
+			delete(fileEnvKeys, k)
+		}
+	}
+	for k, v := range f.values {
+		if _, ok := os.LookupEnv(k); ok && !fileEnvKeys[k] {
+			continue // biến của môi trường thật
+		}
+		_ = os.Setenv(k, v)
+		fileEnvKeys[k] = true
+	}
+}
 
+func configFilePath() string {
+	if v := os.Getenv("GATEWAY_CONFIG_FILE"); v != "" {
+		return v
+	}
+	return ".env"
+}
+
+func configFromEnv(path string) Config {
+	return configFrom(path, os.LookupEnv)
+}
+
+// configFrom dựng Config từ env (process env, hoặc file .env chưa áp dụng khi reload)
+func configFrom(path string, env envLookup) Config {
 	cfg := Config{
 		ServerPort:   9090,
+		HTTPSPort:    8443,
 		AuthGRPCAddr: "localhost:50051",
 		AuthHTTPBase: "http://localhost:8081",
 
 		ContactGRPCAddr: "localhost:50052",
+
+		AuthTimeout:     5 * time.Second,
+		ContactTimeout:  8 * time.Second,
+		AuthHTTPTimeout: 7 * time.Second,
+		Balancing:       client.DefaultBalancing(),
+
+		RateLimitLogin:    "10/1m",
+		RateLimitRegister: "5/1m",
+		RateLimitContact:  "5/1m",
+		RateLimitStore:    "memory",
+
+		IdempotencyStore:   "memory",
+		IdempotencyTTL:     24 * time.Hour,
+		IdempotencyLockTTL: time.Minute,
+
+		Compression: true,
+		Compress:    gwmw.Compress{MinSize: 1024},
+		ETag:        true,
+
+		HTTPCache:       true,
+		Cache:           gwmw.Cache{VaryHeaders: []string{"Accept", "Accept-Language"}},
+		CacheStore:      "memory",
+		CacheMaxEntries: 10000,
+		CacheMaxBytes:   64 << 20,
+
+		Security: gwmw.DefaultSecurityHeaders(),
+		Session:  handler.DefaultSession(),
+		CSRF:     gwmw.CSRF{CookieName: "csrf_token"},
+
+		BodyLimitDefault:  1 << 20,
+		BodyLimitLogin:    4 << 10,
+		BodyLimitRegister: 4 << 10,
+		BodyLimitContact:  16 << 10,
+
+		Resilience: client.DefaultResilienceConfig(),
+
+		ACME:              ACMEConfig{CacheDir: "acme-cache"},
+		HTTPSRedirect:     true,
+		HSTS:              gwmw.HSTS{MaxAge: 180 * 24 * 3600},
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+
+		ConfigFile:      path,
+		DrainTimeout:    30 * time.Second,
+		ShutdownTimeout: 15 * time.Second,
 	}
+	secrets := platform.NewSecretProviderFrom(platform.LookupFunc(env))
 
-	if v := os.Getenv("GATEWAY_PORT"); v != "" {
+	if v := env.get("GATEWAY_PORT"); v != "" {
 		if p, err := strconv.ParseUint(v, 10, 16); err == nil {
 			cfg.ServerPort = uint16(p)
 		}
 	}
-	if v := os.Getenv("AUTH_GRPC_ADDR"); v != "" {
+	if v := env.get("HTTPS_PORT"); v != "" {
+		if p, err := strconv.ParseUint(v, 10, 16); err == nil {
+			cfg.HTTPSPort = uint16(p)
+		}
+	}
+	if v := env.get("AUTH_GRPC_ADDR"); v != "" {
 		cfg.AuthGRPCAddr = v
 	}
-	if v := os.Getenv("AUTH_HTTP_BASE"); v != "" {
+	if v := env.get("AUTH_HTTP_BASE"); v != "" {
 		cfg.AuthHTTPBase = v
 	}
-	if v := os.Getenv("CONTACT_GRPC_ADDR"); v != "" {
+	if v := env.get("CONTACT_GRPC_ADDR"); v != "" {
 		cfg.ContactGRPCAddr = v
 	}
-	if v := os.Getenv("JWT_SECRET_KEY"); v != "" { // nên đồng bộ với auth-service
+	// JWT_SECRET_KEY_FILE / vault / env
+	if v, err := secrets.Get("JWT_SECRET_KEY"); err == nil { // nên đồng bộ với auth-service
 		cfg.JWTSecret = v
 	}
+	cfg.CORSPublic, cfg.CORSAPI, cfg.CORSAdmin = loadCORS(env)
+	env.boolVar("SESSION_COOKIES", &cfg.Session.Enabled)
+	env.stringVar("SESSION_ACCESS_COOKIE", &cfg.Session.AccessCookie)
+	env.stringVar("SESSION_REFRESH_COOKIE", &cfg.Session.RefreshCookie)
+	env.durationVar("SESSION_ACCESS_TTL", &cfg.Session.AccessTTL)
+	env.durationVar("SESSION_REFRESH_TTL", &cfg.Session.RefreshTTL)
+	cfg.Session.Domain = env.get("SESSION_COOKIE_DOMAIN")
+	env.boolVar("SESSION_COOKIE_SECURE", &cfg.Session.Secure)
+	env.stringVar("SESSION_COOKIE_SAMESITE", &cfg.Session.SameSite)
+	cfg.Session.JWTSecret = cfg.JWTSecret
+	env.boolVar("COMPRESSION", &cfg.Compression)
+	env.intVar("COMPRESSION_MIN_SIZE", &cfg.Compress.MinSize)
+	if v := env.get("COMPRESSION_TYPES"); v != "" {
+		cfg.Compress.Types = splitList(v)
+	}
+	env.boolVar("ETAG", &cfg.ETag)
+	env.boolVar("HTTP_CACHE", &cfg.HTTPCache)
+	env.stringVar("CACHE_STORE", &cfg.CacheStore)
+	env.intVar("CACHE_MAX_ENTRIES", &cfg.CacheMaxEntries)
+	env.intVar("CACHE_MAX_BYTES", &cfg.CacheMaxBytes)
+	env.durationVar("CACHE_DEFAULT_TTL", &cfg.Cache.DefaultTTL)
+	env.durationVar("CACHE_STALE_WHILE_REVALIDATE", &cfg.Cache.DefaultSWR)
+	if v, ok := env("CACHE_VARY_HEADERS"); ok {
+		cfg.Cache.VaryHeaders = splitList(v)
+	}
+	env.stringVar("SECURITY_CSP", &cfg.Security.CSP)
+	env.stringVar("SECURITY_FRAME_OPTIONS", &cfg.Security.FrameOptions)
+	env.stringVar("SECURITY_REFERRER_POLICY", &cfg.Security.ReferrerPolicy)
+	if v, err := secrets.Get("CSRF_SECRET"); err == nil && v != "" {
+		cfg.CSRF.Secret = []byte(v)
+	} else {
+		// các instance dùng chung JWT secret nên token CSRF hợp lệ ở mọi instance
+		m := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+		m.Write([]byte("gateway csrf"))
+		cfg.CSRF.Secret = m.Sum(nil)
+	}
+	env.stringVar("CSRF_COOKIE_NAME", &cfg.CSRF.CookieName)
+	cfg.CSRF.CookieDomain = env.get("CSRF_COOKIE_DOMAIN")
+	cfg.CSRF.CookieSecure = cfg.Session.Secure
+	env.boolVar("CSRF_COOKIE_SECURE", &cfg.CSRF.CookieSecure)
+	cfg.CSRF.AuthCookies = []string{cfg.Session.AccessCookie, cfg.Session.RefreshCookie}
+	if v := env.get("CSRF_AUTH_COOKIES"); v != "" {
+		cfg.CSRF.AuthCookies = splitList(v)
+	}
+	cfg.CSRF.TrustedOrigins = append([]string{}, cfg.CORSPublic.Origins...)
+	for _, o := range cfg.CORSAPI.Origins {
+		if !slices.Contains(cfg.CSRF.TrustedOrigins, o) {
+			cfg.CSRF.TrustedOrigins = append(cfg.CSRF.TrustedOrigins, o)
+		}
+	}
+	if v := env.get("CSRF_TRUSTED_ORIGINS"); v != "" {
+		cfg.CSRF.TrustedOrigins = splitList(v)
+	}
+	cfg.RoutesFile = env.get("GATEWAY_ROUTES_FILE")
+	env.durationVar("AUTH_TIMEOUT", &cfg.AuthTimeout)
+	env.durationVar("CONTACT_TIMEOUT", &cfg.ContactTimeout)
+	env.durationVar("AUTH_HTTP_TIMEOUT", &cfg.AuthHTTPTimeout)
+	env.stringVar("GRPC_LB_POLICY", &cfg.Balancing.Policy)
+	env.boolVar("GRPC_HEALTH_CHECK", &cfg.Balancing.HealthCheck)
+	env.durationVar("GRPC_RESOLVER_REFRESH", &cfg.Balancing.FileRefresh)
+	env.durationVar("CONFIG_WATCH_INTERVAL", &cfg.ConfigWatch)
+	env.durationVar("RELOAD_DRAIN_TIMEOUT", &cfg.DrainTimeout)
+	env.durationVar("SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout)
+	if v, err := secrets.Get("ADMIN_TOKEN"); err == nil {
+		cfg.AdminToken = v
+	}
+	if v, err := secrets.Get("METRICS_TOKEN"); err == nil {
+		cfg.MetricsToken = v
+	}
+	if v, err := secrets.Get("API_KEYS"); err == nil {
+		cfg.APIKeys = gwmw.NewAPIKeys(splitList(v))
+	}
+	cfg.TrustedProxies = env.get("TRUSTED_PROXIES")
+	cfg.TrustedProxiesFile = env.get("TRUSTED_PROXIES_FILE")
+	env.stringVar("RATE_LIMIT_LOGIN", &cfg.RateLimitLogin)
+	env.stringVar("RATE_LIMIT_REGISTER", &cfg.RateLimitRegister)
+	env.stringVar("RATE_LIMIT_CONTACT", &cfg.RateLimitContact)
+	cfg.RateLimitAlgorithm = gwmw.RateAlgorithm(env.get("RATE_LIMIT_ALGORITHM"))
+	env.stringVar("RATE_LIMIT_STORE", &cfg.RateLimitStore)
+	env.stringVar("IDEMPOTENCY_STORE", &cfg.IdempotencyStore)
+	env.durationVar("IDEMPOTENCY_TTL", &cfg.IdempotencyTTL)
+	env.durationVar("IDEMPOTENCY_LOCK_TTL", &cfg.IdempotencyLockTTL)
+	env.int64Var("BODY_LIMIT_DEFAULT", &cfg.BodyLimitDefault)
+	env.int64Var("BODY_LIMIT_LOGIN", &cfg.BodyLimitLogin)
+	env.int64Var("BODY_LIMIT_REGISTER", &cfg.BodyLimitRegister)
+	env.int64Var("BODY_LIMIT_CONTACT", &cfg.BodyLimitContact)
+	cfg.RedisAddr = env.get("REDIS_ADDR")
+	cfg.RedisUsername = env.get("REDIS_USERNAME")
+	if v, err := secrets.Get("REDIS_PASSWORD"); err == nil {
+		cfg.RedisPassword = v
+	}
+	cfg.TLS = platform.TLSOptionsFrom("TLS", platform.LookupFunc(env))
+	cfg.ACME.Domains = splitList(env.get("ACME_DOMAINS"))
+	cfg.ACME.Email = env.get("ACME_EMAIL")
+	env.stringVar("ACME_CACHE_DIR", &cfg.ACME.CacheDir)
+	cfg.ACME.DirectoryURL = env.get("ACME_DIRECTORY_URL")
+	cfg.ACME.CAFile = env.get("ACME_CA_FILE")
+	env.boolVar("HTTPS_REDIRECT", &cfg.HTTPSRedirect)
+	env.intVar("HSTS_MAX_AGE", &cfg.HSTS.MaxAge)
+	env.boolVar("HSTS_INCLUDE_SUBDOMAINS", &cfg.HSTS.IncludeSubdomains)
+	env.boolVar("HSTS_PRELOAD", &cfg.HSTS.Preload)
+	env.durationVar("HTTP_READ_HEADER_TIMEOUT", &cfg.ReadHeaderTimeout)
+	env.durationVar("HTTP_IDLE_TIMEOUT", &cfg.IdleTimeout)
+	cfg.UpstreamTLS = platform.TLSOptionsFrom("UPSTREAM_TLS", platform.LookupFunc(env))
+	cfg.AuthGRPCIdentity = env.get("AUTH_GRPC_IDENTITY")
+	cfg.ContactGRPCIdentity = env.get("CONTACT_GRPC_IDENTITY")
+	cfg.Resilience.IdempotentMethods = []string{"/userpb.UserService/Login"}
+	env.intVar("BREAKER_FAILURE_THRESHOLD", &cfg.Resilience.BreakerThreshold)
+	env.durationVar("BREAKER_OPEN_TIMEOUT", &cfg.Resilience.BreakerOpenTimeout)
+	env.intVar("RETRY_MAX_ATTEMPTS", &cfg.Resilience.MaxAttempts)
+	env.durationVar("RETRY_BACKOFF_BASE", &cfg.Resilience.BackoffBase)
+	env.durationVar("RETRY_BACKOFF_MAX", &cfg.Resilience.BackoffMax)
+	if v := env.get("RETRY_BUDGET_RATIO"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.Resilience.BudgetRatio = f
+		}
+	}
+	env.intVar("RETRY_BUDGET_MIN", &cfg.Resilience.BudgetMin)
+	env.durationVar("HEDGE_DELAY", &cfg.Resilience.HedgeDelay)
+	if v, ok := env("UPSTREAM_IDEMPOTENT_METHODS"); ok {
+		cfg.Resilience.IdempotentMethods = splitList(v)
+	}
 	return cfg
 }
+
+func (c Config) validate() error {
+	if c.AuthGRPCAddr == "" || c.ContactGRPCAddr == "" || c.AuthHTTPBase == "" {
+		return errors.New("backend addresses must not be empty")
+	}
+	for _, p := range []gwmw.CORS{c.CORSPublic, c.CORSAPI, c.CORSAdmin} {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.AuthTimeout <= 0 || c.ContactTimeout <= 0 || c.AuthHTTPTimeout <= 0 {
+		return errors.New("timeouts must be positive")
+	}
+	if c.TLS.CertFile != "" && c.ACME.Enabled() {
+		return errors.New("TLS_CERT_FILE and ACME_DOMAINS are mutually exclusive")
+	}
+	if (c.TLS.CertFile != "" || c.ACME.Enabled()) && c.HTTPSPort == c.ServerPort {
+		return errors.New("HTTPS_PORT must differ from GATEWAY_PORT")
+	}
+	if err := c.Session.Validate(); err != nil {
+		return err
+	}
+	if c.BodyLimitDefault < 0 || c.BodyLimitLogin < 0 || c.BodyLimitRegister < 0 || c.BodyLimitContact < 0 {
+		return errors.New("BODY_LIMIT_* must not be negative")
+	}
+	if err := c.Balancing.Validate(); err != nil {
+		return err
+	}
+	for _, spec := range []string{c.RateLimitLogin, c.RateLimitRegister, c.RateLimitContact} {
+		if rateLimitEnabled(spec) {
+			if _, _, err := gwmw.ParseRate(spec); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := platform.LoadIPResolver(c.TrustedProxies, c.TrustedProxiesFile); err != nil {
+		return err
+	}
+	if _, err := gwmw.ParseAlgorithm(string(c.RateLimitAlgorithm)); err != nil {
+		return err
+	}
+	if c.Resilience.BreakerThreshold < 1 || c.Resilience.BreakerOpenTimeout <= 0 {
+		return errors.New("BREAKER_FAILURE_THRESHOLD and BREAKER_OPEN_TIMEOUT must be positive")
+	}
+	if c.Resilience.MaxAttempts < 1 || c.Resilience.BudgetRatio < 0 || c.Resilience.BudgetMin < 0 {
+		return errors.New("RETRY_MAX_ATTEMPTS must be >= 1 and retry budget must not be negative")
+	}
+	for _, st := range []struct{ env, value string }{
+		{"RATE_LIMIT_STORE", c.RateLimitStore},
+		{"IDEMPOTENCY_STORE", c.IdempotencyStore},
+		{"CACHE_STORE", c.CacheStore},
+	} {
+		switch st.value {
+		case "memory":
+		case "redis":
+			if c.RedisAddr == "" {
+				return fmt.Errorf("%s=redis requires REDIS_ADDR", st.env)
+			}
+		default:
+			return fmt.Errorf("unknown %s %q (want memory or redis)", st.env, st.value)
+		}
+	}
+	if c.IdempotencyTTL <= 0 || c.IdempotencyLockTTL <= 0 {
+		return errors.New("IDEMPOTENCY_TTL and IDEMPOTENCY_LOCK_TTL must be positive")
+	}
+	if c.CacheMaxEntries < 1 || c.CacheMaxBytes < 1 || c.Cache.DefaultTTL < 0 || c.Cache.DefaultSWR < 0 {
+		return errors.New("CACHE_MAX_ENTRIES and CACHE_MAX_BYTES must be positive, cache durations must not be negative")
+	}
+	return nil
+}
+
+// rateLimitEnabled: "" hoặc "off" = không giới hạn
+func rateLimitEnabled(spec string) bool {
+	return spec != "" && !strings.EqualFold(spec, "off")
+}
+
+// Mỗi nhóm đọc CORS_<GROUP>_{ORIGINS,METHODS,HEADERS,CREDENTIALS,MAX_AGE},
+// thiếu thì dùng giá trị chung CORS_ALLOWED_* (admin mặc định không cho origin nào)
+func loadCORS(env envLookup) (public, api, admin gwmw.CORS) {
+	base := gwmw.CORS{
+		Origins:     []string{"http://localhost:5173", "http://127.0.0.1:5173", "https://holoc.id.vn"},
+		Methods:     []string{"GET", "POST", "OPTIONS"},
+		Headers:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		Exposed:     []string{"Link", "Retry-After", "X-CSRF-Token", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
+		Credentials: true,
+		MaxAge:      300,
+	}
+	base = corsFromEnv(env, "CORS_ALLOWED", base)
+
+	adminBase := base
+	adminBase.Origins = nil
+	return corsFromEnv(env, "CORS_PUBLIC", base), corsFromEnv(env, "CORS_API", base), corsFromEnv(env, "CORS_ADMIN", adminBase)
+}
+
+func corsFromEnv(env envLookup, prefix string, c gwmw.CORS) gwmw.CORS {
+	if v, ok := env(prefix + "_ORIGINS"); ok {
+		c.Origins = splitList(v)
+	}
+	if v := env.get(prefix + "_METHODS"); v != "" {
+		c.Methods = splitList(v)
+	}
+	if v := env.get(prefix + "_HEADERS"); v != "" {
+		c.Headers = splitList(v)
+	}
+	if v := env.get(prefix + "_EXPOSED_HEADERS"); v != "" {
+		c.Exposed = splitList(v)
+	}
+	if v := env.get(prefix + "_CREDENTIALS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.Credentials = b
+		}
+	}
+	if v := env.get(prefix + "_MAX_AGE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.MaxAge = n
+		}
+	}
+	return c
+}
+
+// envLookup đọc một biến cấu hình, cùng kiểu với os.LookupEnv
+type envLookup func(key string) (string, bool)
+
+func (env envLookup) get(key string) string {
+	v, _ := env(key)
+	return v
+}
+
+func (env envLookup) stringVar(key string, dst *string) {
+	if v := strings.TrimSpace(env.get(key)); v != "" {
+		*dst = v
+	}
+}
+
+func (env envLookup) boolVar(key string, dst *bool) {
+	if v := env.get(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			*dst = b
+		}
+	}
+}
+
+func (env envLookup) intVar(key string, dst *int) {
+	if v := env.get(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			*dst = n
+		}
+	}
+}
+
+func (env envLookup) int64Var(key string, dst *int64) {
+	if v := env.get(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			*dst = n
+		}
+	}
+}
+
+func (env envLookup) durationVar(key string, dst *time.Duration) {
+	if v := env.get(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			*dst = d
+		}
+	}
+}
+
+// "a, b,,c" -> [a b c]
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withEnvFile ghi file .env tạm và reset trạng thái biến đã áp từ file sau test
+func withEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	writeFile(t, path, content)
+	t.Cleanup(func() {
+		fileEnvMu.Lock()
+		for k := range fileEnvKeys {
+			_ = os.Unsetenv(k)
+		}
+		fileEnvKeys = map[string]bool{}
+		fileEnvMu.Unlock()
+	})
+	return path
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadConfigInvalidLeavesEnvUntouched(t *testing.T) {
+	path := withEnvFile(t, "AUTH_TIMEOUT=3s\nRATE_LIMIT_STORE=memory\n")
+	t.Setenv("GATEWAY_CONFIG_FILE", path)
+	cfg := LoadConfig()
+	if cfg.AuthTimeout.String() != "3s" || os.Getenv("AUTH_TIMEOUT") != "3s" {
+		t.Fatalf("initial load: AuthTimeout=%v env=%q", cfg.AuthTimeout, os.Getenv("AUTH_TIMEOUT"))
+	}
+
+	writeFile(t, path, "AUTH_TIMEOUT=9s\nRATE_LIMIT_STORE=bogus\n")
+	if _, _, err := ReloadConfig(path); err == nil {
+		t.Fatal("want validation error for RATE_LIMIT_STORE=bogus")
+	}
+	if got := os.Getenv("AUTH_TIMEOUT"); got != "3s" {
+		t.Errorf("AUTH_TIMEOUT = %q after failed reload, want previous 3s", got)
+	}
+	if got := os.Getenv("RATE_LIMIT_STORE"); got != "memory" {
+		t.Errorf("RATE_LIMIT_STORE = %q after failed reload", got)
+	}
+}
+
+func TestReloadConfigUnsetsRemovedKeys(t *testing.T) {
+	path := withEnvFile(t, "AUTH_TIMEOUT=3s\nHEDGE_DELAY=50ms\n")
+	t.Setenv("GATEWAY_CONFIG_FILE", path)
+	LoadConfig()
+
+	writeFile(t, path, "AUTH_TIMEOUT=4s\n")
+	cfg, env, err := ReloadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Resilience.HedgeDelay != 0 || cfg.AuthTimeout.String() != "4s" {
+		t.Errorf("reloaded config: HedgeDelay=%v AuthTimeout=%v", cfg.Resilience.HedgeDelay, cfg.AuthTimeout)
+	}
+	if os.Getenv("AUTH_TIMEOUT") != "3s" {
+		t.Error("process env changed before apply")
+	}
+	env.apply()
+	if _, ok := os.LookupEnv("HEDGE_DELAY"); ok {
+		t.Error("HEDGE_DELAY removed from the file but still set")
+	}
+	if got := os.Getenv("AUTH_TIMEOUT"); got != "4s" {
+		t.Errorf("AUTH_TIMEOUT = %q, want 4s", got)
+	}
+}
+
+func TestConfigProcessEnvWinsOverFile(t *testing.T) {
+	t.Setenv("CONTACT_TIMEOUT", "11s")
+	path := withEnvFile(t, "CONTACT_TIMEOUT=2s\n")
+	t.Setenv("GATEWAY_CONFIG_FILE", path)
+	if cfg := LoadConfig(); cfg.ContactTimeout.String() != "11s" {
+		t.Errorf("load: ContactTimeout = %v, want process env value", cfg.ContactTimeout)
+	}
+	cfg, env, err := ReloadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.apply()
+	if cfg.ContactTimeout.String() != "11s" || os.Getenv("CONTACT_TIMEOUT") != "11s" {
+		t.Errorf("reload: ContactTimeout = %v env=%q", cfg.ContactTimeout, os.Getenv("CONTACT_TIMEOUT"))
+	}
+
+	writeFile(t, path, "")
+	_, env, _ = ReloadConfig(path)
+	env.apply()
+	if os.Getenv("CONTACT_TIMEOUT") != "11s" {
+		t.Error("process env variable unset because the file dropped it")
+	}
+}
+
+func TestCSRFCookieSecureConfig(t *testing.T) {
+	for _, tt := range []struct {
+		vars map[string]string
+		want bool
+	}{
+		{nil, true},
+		{map[string]string{"SESSION_COOKIE_SECURE": "false"}, false}, // dev qua http: một biến cho cả hai cookie
+		{map[string]string{"SESSION_COOKIE_SECURE": "false", "CSRF_COOKIE_SECURE": "true"}, true},
+		{map[string]string{"CSRF_COOKIE_SECURE": "false"}, false},
+	} {
+		cfg := configFrom("", func(key string) (string, bool) {
+			v, ok := tt.vars[key]
+			return v, ok
+		})
+		if cfg.CSRF.CookieSecure != tt.want {
+			t.Errorf("%v: CSRF.CookieSecure = %v, want %v", tt.vars, cfg.CSRF.CookieSecure, tt.want)
+		}
+	}
+}
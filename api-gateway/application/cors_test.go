@@ -0,0 +1,75 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCORSPreflightPerGroup(t *testing.T) {
+	routes := filepath.Join(t.TempDir(), "routes.yaml")
+	if err := os.WriteFile(routes, []byte(`
+upstreams:
+  auth-http: { http: "http://127.0.0.1:1" }
+routes:
+  - { method: POST, path: /forms/public, upstream: auth-http }
+  - { method: POST, path: /forms/api, upstream: auth-http, group: api, auth: true }
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a := newTestApp(t, map[string]string{
+		"CORS_PUBLIC_ORIGINS":     "https://holoc.id.vn,https://*.holoc.id.vn",
+		"CORS_API_ORIGINS":        "https://app.holoc.id.vn",
+		"CORS_API_CREDENTIALS":    "false",
+		"CORS_ADMIN_ORIGINS":      "https://admin.holoc.id.vn",
+		"CORS_ADMIN_CREDENTIALS":  "false",
+		"GATEWAY_ROUTES_FILE":     routes,
+		"CORS_PUBLIC_CREDENTIALS": "true",
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		allowed     bool
+		credentials bool
+	}{
+		{"public exact origin", "/auth/login", "https://holoc.id.vn", true, true},
+		{"public wildcard subdomain", "/contact/", "https://blog.holoc.id.vn", true, true},
+		{"public wildcard excludes apex lookalike", "/auth/login", "https://evilholoc.id.vn", false, false},
+		{"public disallowed origin", "/auth/register", "https://evil.example", false, false},
+		{"public transcoded route", "/v1/contact", "https://holoc.id.vn", true, true},
+		{"api allowed origin without credentials", "/api/me", "https://app.holoc.id.vn", true, false},
+		{"api rejects public origin", "/api/me", "https://holoc.id.vn", false, false},
+		{"admin allowed origin", "/admin/reload", "https://admin.holoc.id.vn", true, false},
+		{"admin rejects api origin", "/admin/reload", "https://app.holoc.id.vn", false, false},
+		{"route table public group", "/forms/public", "https://www.holoc.id.vn", true, true},
+		{"route table api group", "/forms/api", "https://app.holoc.id.vn", true, false},
+		{"route table api rejects public origin", "/forms/api", "https://www.holoc.id.vn", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			r.Header.Set("Access-Control-Request-Headers", "content-type")
+			w := a.serve(r)
+
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Fatalf("Allow-Origin = %q, want %q (status %d)", got, tt.origin, w.Code)
+			}
+			if !tt.allowed && got != "" {
+				t.Fatalf("Allow-Origin = %q for disallowed origin", got)
+			}
+			if cred := w.Header().Get("Access-Control-Allow-Credentials") == "true"; cred != tt.credentials {
+				t.Errorf("Allow-Credentials = %v, want %v", cred, tt.credentials)
+			}
+			if tt.allowed && w.Header().Get("Access-Control-Allow-Methods") == "" {
+				t.Error("preflight response without Allow-Methods")
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+)
+
+// cookieJar giữ cookie theo tên qua nhiều request (đủ cho test, bỏ qua Path/Domain)
+type cookieJar map[string]string
+
+func (j cookieJar) update(w *httptest.ResponseRecorder) {
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			delete(j, c.Name)
+		} else {
+			j[c.Name] = c.Value
+		}
+	}
+}
+
+func (j cookieJar) apply(r *http.Request) *http.Request {
+	for name, value := range j {
+		r.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return r
+}
+
+// login đăng nhập ở chế độ cookie rồi lấy token CSRF của phiên mới
+func login(t *testing.T, a *App) cookieJar {
+	t.Helper()
+	jar := cookieJar{}
+	w := a.serve(postJSON("/auth/login", `{"email":"a@b.vn","password":"secret"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	jar.update(w)
+	w = a.serve(jar.apply(httptest.NewRequest(http.MethodGet, "/auth/csrf", nil)))
+	jar.update(w)
+	if jar["csrf_token"] == "" || w.Header().Get(gwmw.CSRFHeader) != jar["csrf_token"] {
+		t.Fatalf("csrf token not issued: %v", jar)
+	}
+	return jar
+}
+
+func csrfPost(jar cookieJar, path, token string) *http.Request {
+	r := jar.apply(httptest.NewRequest(http.MethodPost, path, nil))
+	r.Header.Set(gwmw.CSRFHeader, token)
+	r.Header.Set("Origin", "http://example.com")
+	return r
+}
+
+func TestCSRFBoundToSession(t *testing.T) {
+	f := startFakeBackend(t)
+	vars := f.vars()
+	vars["SESSION_COOKIES"] = "true"
+	a := newTestApp(t, vars)
+
+	jar := login(t, a)
+	token := jar["csrf_token"]
+
+	// refresh xoay vòng cookie nhưng giữ sid: token CSRF vẫn dùng được cho lần sau
+	w := a.serve(csrfPost(jar, "/auth/refresh", token))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("refresh: %d %s", w.Code, w.Body)
+	}
+	jar.update(w)
+	if jar["csrf_token"] != token {
+		t.Errorf("csrf token rotated by refresh")
+	}
+
+	// token của phiên khác (kẻ tấn công đăng nhập tài khoản của mình) không dùng được
+	other := login(t, a)["csrf_token"]
+	if w := a.serve(csrfPost(jar, "/auth/refresh", other)); w.Code != http.StatusForbidden {
+		t.Errorf("refresh with other session's token: %d", w.Code)
+	}
+	jar["csrf_token"] = token
+
+	// thiếu Origin/Referer
+	r := csrfPost(jar, "/auth/logout", token)
+	r.Header.Del("Origin")
+	if w := a.serve(r); w.Code != http.StatusForbidden {
+		t.Errorf("logout without Origin: %d", w.Code)
+	}
+
+	if w := a.serve(csrfPost(jar, "/auth/logout", token)); w.Code != http.StatusNoContent {
+		t.Errorf("logout: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,26 @@
+package application
+
+import (
+	"sort"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+// newHealth gom trạng thái grpc.health.v1 của mọi backend gRPC thành báo cáo /readyz.
+// Mỗi backend tự kiểm tra Mongo/Redis/SMTP của nó nên gateway chỉ cần hỏi trạng thái tổng.
+func (b *backend) newHealth() *platform.Health {
+	h := platform.NewHealth("api-gateway",
+		platform.HealthCheck{Name: "auth", Check: client.HealthCheck(b.authConn, "")},
+		platform.HealthCheck{Name: "contact", Check: client.HealthCheck(b.contactConn, "")},
+	)
+	names := make([]string, 0, len(b.upstreamConns))
+	for name := range b.upstreamConns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Add(platform.HealthCheck{Name: "upstream:" + name, Check: client.HealthCheck(b.upstreamConns[name], "")})
+	}
+	return h
+}
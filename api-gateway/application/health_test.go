@@ -0,0 +1,40 @@
+package application
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReadyzAggregatesBackends(t *testing.T) {
+	f := startFakeBackend(t)
+	a := newTestApp(t, f.vars())
+
+	readyz := func() (int, platform.HealthReport) {
+		w := a.serve(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var rep platform.HealthReport
+		if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("body %s: %v", w.Body, err)
+		}
+		return w.Code, rep
+	}
+
+	if code, rep := readyz(); code != http.StatusOK || rep.Checks["auth"].Status != platform.HealthOK || rep.Checks["contact"].Status != platform.HealthOK {
+		t.Errorf("serving backends: %d %+v", code, rep)
+	}
+
+	f.Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	code, rep := readyz()
+	if code != http.StatusServiceUnavailable || rep.Status != platform.HealthFail || rep.Checks["auth"].Error == "" {
+		t.Errorf("not serving backend: %d %+v", code, rep)
+	}
+
+	// livez không phụ thuộc backend
+	if w := a.serve(httptest.NewRequest(http.MethodGet, "/livez", nil)); w.Code != http.StatusOK {
+		t.Errorf("livez = %d", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig xin + gia hạn cert tự động (Let's Encrypt hoặc CA ACME khác) cho Domains
+type ACMEConfig struct {
+	Domains      []string
+	Email        string
+	CacheDir     string         // lưu account key + cert (nên mount volume để restart không xin lại)
+	Cache        autocert.Cache // nil = autocert.DirCache(CacheDir); gắn cache khác (vd. dùng chung giữa instance) khi cần
+	DirectoryURL string         // rỗng = Let's Encrypt production; vd. https://localhost:14000/dir (Pebble)
+	CAFile       string         // CA tin cậy khi gọi DirectoryURL (Pebble dùng CA riêng)
+}
+
+func (c ACMEConfig) Enabled() bool { return len(c.Domains) > 0 }
+
+func (c ACMEConfig) manager() (*autocert.Manager, error) {
+	cache := c.Cache
+	if cache == nil {
+		if c.CacheDir == "" {
+			return nil, errors.New("ACME_CACHE_DIR must not be empty")
+		}
+		cache = autocert.DirCache(c.CacheDir)
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(c.Domains...),
+		Cache:      cache,
+		Email:      c.Email,
+	}
+	if c.DirectoryURL != "" || c.CAFile != "" {
+		cl := &acme.Client{DirectoryURL: c.DirectoryURL}
+		if c.CAFile != "" {
+			pem, err := os.ReadFile(c.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("acme CA: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("acme CA: no certificate found in %s", c.CAFile)
+			}
+			cl.HTTPClient = &http.Client{Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			}}
+		}
+		m.Client = cl
+	}
+	return m, nil
+}
+
+// httpsSetup là cấu hình phục vụ HTTPS, dựng một lần lúc khởi động
+type httpsSetup struct {
+	tls   *tls.Config
+	plain http.Handler              // handler của cổng HTTP (redirect / ACME http-01)
+	watch func(ctx context.Context) // đọc lại cert file khi đổi (nil với ACME)
+}
+
+// newHTTPS: nil nếu không cấu hình TLS_CERT_FILE hoặc ACME_DOMAINS
+func (a *App) newHTTPS() (*httpsSetup, error) {
+	var plain http.Handler = a
+	if a.cfg.HTTPSRedirect {
+		plain = redirectHTTPS(a.cfg.HTTPSPort, a)
+	}
+
+	switch {
+	case a.cfg.ACME.Enabled():
+		m, err := a.cfg.ACME.manager()
+		if err != nil {
+			return nil, err
+		}
+		// HTTPHandler trả lời challenge http-01, request khác đi tiếp vào plain
+		return &httpsSetup{tls: m.TLSConfig(), plain: m.HTTPHandler(plain)}, nil
+
+	case a.cfg.TLS.CertFile != "":
+		r, err := platform.NewCertReloader(a.cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		return &httpsSetup{tls: r.ServerConfig(), plain: plain, watch: r.Watch}, nil
+	}
+	return nil, nil
+}
+
+// redirectHTTPS chuyển request HTTP sang HTTPS (308 giữ nguyên method + body).
+// Healthcheck vẫn trả lời trên HTTP để probe không cần cert.
+func redirectHTTPS(port uint16, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/livez", "/readyz", "/healthz":
+			next.ServeHTTP(w, r)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(int(port)))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
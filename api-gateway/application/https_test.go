@@ -0,0 +1,133 @@
+package application
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/acmetest"
+)
+
+// serveTLS phục vụ a qua TLS với cfg trên cổng loopback, trả về địa chỉ
+func serveTLS(t *testing.T, cfg *tls.Config, h http.Handler) string {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: h}
+	go srv.Serve(lis)
+	t.Cleanup(func() { srv.Close() })
+	return lis.Addr().String()
+}
+
+func TestACMEIssuesCertificate(t *testing.T) {
+	// CA ACME giả chạy cục bộ qua HTTPS với cert tự ký (như Pebble), chỉ hỗ trợ http-01
+	ca := acmetest.NewCAServer(t).ChallengeTypes("http-01").StartTLS()
+	caFile := filepath.Join(t.TempDir(), "acme-ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate().Raw}), 0o600)
+	cacheDir := t.TempDir()
+
+	a := newTestApp(t, map[string]string{
+		"ACME_DOMAINS":       "gw.example.org",
+		"ACME_EMAIL":         "ops@example.org",
+		"ACME_CACHE_DIR":     cacheDir,
+		"ACME_DIRECTORY_URL": ca.URL(),
+		"ACME_CA_FILE":       caFile,
+	})
+	setup, err := a.newHTTPS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup.watch != nil {
+		t.Error("ACME setup has a cert file watcher")
+	}
+	// CA kiểm tra challenge http-01 qua handler của cổng HTTP
+	ca.ResolveHandler("gw.example.org", setup.plain)
+
+	addr := serveTLS(t, setup.tls, a)
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: "gw.example.org", RootCAs: ca.Roots()})
+	if err != nil {
+		t.Fatalf("TLS handshake with ACME certificate: %v", err)
+	}
+	leaf := conn.ConnectionState().PeerCertificates[0]
+	conn.Close()
+	if err := leaf.VerifyHostname("gw.example.org"); err != nil {
+		t.Error(err)
+	}
+
+	// cert + account key nằm trong cache để restart không xin lại
+	entries, _ := os.ReadDir(cacheDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !slices.Contains(names, "gw.example.org") || !slices.Contains(names, "acme_account+key") {
+		t.Errorf("cache dir = %v, want certificate and account key", names)
+	}
+
+	// tên không nằm trong ACME_DOMAINS: không xin cert
+	if _, err := tls.Dial("tcp", addr, &tls.Config{ServerName: "other.example.org", RootCAs: ca.Roots()}); err == nil {
+		t.Error("handshake for a domain outside ACME_DOMAINS succeeded")
+	}
+}
+
+func TestACMEUntrustedDirectory(t *testing.T) {
+	// không có ACME_CA_FILE: cert tự ký của CA giả không được tin, không xin được cert
+	ca := acmetest.NewCAServer(t).ChallengeTypes("http-01").StartTLS()
+	a := newTestApp(t, map[string]string{
+		"ACME_DOMAINS":       "gw.example.org",
+		"ACME_CACHE_DIR":     t.TempDir(),
+		"ACME_DIRECTORY_URL": ca.URL(),
+	})
+	setup, err := a.newHTTPS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTLS(t, setup.tls, a)
+	if _, err := tls.Dial("tcp", addr, &tls.Config{ServerName: "gw.example.org", InsecureSkipVerify: true}); err == nil {
+		t.Error("certificate issued by an untrusted ACME directory")
+	}
+}
+
+func TestACMEConfigErrors(t *testing.T) {
+	if _, err := (ACMEConfig{Domains: []string{"a"}}).manager(); err == nil {
+		t.Error("manager without cache dir succeeded")
+	}
+	if _, err := (ACMEConfig{Domains: []string{"a"}, CacheDir: t.TempDir(), CAFile: filepath.Join(t.TempDir(), "missing")}).manager(); err == nil {
+		t.Error("manager with missing CA file succeeded")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.pem")
+	os.WriteFile(bad, []byte("not a certificate"), 0o600)
+	if _, err := (ACMEConfig{Domains: []string{"a"}, CacheDir: t.TempDir(), CAFile: bad}).manager(); err == nil {
+		t.Error("manager with CA file without certificates succeeded")
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	cases := []struct {
+		port       uint16
+		host, path string
+		code       int
+		location   string
+	}{
+		{443, "holoc.id.vn:8080", "/api/contact?x=1", http.StatusPermanentRedirect, "https://holoc.id.vn/api/contact?x=1"},
+		{8443, "holoc.id.vn", "/", http.StatusPermanentRedirect, "https://holoc.id.vn:8443/"},
+		{443, "holoc.id.vn", "/readyz", http.StatusNoContent, ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, c.path, nil)
+		r.Host = c.host
+		w := httptest.NewRecorder()
+		redirectHTTPS(c.port, next).ServeHTTP(w, r)
+		if w.Code != c.code || w.Header().Get("Location") != c.location {
+			t.Errorf("%s%s: %d %q, want %d %q", c.host, c.path, w.Code, w.Header().Get("Location"), c.code, c.location)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package application
+
+import (
+	"net/http"
+
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+)
+
+// idempotency dựng middleware Idempotency-Key cho route name; đặt sau BodyLimit (đọc body
+// để lấy fingerprint) và sau JWT nếu có. Key tách theo user khi đã đăng nhập để các client không
+// chiếm trước hay nhận lại response đã lưu của nhau. Khách thì tách theo cookie CSRF đã ký (trình
+// duyệt), không có thì theo hash của body, body rỗng mới theo IP client: retry từ IP khác (đổi mạng,
+// qua NAT khác) vẫn được phát lại, còn khách khác dùng trùng key chỉ đụng nhau khi gửi đúng cùng body.
+func (a *App) idempotency(b *backend, name string) func(http.Handler) http.Handler {
+	return gwmw.Idempotency{
+		Name:    name,
+		Store:   a.idemStore,
+		TTL:     b.cfg.IdempotencyTTL,
+		LockTTL: b.cfg.IdempotencyLockTTL,
+		Scope:   gwmw.KeyUser(b.cfg.CSRF.Key(gwmw.KeyBody(gwmw.KeyIP))),
+	}.Middleware
+}
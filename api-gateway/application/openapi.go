@@ -0,0 +1,225 @@
+package application
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/handler"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/openapi"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+const specVersion = "1.0.0"
+
+// buildSpec mô tả các route viết tay trong loadRoutes và các route transcode /v1.
+// Route mới thêm vào loadRoutes mà không khai báo ở đây sẽ làm TestSpecCoversRoutes (openapi_test.go) thất bại.
+func buildSpec() (*openapi.Document, error) {
+	doc := openapi.New("WebPersonal API Gateway", specVersion)
+	doc.AddTag("auth", "Đăng nhập / đăng ký (auth-service)")
+	doc.AddTag("contact", "Form liên hệ (contact-service)")
+	doc.AddTag("api", "API cần JWT")
+	doc.AddTag("admin", "Quản trị gateway (ADMIN_TOKEN)")
+	doc.AddTag("routes", "Route khai báo trong GATEWAY_ROUTES_FILE")
+	doc.AddTag("meta", "Healthcheck và tài liệu")
+	doc.AddSecurity("bearerAuth", openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+	doc.AddSecurity("cookieAuth", openapi.SecurityScheme{
+		Type: "apiKey", In: "cookie", Name: handler.DefaultSession().AccessCookie,
+		Description: "Access cookie HttpOnly khi bật SESSION_COOKIES (tên theo SESSION_ACCESS_COOKIE)",
+	})
+	doc.AddSecurity("adminToken", openapi.SecurityScheme{Type: "http", Scheme: "bearer", Description: "ADMIN_TOKEN"})
+
+	problem := doc.Schema("Problem", util.Problem{})
+	errs := func(codes ...int) map[string]openapi.Response {
+		out := map[string]openapi.Response{}
+		for _, c := range codes {
+			out[strconv.Itoa(c)] = openapi.ProblemResponse(http.StatusText(c), problem)
+		}
+		return out
+	}
+	with := func(op *openapi.Operation, more map[string]openapi.Response) *openapi.Operation {
+		for k, v := range more {
+			op.Responses[k] = v
+		}
+		return op
+	}
+	idempotencyKey := idempotencyParam()
+	bearer := []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+	admin := []map[string][]string{{"adminToken": {}}}
+
+	healthReport := doc.Schema("HealthReport", platform.HealthReport{})
+	doc.Add(http.MethodGet, "/livez", &openapi.Operation{
+		Summary: "Liveness", Tags: []string{"meta"},
+		Description: "Chỉ báo process còn sống, không kiểm tra backend.",
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("OK", healthReport)},
+	})
+	doc.Add(http.MethodGet, "/healthz", &openapi.Operation{
+		Summary: "Liveness (tên cũ của /livez)", Tags: []string{"meta"},
+		Responses: map[string]openapi.Response{"200": openapi.JSONResponse("OK", healthReport)},
+	})
+	doc.Add(http.MethodGet, "/readyz", &openapi.Operation{
+		Summary: "Readiness", Tags: []string{"meta"},
+		Description: "Gọi grpc.health.v1 trên auth, contact và các upstream gRPC của route table; mỗi backend là một mục trong checks.",
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Mọi backend sẵn sàng", healthReport),
+			"503": openapi.JSONResponse("Có backend không sẵn sàng", healthReport),
+		},
+	})
+	doc.Add(http.MethodGet, "/metrics", &openapi.Operation{
+		Summary: "Prometheus metrics", Tags: []string{"meta"},
+		Description: "Yêu cầu Bearer METRICS_TOKEN nếu được cấu hình.",
+		Responses:   map[string]openapi.Response{"200": {Description: "Prometheus text format"}, "401": {Description: "Unauthorized"}},
+	})
+	doc.Add(http.MethodGet, "/openapi.json", &openapi.Operation{
+		Summary: "Tài liệu OpenAPI này", Tags: []string{"meta"},
+		Responses: map[string]openapi.Response{"200": {Description: "OpenAPI document"}},
+	})
+	doc.Add(http.MethodGet, "/docs", &openapi.Operation{
+		Summary: "Swagger UI", Tags: []string{"meta"},
+		Responses: map[string]openapi.Response{"200": {Description: "HTML"}},
+	})
+
+	doc.Add(http.MethodGet, "/auth/csrf", &openapi.Operation{
+		Summary: "Token CSRF", OperationID: "csrfToken", Tags: []string{"auth"},
+		Description: "Cấp (hoặc trả lại) token double-submit; client xác thực bằng cookie gửi token trong header X-CSRF-Token " +
+			"cho request POST/PUT/PATCH/DELETE, thiếu hoặc sai thì 403 CSRF_TOKEN_INVALID.",
+		Responses: map[string]openapi.Response{"200": openapi.JSONResponse("OK", &openapi.Schema{
+			Type: "object", Properties: map[string]*openapi.Schema{"csrf_token": {Type: "string"}}, Required: []string{"csrf_token"},
+		})},
+	})
+	doc.Add(http.MethodPost, "/auth/login", with(&openapi.Operation{
+		Summary: "Đăng nhập", OperationID: "login", Tags: []string{"auth"},
+		Description: "Khi bật SESSION_COOKIES, token không có trong body mà được đặt trong cookie HttpOnly " +
+			"(access + refresh); dùng /auth/refresh và /auth/logout.",
+		RequestBody: openapi.JSONBody(doc.Schema("LoginInput", client.LoginInput{})),
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("OK", doc.Schema("LoginResult", client.LoginResult{}))},
+	}, errs(400, 401, 403, 413, 415, 429, 500, 503)))
+	doc.Add(http.MethodPost, "/auth/refresh", with(&openapi.Operation{
+		Summary: "Làm mới phiên cookie", OperationID: "refresh", Tags: []string{"auth"},
+		Description: "Chỉ có khi bật SESSION_COOKIES: đổi refresh cookie lấy access + refresh cookie mới. Cần X-CSRF-Token.",
+		Responses:   map[string]openapi.Response{"204": {Description: "Đã đặt cookie mới"}},
+	}, errs(401, 403, 404, 429)))
+	doc.Add(http.MethodPost, "/auth/logout", with(&openapi.Operation{
+		Summary: "Đăng xuất (chế độ cookie)", OperationID: "logout", Tags: []string{"auth"},
+		Description: "Chỉ có khi bật SESSION_COOKIES: xoá access và refresh cookie. Cần X-CSRF-Token.",
+		Responses:   map[string]openapi.Response{"204": {Description: "Đã xoá cookie"}},
+	}, errs(403, 404)))
+	doc.Add(http.MethodPost, "/auth/register", with(&openapi.Operation{
+		Summary: "Đăng ký", OperationID: "register", Tags: []string{"auth"},
+		Description: "Kiểm tra body rồi forward HTTP sang auth-service; response giữ nguyên của auth-service.",
+		RequestBody: openapi.JSONBody(doc.Schema("RegisterInput", handler.RegisterInput{})),
+		Responses: map[string]openapi.Response{
+			"201": {Description: "Created"},
+			"409": openapi.ProblemResponse("Email đã tồn tại", problem),
+		},
+	}, errs(400, 403, 413, 415, 422, 429, 502, 503)))
+	doc.Add(http.MethodPost, "/contact/", with(&openapi.Operation{
+		Summary: "Gửi form liên hệ", OperationID: "submitContact", Tags: []string{"contact"},
+		Parameters:  []openapi.Parameter{idempotencyKey},
+		RequestBody: openapi.JSONBody(doc.Schema("ContactSubmitInput", client.ContactSubmitInput{})),
+		Responses:   map[string]openapi.Response{"201": openapi.JSONResponse("Created", doc.Schema("ContactSubmitResult", client.ContactSubmitResult{}))},
+	}, errs(400, 403, 409, 413, 415, 422, 429, 500, 503)))
+
+	// /v1/*: sinh từ annotation google.api.http giống transcoder
+	if err := doc.AddHTTPRules("userpb.UserService", "auth", errs(400, 401, 403, 409, 500, 503)); err != nil {
+		return nil, err
+	}
+	if err := doc.AddHTTPRules("contactpb.ContactService", "contact", errs(400, 403, 500, 503)); err != nil {
+		return nil, err
+	}
+
+	doc.Add(http.MethodGet, "/api/me", with(&openapi.Operation{
+		Summary: "User id trong token", OperationID: "me", Tags: []string{"api"}, Security: bearer,
+		Responses: map[string]openapi.Response{"200": openapi.JSONResponse("OK", &openapi.Schema{
+			Type: "object", Properties: map[string]*openapi.Schema{"user_id": {Type: "string"}}, Required: []string{"user_id"},
+		})},
+	}, errs(401)))
+
+	doc.Add(http.MethodGet, "/admin/reload", with(&openapi.Operation{
+		Summary: "Trạng thái reload cấu hình", Tags: []string{"admin"}, Security: admin,
+		Responses: map[string]openapi.Response{"200": openapi.JSONResponse("OK", doc.Schema("ReloadStatus", reloadReport{}))},
+	}, errs(401, 404)))
+	doc.Add(http.MethodPost, "/admin/reload", with(&openapi.Operation{
+		Summary: "Reload cấu hình ngay", Tags: []string{"admin"}, Security: admin,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("OK", doc.Schema("ReloadStatus", reloadReport{})),
+			"500": openapi.JSONResponse("Reload lỗi, cấu hình cũ vẫn được dùng", doc.Schema("ReloadStatus", reloadReport{})),
+		},
+	}, errs(401, 404)))
+	doc.Add(http.MethodGet, "/admin/upstreams", with(&openapi.Operation{
+		Summary: "Trạng thái circuit breaker và retry budget", Tags: []string{"admin"}, Security: admin,
+		Description: "Mỗi upstream (auth, auth-http, contact, upstream của route table) một mục; gồm các upstream đã cấu hình.",
+		Responses: map[string]openapi.Response{"200": openapi.JSONResponse("OK", &openapi.Schema{
+			Type: "array", Items: doc.Schema("UpstreamReport", client.UpstreamReport{}),
+		})},
+	}, errs(401, 404)))
+	doc.Add(http.MethodPost, "/admin/cache/purge", with(&openapi.Operation{
+		Summary: "Xoá response cache", Tags: []string{"admin"}, Security: admin,
+		Description: "Xoá các response đã cache có path bắt đầu bằng prefix (path hoặc URL đầy đủ); prefix rỗng xoá toàn bộ.",
+		RequestBody: openapi.JSONBody(doc.Schema("CachePurgeInput", CachePurgeInput{})),
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("OK", doc.Schema("CachePurgeResult", CachePurgeResult{}))},
+	}, errs(400, 401, 404, 413, 415, 500)))
+
+	return doc, nil
+}
+
+// describeRoute thêm một route của GATEWAY_ROUTES_FILE vào tài liệu
+func describeRoute(doc *openapi.Document, rt Route, h http.Handler) {
+	op := &openapi.Operation{
+		Summary:   rt.Method + " " + rt.Path + " -> " + rt.Upstream,
+		Tags:      []string{"routes"},
+		Responses: map[string]openapi.Response{},
+	}
+	if rt.Auth {
+		op.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+	}
+	problem := doc.Schema("Problem", util.Problem{})
+	for _, c := range []int{400, 500, 503} {
+		op.Responses[strconv.Itoa(c)] = openapi.ProblemResponse(http.StatusText(c), problem)
+	}
+	if rt.RateLimit != "" {
+		op.Responses["429"] = openapi.ProblemResponse(http.StatusText(429), problem)
+	}
+	if rt.Idempotency {
+		op.Parameters = append(op.Parameters, idempotencyParam())
+		op.Responses["409"] = openapi.ProblemResponse(http.StatusText(409), problem)
+		op.Responses["422"] = openapi.ProblemResponse(http.StatusText(422), problem)
+	}
+	if rt.Cache {
+		op.Description = "Response được cache theo Cache-Control của upstream (header X-Cache: HIT | STALE | MISS). "
+	}
+	if rt.Method != http.MethodGet && rt.Method != http.MethodHead {
+		// CSRF (chỉ với client xác thực bằng cookie)
+		op.Responses["403"] = openapi.ProblemResponse(http.StatusText(403), problem)
+	}
+
+	status := strconv.Itoa(http.StatusOK)
+	if fwd, ok := h.(*handler.GRPCForward); ok {
+		if rt.Status != 0 {
+			status = strconv.Itoa(rt.Status)
+		}
+		if rt.Method != http.MethodGet && rt.Method != http.MethodDelete {
+			op.RequestBody = openapi.JSONBody(doc.ProtoSchema(fwd.Method.Input()))
+			op.Responses["413"] = openapi.ProblemResponse(http.StatusText(413), problem)
+			op.Responses["415"] = openapi.ProblemResponse(http.StatusText(415), problem)
+		}
+		op.Description += "gRPC " + string(fwd.Method.FullName())
+		op.Responses[status] = openapi.JSONResponse("OK", doc.ProtoSchema(fwd.Method.Output()))
+	} else {
+		op.Description += "Forward HTTP sang upstream " + rt.Upstream
+		op.Responses[status] = openapi.Response{Description: "Response của upstream"}
+	}
+	doc.Add(rt.Method, rt.Path, op)
+}
+
+// idempotencyParam mô tả header Idempotency-Key (middleware.Idempotency)
+func idempotencyParam() openapi.Parameter {
+	maxLen := 255
+	return openapi.Parameter{
+		Name: gwmw.IdempotencyHeader, In: "header", Schema: &openapi.Schema{Type: "string", MaxLength: &maxLen},
+		Description: "Retry cùng key + cùng body nhận lại response đã lưu (Idempotent-Replayed: true); " +
+			"key đang xử lý 409, key dùng lại với body khác 422",
+	}
+}
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+// TestSpecCoversRoutes duyệt router và báo các route chưa được mô tả trong buildSpec
+func TestSpecCoversRoutes(t *testing.T) {
+	routes := filepath.Join(t.TempDir(), "routes.yaml")
+	if err := os.WriteFile(routes, []byte(`
+upstreams:
+  auth-http: { http: "http://127.0.0.1:1" }
+  contact-grpc: { grpc: "127.0.0.1:1" }
+routes:
+  - { method: POST, path: /forms/contact, upstream: contact-grpc, grpc_method: contactpb.ContactService/Submit, idempotency_key: true }
+  - { method: GET, path: "/posts/{slug}", upstream: auth-http, cache: true }
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, vars := range map[string]map[string]string{
+		"default":     nil,
+		"cookie mode": {"SESSION_COOKIES": "true"},
+		"http cache":  {"HTTP_CACHE": "true"},
+		"route table": {"GATEWAY_ROUTES_FILE": routes, "HTTP_CACHE": "true"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := newTestApp(t, vars).current.Load()
+			missing, err := b.spec.Missing(b.router.(chi.Routes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(missing) > 0 {
+				t.Errorf("routes missing from spec (see application/openapi.go): %s", strings.Join(missing, ", "))
+			}
+		})
+	}
+}
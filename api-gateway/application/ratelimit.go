@@ -0,0 +1,20 @@
+package application
+
+import (
+	"net/http"
+
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+)
+
+// rateLimit dựng middleware giới hạn theo IP cho route viết sẵn trong loadRoutes.
+// spec đã được validate cùng Config; "off" hoặc rỗng = không giới hạn.
+func (a *App) rateLimit(b *backend, name, spec string) func(http.Handler) http.Handler {
+	if !rateLimitEnabled(spec) {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	rl, _ := gwmw.NewRateLimit(spec, gwmw.KeyIP)
+	rl.Name = name
+	rl.Algorithm, _ = gwmw.ParseAlgorithm(string(b.cfg.RateLimitAlgorithm))
+	rl.Store = a.rateStore
+	return rl.Middleware
+}
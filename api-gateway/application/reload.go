@@ -0,0 +1,201 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+)
+
+// Reload đọc lại cấu hình, dựng router + client mới rồi swap atomically.
+// Thế hệ cũ được đóng sau khi các request đang chạy trên nó hoàn tất.
+func (a *App) Reload() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	// process env chỉ đổi khi cấu hình mới hợp lệ và thế hệ mới đã dựng xong
+	cfg, env, err := ReloadConfig(a.cfg.ConfigFile)
+	if err == nil {
+		cfg.ServerPort = a.cfg.ServerPort // đổi port cần restart
+		var b *backend
+		if b, err = a.newBackend(cfg); err == nil {
+			env.apply()
+			old := a.current.Swap(b)
+			go a.retire(old, cfg.DrainTimeout)
+		}
+	}
+	a.status.record(cfg, err)
+	if err != nil {
+		slog.Error("config reload failed", "error", err)
+		return err
+	}
+	slog.Info("config reloaded", "auth", cfg.AuthGRPCAddr, "contact", cfg.ContactGRPCAddr, "origins", cfg.CORSPublic.Origins)
+	return nil
+}
+
+func (a *App) retire(old *backend, timeout time.Duration) {
+	if !old.inflight.drain(timeout) {
+		slog.Warn("drain timeout, closing old connections anyway", "timeout", timeout.String())
+	}
+	_ = old.close()
+}
+
+// watchReload reload khi nhận SIGHUP hoặc khi file cấu hình đổi mtime
+func (a *App) watchReload(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if a.cfg.ConfigWatch > 0 {
+		t := time.NewTicker(a.cfg.ConfigWatch)
+		defer t.Stop()
+		tick = t.C
+	}
+	lastMod := modTime(a.cfg.ConfigFile)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("SIGHUP received, reloading")
+			_ = a.Reload()
+		case <-tick:
+			if m := modTime(a.cfg.ConfigFile); !m.Equal(lastMod) {
+				lastMod = m
+				slog.Info("config file changed, reloading", "file", a.cfg.ConfigFile)
+				_ = a.Reload()
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// GET /admin/reload: trạng thái lần reload gần nhất
+func (a *App) reloadStatusHandler(w http.ResponseWriter, r *http.Request) {
+	util.JSON(w, http.StatusOK, a.status.snapshot())
+}
+
+// POST /admin/reload: reload ngay
+func (a *App) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if err := a.Reload(); err != nil {
+		util.JSON(w, http.StatusInternalServerError, a.status.snapshot())
+		return
+	}
+	util.JSON(w, http.StatusOK, a.status.snapshot())
+}
+
+type reloadStatus struct {
+	mu         sync.Mutex
+	generation int
+	at         time.Time
+	err        error
+	cfg        Config
+}
+
+type reloadReport struct {
+	Generation      int       `json:"generation"`
+	LastReload      time.Time `json:"last_reload"`
+	OK              bool      `json:"ok"`
+	Error           string    `json:"error,omitempty"`
+	AuthGRPCAddr    string    `json:"auth_grpc_addr"`
+	AuthHTTPBase    string    `json:"auth_http_base"`
+	ContactGRPCAddr string    `json:"contact_grpc_addr"`
+	CORSOrigins     struct {
+		Public []string `json:"public"`
+		API    []string `json:"api"`
+		Admin  []string `json:"admin"`
+	} `json:"cors_origins"`
+}
+
+func (s *reloadStatus) record(cfg Config, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.at = time.Now()
+	s.err = err
+	if err == nil {
+		s.generation++
+		s.cfg = cfg
+	}
+}
+
+func (s *reloadStatus) snapshot() reloadReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rep := reloadReport{
+		Generation:      s.generation,
+		LastReload:      s.at,
+		OK:              s.err == nil,
+		AuthGRPCAddr:    s.cfg.AuthGRPCAddr,
+		AuthHTTPBase:    s.cfg.AuthHTTPBase,
+		ContactGRPCAddr: s.cfg.ContactGRPCAddr,
+	}
+	rep.CORSOrigins.Public = s.cfg.CORSPublic.Origins
+	rep.CORSOrigins.API = s.cfg.CORSAPI.Origins
+	rep.CORSOrigins.Admin = s.cfg.CORSAdmin.Origins
+	if s.err != nil {
+		rep.Error = s.err.Error()
+	}
+	return rep
+}
+
+// inflight đếm request đang chạy trên một thế hệ backend
+type inflight struct {
+	mu       sync.Mutex
+	n        int
+	draining bool
+	done     chan struct{}
+}
+
+func (f *inflight) acquire() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draining {
+		return false
+	}
+	f.n++
+	return true
+}
+
+func (f *inflight) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n--
+	if f.draining && f.n == 0 {
+		close(f.done)
+	}
+}
+
+// drain chặn request mới và chờ request cũ xong; false nếu hết timeout
+func (f *inflight) drain(timeout time.Duration) bool {
+	f.mu.Lock()
+	f.draining = true
+	if f.n == 0 {
+		f.mu.Unlock()
+		return true
+	}
+	f.done = make(chan struct{})
+	done := f.done
+	f.mu.Unlock()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
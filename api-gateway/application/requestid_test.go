@@ -0,0 +1,40 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+func TestRequestIDPropagation(t *testing.T) {
+	f := startFakeBackend(t)
+	var httpID string
+	authHTTP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpID = r.Header.Get(platform.RequestIDHeader)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer authHTTP.Close()
+	vars := f.vars()
+	vars["AUTH_HTTP_BASE"] = authHTTP.URL
+	a := newTestApp(t, vars)
+
+	r := postJSON("/contact/", `{"name":"Loc","email":"a@b.vn","message":"hello"}`)
+	r.Header.Set(platform.RequestIDHeader, "trace-contact")
+	if w := a.serve(r); w.Code != http.StatusCreated || w.Header().Get(platform.RequestIDHeader) != "trace-contact" {
+		t.Fatalf("contact: status %d, X-Request-ID %q", w.Code, w.Header().Get(platform.RequestIDHeader))
+	}
+	if got, _ := f.requestID.Load().(string); got != "trace-contact" {
+		t.Errorf("gRPC metadata x-request-id = %q", got)
+	}
+
+	r = postJSON("/auth/register", `{"email":"a@b.vn","password":"secret123"}`)
+	r.Header.Set(platform.RequestIDHeader, "trace-register")
+	if w := a.serve(r); w.Code != http.StatusCreated {
+		t.Fatalf("register: status %d body %s", w.Code, w.Body)
+	}
+	if httpID != "trace-register" {
+		t.Errorf("forwarded X-Request-ID = %q", httpID)
+	}
+}
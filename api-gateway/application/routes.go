@@ -3,40 +3,146 @@ package application
 import (
 	"net/http"
 
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/handler"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/openapi"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/cors"
 )
 
-func (a *App) loadRoutes() {
+func (a *App) loadRoutes(b *backend) error {
+	spec, err := buildSpec()
+	if err != nil {
+		return err
+	}
+	// tag validate sai thì báo lỗi lúc khởi động / reload, không đợi tới request đầu tiên
+	if err := util.RegisterValidation(client.LoginInput{}, handler.RegisterInput{}, client.ContactSubmitInput{}, CachePurgeInput{}); err != nil {
+		return err
+	}
+
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(platform.RequestID)
+	r.Use(b.cfg.HSTS.Middleware) // chỉ gắn khi request đi qua TLS
+	r.Use(b.cfg.Security.Middleware)
+	r.Use(b.ips.Middleware) // IP client cho rate limit, log, remote_ip (TRUSTED_PROXIES)
+	r.Use(platform.HTTPTracing("api-gateway"))
+	r.Use(platform.RequestLogger)
+	r.Use(platform.HTTPMetrics)
 	r.Use(middleware.Recoverer)
+	r.Use(gwmw.BodyLimit(b.cfg.BodyLimitDefault))
+	if b.cfg.Compression {
+		r.Use(b.cfg.Compress.Middleware)
+	}
+	if b.cfg.ETag {
+		r.Use(gwmw.ETag{}.Middleware) // sau Compress: ETag tính trên body chưa nén
+	}
 
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://127.0.0.1:5173", "https://holoc.id.vn"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
-
-	// healthcheck
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		util.Error(w, http.StatusNotFound, "")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		util.Error(w, http.StatusMethodNotAllowed, "")
 	})
 
-	// auth proxy
-	r.Route("/auth", func(rt chi.Router) {
-		rt.Post("/login", a.AuthHandler.Login)       // gRPC -> auth
-		rt.Post("/register", a.AuthHandler.Register) // HTTP -> auth
+	// healthcheck (application/health.go); /healthz giữ lại cho healthcheck cũ
+	r.Get("/healthz", b.health.Livez)
+	r.Get("/livez", b.health.Livez)
+	r.Get("/readyz", b.health.Readyz)
+
+	r.Method(http.MethodGet, "/metrics", platform.MetricsHandler(b.cfg.MetricsToken))
+
+	// middleware riêng của từng route ghi dữ liệu; route transcode /v1 tương ứng dùng lại
+	// cùng chuỗi (và cùng bucket rate limit / không gian Idempotency-Key)
+	loginMW := chi.Chain(a.rateLimit(b, "auth.login", b.cfg.RateLimitLogin), gwmw.BodyLimit(b.cfg.BodyLimitLogin))
+	// đăng ký không dùng Idempotency-Key: email trùng đã trả 409, và response của auth-service
+	// (có thể kèm Set-Cookie) không nên được lưu lại để phát lại cho request ẩn danh
+	registerMW := chi.Chain(a.rateLimit(b, "auth.register", b.cfg.RateLimitRegister), gwmw.BodyLimit(b.cfg.BodyLimitRegister))
+	contactMW := chi.Chain(a.rateLimit(b, "contact.submit", b.cfg.RateLimitContact), gwmw.BodyLimit(b.cfg.BodyLimitContact),
+		a.idempotency(b, "contact.submit"))
+
+	// public: form liên hệ + đăng nhập/đăng ký
+	r.Group(func(pub chi.Router) {
+		pub.Use(b.cfg.CORSPublic.Middleware)
+		pub.Use(b.cfg.CSRF.Middleware) // chỉ kiểm tra request xác thực bằng cookie
+
+		// auth proxy
+		pub.Route("/auth", func(rt chi.Router) {
+			rt.Get("/csrf", b.cfg.CSRF.TokenHandler)                         // token cho frontend khác domain
+			rt.With(loginMW...).Post("/login", b.AuthHandler.Login)          // gRPC -> auth
+			rt.With(registerMW...).Post("/register", b.AuthHandler.Register) // HTTP -> auth
+			// chế độ cookie (SESSION_COOKIES)
+			rt.With(a.rateLimit(b, "auth.refresh", b.cfg.RateLimitLogin)).Post("/refresh", b.AuthHandler.Refresh)
+			rt.Post("/logout", b.AuthHandler.Logout)
+		})
+
+		pub.Route("/contact", func(rt chi.Router) {
+			rt.With(contactMW...).Post("/", b.ContactHandler.Submit)
+		})
+
+		// REST tự sinh từ annotation google.api.http (xem application/transcode.go)
+		pub.Route("/v1", func(v1 chi.Router) {
+			// chế độ cookie: token không được nằm trong body, dùng handler login viết tay (cùng JSON)
+			login := b.transcoder
+			if b.cfg.Session.Enabled {
+				login = http.HandlerFunc(b.AuthHandler.Login)
+			}
+			v1.With(loginMW...).With(gwmw.ValidateJSON[client.LoginInput]).Method(http.MethodPost, "/auth/login", login)
+			v1.With(registerMW...).With(gwmw.ValidateJSON[handler.RegisterInput]).Method(http.MethodPost, "/auth/register", b.transcoder)
+			v1.With(contactMW...).With(gwmw.ValidateJSON[client.ContactSubmitInput]).Method(http.MethodPost, "/contact", b.transcoder)
+
+			// RPC khác chỉ được đọc: RPC ghi mới phải khai báo ở trên kèm middleware riêng
+			if b.cfg.HTTPCache {
+				// GET công khai được cache theo Cache-Control của backend
+				v1.With(readOnly, a.cache(b, 0, nil)).Mount("/", b.transcoder)
+			} else {
+				v1.With(readOnly).Mount("/", b.transcoder)
+			}
+		})
+	})
+
+	// authenticated API (Bearer JWT)
+	r.Route("/api", func(rt chi.Router) {
+		rt.Use(b.cfg.CORSAPI.Middleware)
+		rt.Use(b.cfg.CSRF.Middleware)
+		rt.Use(b.jwt().Middleware)
+		rt.Get("/me", handler.Me)
 	})
 
-	r.Route("/contact", func(rt chi.Router) {
-		rt.Post("/", a.ContactHandler.Submit)
+	// admin (bearer ADMIN_TOKEN)
+	r.Route("/admin", func(rt chi.Router) {
+		rt.Use(b.cfg.CORSAdmin.Middleware)
+		rt.Use(gwmw.AdminToken{Token: b.cfg.AdminToken}.Middleware)
+		rt.Get("/reload", a.reloadStatusHandler)
+		rt.Post("/reload", a.reloadHandler)
+		rt.Get("/upstreams", a.upstreamsHandler)
+		rt.Post("/cache/purge", a.cachePurgeHandler)
 	})
 
-	a.router = r
+	// route khai báo trong GATEWAY_ROUTES_FILE
+	if err := a.mountRouteTable(r, b, spec); err != nil {
+		return err
+	}
+
+	// tài liệu OpenAPI + Swagger UI (application/openapi.go)
+	specHandler, err := spec.Handler()
+	if err != nil {
+		return err
+	}
+	r.Get("/openapi.json", specHandler.ServeHTTP)
+	r.Get("/docs", openapi.UI("/openapi.json").ServeHTTP)
+
+	b.router, b.spec = r, spec
+	return nil
+}
+
+// jwt: Bearer header, thêm access cookie khi bật chế độ cookie
+func (b *backend) jwt() gwmw.JWT {
+	m := gwmw.JWT{Secret: b.cfg.JWTSecret}
+	if b.cfg.Session.Enabled {
+		m.Cookie = b.cfg.Session.AccessCookie
+	}
+	return m
 }
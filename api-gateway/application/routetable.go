@@ -0,0 +1,272 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/handler"
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/openapi"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/go-chi/chi"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+)
+
+// RouteTable là bảng route khai báo bằng YAML (GATEWAY_ROUTES_FILE).
+// Ví dụ:
+//
+//	upstreams:
+//	  auth-http:    { http: "${AUTH_HTTP_BASE}" }
+//	  contact-grpc: { grpc: "${CONTACT_GRPC_ADDR}" }
+//	routes:
+//	  - method: POST
+//	    path: /forms/contact
+//	    upstream: contact-grpc
+//	    grpc_method: contactpb.ContactService/Submit
+//	    client_ip_field: remote_ip
+//	    timeout: 8s
+//	    rate_limit: 5/1m
+//	    rate_limit_key: ip
+//	    max_body_bytes: 16384
+//	    idempotency_key: true
+//	  - method: POST
+//	    path: /forms/register
+//	    upstream: auth-http
+//	    upstream_path: /auth/register
+//	    max_body_bytes: 4096
+type RouteTable struct {
+	Upstreams map[string]Upstream `yaml:"upstreams"`
+	Routes    []Route             `yaml:"routes"`
+}
+
+// Upstream là một backend HTTP (base URL) hoặc gRPC (host:port, danh sách, dns:/// hoặc file://)
+type Upstream struct {
+	HTTP     string `yaml:"http"`
+	GRPC     string `yaml:"grpc"`
+	Identity string `yaml:"identity"` // gRPC + UPSTREAM_TLS: SAN mong đợi của backend
+}
+
+type Route struct {
+	Method        string        `yaml:"method"`
+	Path          string        `yaml:"path"`
+	Upstream      string        `yaml:"upstream"`
+	UpstreamPath  string        `yaml:"upstream_path"`   // HTTP: mặc định bằng Path
+	GRPCMethod    string        `yaml:"grpc_method"`     // gRPC: "package.Service/Method"
+	ClientIPField string        `yaml:"client_ip_field"` // gRPC: field nhận IP client
+	Status        int           `yaml:"status"`          // gRPC: status khi thành công (mặc định 200)
+	Group         string        `yaml:"group"`           // public | api | admin (chính sách CORS)
+	Auth          bool          `yaml:"auth"`            // yêu cầu Bearer JWT
+	Timeout       time.Duration `yaml:"timeout"`
+	RateLimit     string        `yaml:"rate_limit"`           // "N/duration", vd "10/1m"
+	RateLimitKey  string        `yaml:"rate_limit_key"`       // ip (mặc định) | user | api_key (key trong API_KEYS, không thì theo IP)
+	RateLimitAlg  string        `yaml:"rate_limit_algorithm"` // sliding_window | token_bucket (mặc định RATE_LIMIT_ALGORITHM)
+	MaxBodyBytes  int64         `yaml:"max_body_bytes"`
+	Idempotency   bool          `yaml:"idempotency_key"` // POST/PATCH: hỗ trợ header Idempotency-Key
+	Cache         bool          `yaml:"cache"`           // GET công khai: cache response (HTTP_CACHE)
+	CacheTTL      time.Duration `yaml:"cache_ttl"`       // khi upstream không gửi Cache-Control (mặc định CACHE_DEFAULT_TTL)
+	CacheVary     []string      `yaml:"cache_vary"`      // header đưa vào cache key (mặc định CACHE_VARY_HEADERS)
+}
+
+// LoadRouteTable đọc file YAML, thay ${ENV} rồi kiểm tra tính hợp lệ
+func LoadRouteTable(path string) (*RouteTable, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read route table: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(os.ExpandEnv(string(raw)))))
+	dec.KnownFields(true)
+
+	var t RouteTable
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("parse route table %s: %w", path, err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("route table %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+func (t *RouteTable) validate() error {
+	for name, u := range t.Upstreams {
+		if (u.HTTP == "") == (u.GRPC == "") {
+			return fmt.Errorf("upstream %q: exactly one of http or grpc is required", name)
+		}
+	}
+	seen := map[string]bool{}
+	for i, rt := range t.Routes {
+		rt.Method = strings.ToUpper(rt.Method)
+		t.Routes[i].Method = rt.Method
+		where := fmt.Sprintf("route %d (%s %s)", i, rt.Method, rt.Path)
+
+		switch rt.Method {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return fmt.Errorf("%s: unsupported method", where)
+		}
+		if !strings.HasPrefix(rt.Path, "/") {
+			return fmt.Errorf("%s: path must start with /", where)
+		}
+		if seen[rt.Method+" "+rt.Path] {
+			return fmt.Errorf("%s: duplicate route", where)
+		}
+		seen[rt.Method+" "+rt.Path] = true
+
+		u, ok := t.Upstreams[rt.Upstream]
+		if !ok {
+			return fmt.Errorf("%s: unknown upstream %q", where, rt.Upstream)
+		}
+		if u.GRPC != "" && rt.GRPCMethod == "" {
+			return fmt.Errorf("%s: grpc_method is required for gRPC upstream", where)
+		}
+		if u.HTTP != "" && (rt.GRPCMethod != "" || rt.ClientIPField != "") {
+			return fmt.Errorf("%s: grpc_method/client_ip_field not allowed for HTTP upstream", where)
+		}
+		switch rt.Group {
+		case "", "public", "api", "admin":
+		default:
+			return fmt.Errorf("%s: unknown group %q", where, rt.Group)
+		}
+		if rt.RateLimit != "" {
+			if _, _, err := gwmw.ParseRate(rt.RateLimit); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+		}
+		if _, err := gwmw.ParseKeyFunc(rt.RateLimitKey, nil); err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		if _, err := gwmw.ParseAlgorithm(rt.RateLimitAlg); err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		if rt.Timeout < 0 || rt.MaxBodyBytes < 0 {
+			return fmt.Errorf("%s: timeout and max_body_bytes must not be negative", where)
+		}
+		if rt.Idempotency && rt.Method != http.MethodPost && rt.Method != http.MethodPatch {
+			return fmt.Errorf("%s: idempotency_key is only supported for POST and PATCH", where)
+		}
+		if (rt.Cache || rt.CacheTTL != 0 || len(rt.CacheVary) > 0) && (rt.Method != http.MethodGet || rt.Auth || !rt.Cache) {
+			return fmt.Errorf("%s: cache, cache_ttl and cache_vary need cache: true on a GET route without auth", where)
+		}
+		if rt.CacheTTL < 0 {
+			return fmt.Errorf("%s: cache_ttl must not be negative", where)
+		}
+	}
+	return nil
+}
+
+// dialUpstreams mở kết nối gRPC (bằng dial) cho các upstream gRPC trong bảng
+func (t *RouteTable) dialUpstreams(dial func(name string, u Upstream) (*grpc.ClientConn, error)) (map[string]*grpc.ClientConn, []func() error, error) {
+	conns := map[string]*grpc.ClientConn{}
+	var closers []func() error
+	for name, u := range t.Upstreams {
+		if u.GRPC == "" {
+			continue
+		}
+		conn, err := dial(name, u)
+		if err != nil {
+			for _, c := range closers {
+				_ = c()
+			}
+			return nil, nil, fmt.Errorf("connect upstream %s: %w", name, err)
+		}
+		conns[name] = conn
+		closers = append(closers, conn.Close)
+	}
+	return conns, closers, nil
+}
+
+// mountRouteTable đăng ký các route khai báo vào router và mô tả chúng trong spec
+func (a *App) mountRouteTable(r chi.Router, b *backend, spec *openapi.Document) error {
+	if b.routes == nil {
+		return nil
+	}
+	preflight := map[string]bool{}
+	for _, rt := range b.routes.Routes {
+		if rt.RateLimit != "" && strings.EqualFold(rt.RateLimitKey, "api_key") && len(b.cfg.APIKeys) == 0 {
+			return fmt.Errorf("route %s %s: rate_limit_key api_key requires API_KEYS", rt.Method, rt.Path)
+		}
+		h, err := a.routeHandler(b, rt)
+		if err != nil {
+			return fmt.Errorf("route %s %s: %w", rt.Method, rt.Path, err)
+		}
+		mws := a.routeMiddlewares(b, rt)
+		r.With(mws...).Method(rt.Method, rt.Path, h)
+		describeRoute(spec, rt, h)
+
+		// OPTIONS cho preflight CORS (middleware đầu tiên luôn là CORS của nhóm)
+		if !preflight[rt.Path] {
+			preflight[rt.Path] = true
+			r.With(mws[0]).Options(rt.Path, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+		}
+	}
+	return nil
+}
+
+func (a *App) routeHandler(b *backend, rt Route) (http.Handler, error) {
+	u := b.routes.Upstreams[rt.Upstream]
+	if u.HTTP != "" {
+		path := rt.UpstreamPath
+		if path == "" {
+			path = rt.Path
+		}
+		c := &http.Client{Timeout: rt.Timeout, Transport: a.resilience.Transport(rt.Upstream, platform.TracingTransport(nil))}
+		return &handler.HTTPForward{Target: strings.TrimRight(u.HTTP, "/") + path, Name: rt.Upstream, HTTP: c}, nil
+	}
+
+	fwd, err := handler.NewGRPCForward(b.upstreamConns[rt.Upstream], rt.GRPCMethod)
+	if err != nil {
+		return nil, err
+	}
+	if rt.ClientIPField != "" {
+		if err := fwd.SetClientIPField(rt.ClientIPField); err != nil {
+			return nil, err
+		}
+	}
+	fwd.Timeout = rt.Timeout
+	fwd.SuccessStatus = rt.Status
+	return fwd, nil
+}
+
+func (a *App) routeMiddlewares(b *backend, rt Route) []func(http.Handler) http.Handler {
+	var mws []func(http.Handler) http.Handler
+	switch rt.Group {
+	case "api":
+		mws = append(mws, b.cfg.CORSAPI.Middleware)
+	case "admin":
+		mws = append(mws, b.cfg.CORSAdmin.Middleware)
+	default:
+		mws = append(mws, b.cfg.CORSPublic.Middleware)
+	}
+	mws = append(mws, b.cfg.CSRF.Middleware) // sau CORS để lỗi 403 vẫn có header CORS
+	if rt.Auth {
+		mws = append(mws, b.jwt().Middleware)
+	}
+	if rt.RateLimit != "" {
+		// đã validate khi load; đặt sau JWT để key "user" đọc được user_id
+		key, _ := gwmw.ParseKeyFunc(rt.RateLimitKey, b.cfg.APIKeys)
+		rl, _ := gwmw.NewRateLimit(rt.RateLimit, key)
+		rl.Name = "route:" + rt.Method + " " + rt.Path
+		rl.Algorithm, _ = gwmw.ParseAlgorithm(rt.RateLimitAlg)
+		if rt.RateLimitAlg == "" {
+			rl.Algorithm, _ = gwmw.ParseAlgorithm(string(b.cfg.RateLimitAlgorithm))
+		}
+		rl.Store = a.rateStore
+		mws = append(mws, rl.Middleware)
+	}
+	if rt.MaxBodyBytes > 0 {
+		mws = append(mws, gwmw.BodyLimit(rt.MaxBodyBytes))
+	}
+	if rt.Idempotency {
+		mws = append(mws, a.idempotency(b, "route:"+rt.Method+" "+rt.Path))
+	}
+	if rt.Cache && b.cfg.HTTPCache {
+		mws = append(mws, a.cache(b, rt.CacheTTL, rt.CacheVary))
+	}
+	return mws
+}
@@ -0,0 +1,42 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSessionCookieFlow(t *testing.T) {
+	f := startFakeBackend(t)
+	vars := f.vars()
+	vars["SESSION_COOKIES"] = "true"
+	a := newTestApp(t, vars)
+
+	// không bật chế độ cookie thì access cookie không được chấp nhận
+	plain := newTestApp(t, f.vars())
+	jar := login(t, a)
+	if w := plain.serve(jar.apply(httptest.NewRequest(http.MethodGet, "/api/me", nil))); w.Code != http.StatusUnauthorized {
+		t.Errorf("/api/me with cookie, sessions disabled: %d", w.Code)
+	}
+
+	w := a.serve(jar.apply(httptest.NewRequest(http.MethodGet, "/api/me", nil)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"user_id":"u1"`) {
+		t.Fatalf("/api/me with access cookie: %d %s", w.Code, w.Body)
+	}
+
+	w = a.serve(csrfPost(jar, "/auth/logout", jar["csrf_token"]))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("logout: %d %s", w.Code, w.Body)
+	}
+	jar.update(w)
+	if _, ok := jar["access_token"]; ok {
+		t.Error("access cookie not cleared by logout")
+	}
+	if w := a.serve(jar.apply(httptest.NewRequest(http.MethodGet, "/api/me", nil))); w.Code != http.StatusUnauthorized {
+		t.Errorf("/api/me after logout: %d", w.Code)
+	}
+	if w := a.serve(jar.apply(httptest.NewRequest(http.MethodPost, "/auth/refresh", nil))); w.Code != http.StatusUnauthorized {
+		t.Errorf("/auth/refresh after logout: %d", w.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracePropagatesToBackend(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	f := startFakeBackend(t)
+	a := newTestApp(t, f.vars())
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := postJSON("/contact/", `{"name":"Loc","email":"a@b.vn","message":"hello"}`)
+	r.Header.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	if w := a.serve(r); w.Code != http.StatusCreated {
+		t.Fatalf("contact: %d %s", w.Code, w.Body)
+	}
+
+	// span server của gateway nối vào trace của client; span client gRPC là cha của backend
+	var server, client sdktrace.ReadOnlySpan
+	for _, s := range sr.Ended() {
+		switch s.SpanKind() {
+		case trace.SpanKindServer:
+			server = s
+		case trace.SpanKindClient:
+			client = s
+		}
+	}
+	if server == nil || client == nil {
+		t.Fatalf("want server and gRPC client spans, got %d spans", len(sr.Ended()))
+	}
+	if !strings.HasPrefix(server.Name(), "POST /contact/") || server.SpanContext().TraceID().String() != traceID {
+		t.Errorf("server span %q in trace %s", server.Name(), server.SpanContext().TraceID())
+	}
+	if client.SpanContext().TraceID().String() != traceID || client.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("gRPC client span not a child of the server span")
+	}
+	got, _ := f.traceparent.Load().(string)
+	if !strings.Contains(got, traceID+"-"+client.SpanContext().SpanID().String()) {
+		t.Errorf("backend traceparent = %q, want parent %s", got, client.SpanContext().SpanID())
+	}
+}
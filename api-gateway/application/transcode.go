@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/handler"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	authv1 "github.com/RibunLoc/WebPersonalBackend/gen/auth/v1"
+	contactv1 "github.com/RibunLoc/WebPersonalBackend/gen/contact/v1"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// newTranscoder dựng REST -> gRPC từ annotation google.api.http trong proto/.
+// RPC mới chỉ cần khai báo option (google.api.http) rồi `buf generate`.
+// JSON dùng tên field trong proto (snake_case) giống các route viết tay.
+// Mỗi lời gọi bị giới hạn bởi timeout của upstream tương ứng, kể cả khi client gửi Grpc-Timeout lớn hơn.
+func newTranscoder(authConn, contactConn *grpc.ClientConn, authTimeout, contactTimeout time.Duration) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{},
+		}),
+		runtime.WithErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+			util.GRPCError(w, err)
+		}),
+		// header metadata của backend -> header HTTP, chỉ các header trong allowlist của HTTPForward
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+		runtime.WithRoutingErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, httpStatus int) {
+			util.Error(w, httpStatus, "")
+		}),
+	)
+	ctx := context.Background()
+	auth := authv1.NewUserServiceClient(deadlineConn{authConn, authTimeout})
+	if err := authv1.RegisterUserServiceHandlerClient(ctx, mux, auth); err != nil {
+		return nil, fmt.Errorf("register auth transcoder: %w", err)
+	}
+	contact := contactv1.NewContactServiceClient(deadlineConn{contactConn, contactTimeout})
+	if err := contactv1.RegisterContactServiceHandlerClient(ctx, mux, contact); err != nil {
+		return nil, fmt.Errorf("register contact transcoder: %w", err)
+	}
+	return withClientIP(mux), nil
+}
+
+// deadlineConn đặt thời hạn cho mỗi lời gọi unary. context.WithTimeout giữ thời hạn sớm hơn,
+// nên Grpc-Timeout (runtime đã đưa vào context) chỉ có thể rút ngắn chứ không kéo dài quá timeout.
+type deadlineConn struct {
+	grpc.ClientConnInterface
+	timeout time.Duration
+}
+
+func (c deadlineConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+}
+
+// withClientIP đưa IP client vào context để client.ClientIPInterceptor sử dụng
+func withClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := client.WithClientIP(r.Context(), platform.ClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func outgoingHeader(key string) (string, bool) {
+	for _, h := range handler.ForwardResponseHeaders {
+		if strings.EqualFold(key, h) {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+// readOnly chặn method ghi dữ liệu trên các route transcode chưa được khai báo riêng
+func readOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			util.Error(w, http.StatusMethodNotAllowed, "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
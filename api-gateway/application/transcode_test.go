@@ -0,0 +1,231 @@
+package application
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func postJSON(path, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestTranscodedLogin(t *testing.T) {
+	f := startFakeBackend(t)
+	a := newTestApp(t, f.vars())
+
+	w := a.serve(postJSON("/v1/auth/login", `{"email":"a@b.vn","password":"secret"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", w.Code, w.Body)
+	}
+	var res struct{ Token string }
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Token != "jwt-token" {
+		t.Errorf("token = %q", res.Token)
+	}
+	// header metadata trong allowlist thành header HTTP, phần còn lại bị bỏ
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q", w.Header().Get("Cache-Control"))
+	}
+	for k := range w.Header() {
+		if strings.Contains(strings.ToLower(k), "internal-node") {
+			t.Errorf("backend metadata leaked as %s", k)
+		}
+	}
+}
+
+func TestTranscodedLoginCookieMode(t *testing.T) {
+	f := startFakeBackend(t)
+	vars := f.vars()
+	vars["SESSION_COOKIES"] = "true"
+	a := newTestApp(t, vars)
+
+	w := a.serve(postJSON("/v1/auth/login", `{"email":"a@b.vn","password":"secret"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "jwt-token") {
+		t.Errorf("token in body in cookie mode: %s", w.Body)
+	}
+	if len(w.Result().Cookies()) == 0 {
+		t.Error("no session cookies issued")
+	}
+}
+
+func TestTranscodedDeadline(t *testing.T) {
+	f := startFakeBackend(t)
+	vars := f.vars()
+	vars["AUTH_TIMEOUT"] = "2s"
+	vars["CONTACT_TIMEOUT"] = "3s"
+	a := newTestApp(t, vars)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		grpcTO   string
+		min, max time.Duration
+	}{
+		{"auth default", "/v1/auth/login", `{"email":"a@b.vn","password":"secret"}`, "", time.Second, 2 * time.Second},
+		{"contact default", "/v1/contact", `{"name":"Loc","email":"a@b.vn","message":"hello"}`, "", 2 * time.Second, 3 * time.Second},
+		{"grpc-timeout capped", "/v1/auth/login", `{"email":"a@b.vn","password":"secret"}`, "1H", time.Second, 2 * time.Second},
+		{"grpc-timeout shorter", "/v1/auth/login", `{"email":"a@b.vn","password":"secret"}`, "500m", 0, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		r := postJSON(tt.path, tt.body)
+		if tt.grpcTO != "" {
+			r.Header.Set("Grpc-Timeout", tt.grpcTO)
+		}
+		if w := a.serve(r); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d body=%s", tt.name, w.Code, w.Body)
+		}
+		if left := f.deadline.Load().(time.Duration); left <= tt.min || left > tt.max {
+			t.Errorf("%s: backend deadline in %v, want (%v, %v]", tt.name, left, tt.min, tt.max)
+		}
+	}
+}
+
+func TestTranscodedRouteMiddleware(t *testing.T) {
+	f := startFakeBackend(t)
+	vars := f.vars()
+	vars["RATE_LIMIT_CONTACT"] = "2/1m"
+	vars["BODY_LIMIT_LOGIN"] = "64"
+	a := newTestApp(t, vars)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"validation", postJSON("/v1/auth/login", `{"email":"not-an-email","password":"x"}`), http.StatusBadRequest},
+		{"unknown field", postJSON("/v1/contact", `{"name":"Loc","email":"a@b.vn","message":"hello","remote_ip":"1.1.1.1"}`), http.StatusBadRequest},
+		{"content type", httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{}`)), http.StatusUnsupportedMediaType},
+		{"body limit", postJSON("/v1/auth/login", `{"email":"a@b.vn","password":"`+strings.Repeat("x", 100)+`"}`), http.StatusRequestEntityTooLarge},
+		// contact.submit dùng chung bucket với /contact/ (2 request ở trên + dưới)
+		{"shared rate limit", postJSON("/contact/", `{}`), http.StatusBadRequest},
+		{"rate limited", postJSON("/v1/contact", `{"name":"Loc","email":"a@b.vn","message":"hello"}`), http.StatusTooManyRequests},
+		{"undeclared method", httptest.NewRequest(http.MethodPut, "/v1/contact", strings.NewReader(`{}`)), http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if w := a.serve(tt.req); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+	if n := f.logins.Load() + f.submit.Load(); n != 0 {
+		t.Errorf("backend called %d times for rejected requests", n)
+	}
+}
+
+func TestTranscodedIdempotency(t *testing.T) {
+	f := startFakeBackend(t)
+	a := newTestApp(t, f.vars())
+
+	for i := 0; i < 2; i++ {
+		r := postJSON("/v1/contact", `{"name":"Loc","email":"a@b.vn","message":"hello"}`)
+		r.Header.Set("Idempotency-Key", "k-1")
+		w := a.serve(r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("attempt %d: status = %d body=%s", i, w.Code, w.Body)
+		}
+		if i == 1 && w.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("retry was not replayed")
+		}
+	}
+	if n := f.submit.Load(); n != 1 {
+		t.Errorf("backend called %d times, want 1", n)
+	}
+}
+
+func TestPasswordByteLimit(t *testing.T) {
+	a := newTestApp(t, nil)
+	// 40 ký tự nhưng 80 byte: bcrypt chỉ dùng 72 byte đầu nên bị từ chối ở gateway
+	password := strings.Repeat("é", 40)
+	for _, path := range []string{"/auth/register", "/v1/auth/register", "/auth/login", "/v1/auth/login"} {
+		w := a.serve(postJSON(path, `{"email":"a@b.vn","password":"`+password+`"}`))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "at most 72 bytes") {
+			t.Errorf("%s: %d %s", path, w.Code, w.Body)
+		}
+	}
+}
+
+func TestAnonymousIdempotencyScope(t *testing.T) {
+	f := startFakeBackend(t)
+	a := newTestApp(t, f.vars())
+	csrf := func() string {
+		jar := cookieJar{}
+		jar.update(a.serve(httptest.NewRequest(http.MethodGet, "/auth/csrf", nil)))
+		return jar["csrf_token"]
+	}
+	t1, t2 := csrf(), csrf()
+	const (
+		bodyA = `{"name":"Loc","email":"a@b.vn","message":"hello"}`
+		bodyB = `{"name":"An","email":"an@b.vn","message":"xin chao"}`
+	)
+
+	tests := []struct {
+		name     string
+		ip, body string
+		csrf     string
+		replayed bool
+	}{
+		{"first", "203.0.113.1", bodyA, "", false},
+		// retry đổi mạng (IP khác) vẫn được nhận ra nhờ body
+		{"retry from other ip", "203.0.113.2", bodyA, "", true},
+		// khách khác dùng trùng key với body khác: không 422, không nhận response của người trước
+		{"other client same key", "203.0.113.1", bodyB, "", false},
+		{"browser first", "203.0.113.1", bodyA, t1, false},
+		{"browser retry from other ip", "198.51.100.7", bodyA, t1, true},
+		{"other browser same body", "198.51.100.7", bodyA, t2, false},
+	}
+	want := int32(0)
+	for _, tt := range tests {
+		r := postJSON("/contact/", tt.body)
+		r.RemoteAddr = tt.ip + ":4321"
+		r.Header.Set("Idempotency-Key", "shared-key")
+		if tt.csrf != "" {
+			r.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.csrf})
+		}
+		w := a.serve(r)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: %d %s", tt.name, w.Code, w.Body)
+		}
+		if got := w.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+			t.Errorf("%s: replayed = %v, want %v", tt.name, got, tt.replayed)
+		}
+		if !tt.replayed {
+			want++
+		}
+	}
+	if n := f.submit.Load(); n != want {
+		t.Errorf("backend called %d times, want %d", n, want)
+	}
+}
+
+func TestRegisterIgnoresIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"u1"}`))
+	}))
+	t.Cleanup(auth.Close)
+	a := newTestApp(t, map[string]string{"AUTH_HTTP_BASE": auth.URL})
+
+	for i := 0; i < 2; i++ {
+		r := postJSON("/auth/register", `{"email":"a@b.vn","password":"secret1"}`)
+		r.Header.Set("Idempotency-Key", "k-1")
+		if w := a.serve(r); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("attempt %d: %d replayed=%q", i, w.Code, w.Header().Get("Idempotent-Replayed"))
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("auth-service called %d times, want 2", calls.Load())
+	}
+}
@@ -0,0 +1,158 @@
+// devcerts tạo CA local + cert cho từng service để chạy gRPC TLS/mTLS khi dev/test.
+// KHÔNG dùng cho production.
+//
+//	go run ./cmd/devcerts -out ../certs api-gateway auth-service contact-service
+//
+// Mỗi service có <name>.pem / <name>-key.pem với SAN: DNS <name>, DNS localhost,
+// IP 127.0.0.1, ::1 và URI spiffe://holoc.id.vn/<name>; dùng được cho cả server lẫn client (mTLS).
+// Ví dụ cấu hình:
+//
+//	auth-service: GRPC_TLS_CERT_FILE=certs/auth-service.pem GRPC_TLS_KEY_FILE=certs/auth-service-key.pem
+//	              GRPC_TLS_CA_FILE=certs/ca.pem GRPC_TLS_CLIENT_AUTH=true
+//	              GRPC_TLS_ALLOWED_SANS=spiffe://holoc.id.vn/api-gateway
+//	api-gateway:  UPSTREAM_TLS_CA_FILE=certs/ca.pem UPSTREAM_TLS_CERT_FILE=certs/api-gateway.pem
+//	              UPSTREAM_TLS_KEY_FILE=certs/api-gateway-key.pem
+//	              AUTH_GRPC_IDENTITY=spiffe://holoc.id.vn/auth-service
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const trustDomain = "holoc.id.vn"
+
+func main() {
+	out := flag.String("out", "certs", "thư mục ghi cert")
+	validFor := flag.Duration("valid", 365*24*time.Hour, "thời hạn cert")
+	flag.Parse()
+
+	names := flag.Args()
+	if len(names) == 0 {
+		names = []string{"api-gateway", "auth-service", "contact-service"}
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	caKey, caCert, err := loadOrCreateCA(*out, *validFor)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range names {
+		if err := issue(*out, name, caKey, caCert, *validFor); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %s\n", name, filepath.Join(*out, name+".pem"))
+	}
+}
+
+// loadOrCreateCA dùng lại ca.pem / ca-key.pem nếu đã có để cert cũ vẫn hợp lệ
+func loadOrCreateCA(dir string, validFor time.Duration) (*ecdsa.PrivateKey, *x509.Certificate, error) {
+	certPath, keyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	if certPEM, err := os.ReadFile(certPath); err == nil {
+		keyPEM, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		cb, _ := pem.Decode(certPEM)
+		kb, _ := pem.Decode(keyPEM)
+		if cb == nil || kb == nil {
+			return nil, nil, fmt.Errorf("invalid PEM in %s", dir)
+		}
+		cert, err := x509.ParseCertificate(cb.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := x509.ParseECPrivateKey(kb.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial(),
+		Subject:               pkix.Name{CommonName: "holoc dev CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(validFor),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(certPath, "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, cert, writePEM(keyPath, "EC PRIVATE KEY", keyDER, 0o600)
+}
+
+func issue(dir, name string, caKey *ecdsa.PrivateKey, ca *x509.Certificate, validFor time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial(),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{name, "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+		URIs:         []*url.URL{{Scheme: "spiffe", Host: trustDomain, Path: "/" + name}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	if err := writePEM(filepath.Join(dir, name+".pem"), "CERTIFICATE", der, 0o644); err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writePEM(filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDER, 0o600)
+}
+
+func serial() *big.Int {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return n
+}
+
+func writePEM(path, typ string, der []byte, mode os.FileMode) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), mode)
+}
@@ -5,17 +5,18 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
 	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 )
 
 type AuthProxy struct {
 	AuthGRPC     *client.AuthGRPC
 	AuthHTTPBase string
 	HTTP         *http.Client
+	Session      Session // chế độ cookie (handler/session.go); tắt = trả token trong body như trước
 }
 
 func NewAuthProxy(grpcCl *client.AuthGRPC, httpBase string) *AuthProxy {
@@ -23,7 +24,8 @@ func NewAuthProxy(grpcCl *client.AuthGRPC, httpBase string) *AuthProxy {
 		AuthGRPC:     grpcCl,
 		AuthHTTPBase: httpBase,
 		HTTP: &http.Client{
-			Timeout: 7 * time.Second,
+			Timeout:   7 * time.Second,
+			Transport: platform.TracingTransport(nil),
 		},
 	}
 }
@@ -31,44 +33,77 @@ func NewAuthProxy(grpcCl *client.AuthGRPC, httpBase string) *AuthProxy {
 // POST /auth/login  (gRPC -> auth-service)
 func (h *AuthProxy) Login(w http.ResponseWriter, r *http.Request) {
 	var in client.LoginInput
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		util.Error(w, http.StatusBadRequest, "invalid JSON")
+	if !util.BindJSON(w, r, &in) {
 		return
 	}
 	res, err := h.AuthGRPC.Login(r.Context(), in)
 	if err != nil {
-		util.Error(w, http.StatusUnauthorized, err.Error())
+		util.GRPCError(w, err)
 		return
 	}
+	if h.Session.Enabled {
+		if err := h.Session.issue(w, res.User.ID, newSessionID()); err != nil {
+			util.Error(w, http.StatusInternalServerError, "cannot create session")
+			return
+		}
+		res.Token = "" // token chỉ nằm trong cookie HttpOnly
+	}
 	util.JSON(w, http.StatusOK, res)
 }
 
-// POST /auth/register  (HTTP -> auth-service REST)
-func (h *AuthProxy) Register(w http.ResponseWriter, r *http.Request) {
-	target, _ := url.Parse(h.AuthHTTPBase + "/auth/register")
-
-	// đọc body và forward
-	bodyBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		util.Error(w, http.StatusBadRequest, "cannot read body")
+// POST /auth/refresh  (chế độ cookie) — đổi refresh cookie lấy cặp cookie mới
+func (h *AuthProxy) Refresh(w http.ResponseWriter, r *http.Request) {
+	if !h.Session.Enabled {
+		util.Error(w, http.StatusNotFound, "")
 		return
 	}
-	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, target.String(), bytes.NewReader(bodyBytes))
+	uid, sid, err := h.Session.refreshUser(r)
 	if err != nil {
-		util.Error(w, http.StatusInternalServerError, "cannot create forward request")
+		h.Session.clear(w)
+		util.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err := h.Session.issue(w, uid, sid); err != nil {
+		util.Error(w, http.StatusInternalServerError, "cannot create session")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /auth/logout  (chế độ cookie) — xoá access và refresh cookie
+func (h *AuthProxy) Logout(w http.ResponseWriter, r *http.Request) {
+	if !h.Session.Enabled {
+		util.Error(w, http.StatusNotFound, "")
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
+	h.Session.clear(w)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterInput là body của POST /auth/register; kiểm tra ở gateway trước khi forward
+type RegisterInput struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=6,maxbytes=72"` // bcrypt chỉ dùng 72 byte đầu
+	Fullname string `json:"fullname,omitempty" validate:"max=100"`
+}
 
-	resp, err := h.HTTP.Do(req)
+// POST /auth/register  (HTTP -> auth-service REST)
+func (h *AuthProxy) Register(w http.ResponseWriter, r *http.Request) {
+	var in RegisterInput
+	if !util.BindJSON(w, r, &in) {
+		return
+	}
+	// forward đúng body đã kiểm tra (không kèm field lạ / dữ liệu thừa)
+	body, err := json.Marshal(in)
 	if err != nil {
-		util.Error(w, http.StatusBadGateway, "auth-service unavailable")
+		util.Error(w, http.StatusInternalServerError, "cannot encode request")
 		return
 	}
-	defer resp.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Type", "application/json")
 
-	// truyền nguyên status + body trả về cho client
-	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
-	w.WriteHeader(resp.StatusCode)
-	_, _ = io.Copy(w, resp.Body)
+	fwd := HTTPForward{Target: h.AuthHTTPBase + "/auth/register", Name: "auth-service", HTTP: h.HTTP, MapErrors: true}
+	fwd.ServeHTTP(w, r)
 }
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCForward nhận JSON, gọi một unary gRPC method bất kỳ (tra descriptor
+// trong registry của các package gen/) và trả kết quả dạng JSON.
+type GRPCForward struct {
+	Conn          grpc.ClientConnInterface
+	Method        protoreflect.MethodDescriptor
+	Timeout       time.Duration
+	ClientIPField string // tên field (string) được gán IP client, vd "remote_ip"
+	SuccessStatus int    // mặc định 200
+
+	ResponseHeaders []string // metadata header của upstream trả lại cho client; nil = ForwardResponseHeaders
+}
+
+// fullMethod dạng "contactpb.ContactService/Submit"
+func NewGRPCForward(conn grpc.ClientConnInterface, fullMethod string) (*GRPCForward, error) {
+	svc, name, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid gRPC method %q, want package.Service/Method", fullMethod)
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(svc))
+	if err != nil {
+		return nil, fmt.Errorf("gRPC service %s: %w", svc, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", svc)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("gRPC method %s not found in %s", name, svc)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("gRPC method %s is streaming, only unary is supported", fullMethod)
+	}
+	return &GRPCForward{Conn: conn, Method: md}, nil
+}
+
+func (f *GRPCForward) SetClientIPField(name string) error {
+	if err := checkStringField(f.Method.Input(), name); err != nil {
+		return err
+	}
+	f.ClientIPField = name
+	return nil
+}
+
+func checkStringField(msg protoreflect.MessageDescriptor, name string) error {
+	fd := msg.Fields().ByName(protoreflect.Name(name))
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+		return fmt.Errorf("%s has no string field %q", msg.FullName(), name)
+	}
+	return nil
+}
+
+var (
+	jsonIn  = protojson.UnmarshalOptions{}
+	jsonOut = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+func (f *GRPCForward) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength != 0 && !util.IsJSONContentType(r.Header.Get("Content-Type")) {
+		util.Error(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			util.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		util.Error(w, http.StatusBadRequest, "cannot read body")
+		return
+	}
+
+	in := dynamicpb.NewMessage(f.Method.Input())
+	if len(body) > 0 {
+		// protojson từ chối field lạ và dữ liệu thừa sau object
+		if err := jsonIn.Unmarshal(body, in); err != nil {
+			util.Error(w, http.StatusBadRequest, "invalid JSON: "+protoErrDetail(err))
+			return
+		}
+	}
+	if f.ClientIPField != "" {
+		fd := in.Descriptor().Fields().ByName(protoreflect.Name(f.ClientIPField))
+		in.Set(fd, protoreflect.ValueOfString(platform.ClientIP(r)))
+	}
+
+	ctx := r.Context()
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	out := dynamicpb.NewMessage(f.Method.Output())
+	method := "/" + string(f.Method.Parent().FullName()) + "/" + string(f.Method.Name())
+	var md metadata.MD
+	err = f.Conn.Invoke(ctx, method, in, out, grpc.Header(&md))
+	// Cache-Control, Vary, Set-Cookie... của upstream: cache của gateway dựa vào chúng
+	// (content-type trong metadata là application/grpc của transport, không phải của response)
+	for _, name := range orDefault(f.ResponseHeaders, ForwardResponseHeaders) {
+		if vs := md.Get(name); len(vs) > 0 && !strings.EqualFold(name, "Content-Type") {
+			w.Header()[http.CanonicalHeaderKey(name)] = vs
+		}
+	}
+	if err != nil {
+		util.GRPCError(w, err)
+		return
+	}
+
+	b, err := jsonOut.Marshal(out)
+	if err != nil {
+		util.Error(w, http.StatusInternalServerError, "cannot encode response")
+		return
+	}
+	code := f.SuccessStatus
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
+
+// protoErrDetail bỏ tiền tố "proto:" (protobuf-go cố ý đổi ngẫu nhiên dấu cách sau tiền tố)
+func protoErrDetail(err error) string {
+	return strings.TrimLeft(strings.TrimPrefix(err.Error(), "proto:"), " \u00a0")
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/internal/client"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+// HTTPForward chuyển tiếp request sang một upstream HTTP và trả nguyên
+// status + body về cho client. Dùng cho /auth/register và route khai báo trong route table.
+//
+// Chỉ các header trong allowlist được chuyển qua mỗi chiều; header hop-by-hop và
+// header nội bộ (X-Forwarded-*, X-Request-ID) do gateway tự đặt.
+type HTTPForward struct {
+	Target string       // URL đích (không gồm query)
+	Name   string       // tên upstream dùng trong thông báo lỗi, vd "auth-service"
+	HTTP   *http.Client // nil = defaultForwardClient
+
+	RequestHeaders  []string // nil = ForwardRequestHeaders
+	ResponseHeaders []string // nil = ForwardResponseHeaders
+
+	// MapErrors chuyển body lỗi {"error": "..."} của upstream thành problem+json
+	// (message của lỗi 5xx không được trả ra, giống util.GRPCError)
+	MapErrors bool
+}
+
+var defaultForwardClient = &http.Client{Transport: platform.TracingTransport(nil)}
+
+// ForwardRequestHeaders là header của client được gửi tiếp cho upstream
+var ForwardRequestHeaders = []string{
+	"Accept", "Accept-Language", "Authorization", "Cookie", "Content-Type",
+	"If-None-Match", "If-Modified-Since", "User-Agent",
+}
+
+// ForwardResponseHeaders là header của upstream được trả lại cho client
+var ForwardResponseHeaders = []string{
+	"Content-Type", "Content-Language", "Content-Disposition", "Location", "Link",
+	"Set-Cookie", "WWW-Authenticate", "Retry-After",
+	"Cache-Control", "Expires", "Vary", "ETag", "Last-Modified",
+}
+
+func (f *HTTPForward) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// đọc body và forward
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			util.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		util.Error(w, http.StatusBadRequest, "cannot read body")
+		return
+	}
+	target := f.Target
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target, bytes.NewReader(bodyBytes))
+	if err != nil {
+		util.Error(w, http.StatusInternalServerError, "cannot create forward request")
+		return
+	}
+	copyHeaders(req.Header, r.Header, orDefault(f.RequestHeaders, ForwardRequestHeaders))
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id := platform.RequestIDFromContext(r.Context()); id != "" {
+		req.Header.Set(platform.RequestIDHeader, id)
+	}
+	// IP client đã resolve; upstream chỉ nên tin header này khi gateway nằm trong TRUSTED_PROXIES của nó
+	req.Header.Set("X-Forwarded-For", platform.ClientIP(r))
+
+	c := f.HTTP
+	if c == nil {
+		c = defaultForwardClient
+	}
+	start := time.Now()
+	resp, err := c.Do(req)
+	if errors.Is(err, client.ErrCircuitOpen) {
+		// breaker đang mở: không gọi backend, báo client thử lại sau
+		client.ObserveUpstream(f.Name, "http", "circuit_open", start)
+		util.WriteProblem(w, util.Problem{Status: http.StatusServiceUnavailable, Code: "CIRCUIT_OPEN", Detail: f.Name + " unavailable"})
+		return
+	}
+	if err != nil {
+		client.ObserveUpstream(f.Name, "http", "error", start)
+		util.Error(w, http.StatusBadGateway, f.Name+" unavailable")
+		return
+	}
+	client.ObserveUpstream(f.Name, "http", strconv.Itoa(resp.StatusCode), start)
+	defer resp.Body.Close()
+
+	// truyền nguyên status + body (và header trong allowlist) về cho client
+	copyHeaders(w.Header(), resp.Header, orDefault(f.ResponseHeaders, ForwardResponseHeaders))
+	if f.MapErrors && resp.StatusCode >= 400 && !isProblem(resp.Header.Get("Content-Type")) {
+		writeUpstreamError(w, resp)
+		return
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// writeUpstreamError ghi lỗi của upstream dạng problem+json, giữ nguyên status
+func writeUpstreamError(w http.ResponseWriter, resp *http.Response) {
+	var body struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	p := util.Problem{Status: resp.StatusCode}
+	if resp.StatusCode < 500 {
+		p.Detail = body.Error
+	}
+	util.WriteProblem(w, p)
+}
+
+func isProblem(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == "application/problem+json"
+}
+
+// copyHeaders chép các header có tên trong names (giữ mọi giá trị, vd nhiều Set-Cookie)
+func copyHeaders(dst, src http.Header, names []string) {
+	for _, name := range names {
+		if vs := src.Values(name); len(vs) > 0 {
+			dst[http.CanonicalHeaderKey(name)] = append([]string(nil), vs...)
+		}
+	}
+}
+
+func orDefault(names, def []string) []string {
+	if names == nil {
+		return def
+	}
+	return names
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+func TestHTTPForwardHeaders(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Add("Set-Cookie", "a=1; Path=/")
+		w.Header().Add("Set-Cookie", "b=2; Path=/")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Internal-Debug", "secret")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	defer upstream.Close()
+
+	f := &HTTPForward{Target: upstream.URL + "/auth/register", Name: "auth-service"}
+	r := httptest.NewRequest(http.MethodPost, "/auth/register?x=1", strings.NewReader(`{"a":1}`))
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("Authorization", "Bearer t")
+	r.Header.Set("Cookie", "refresh=abc")
+	r.Header.Set("Accept-Language", "vi")
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	r.Header.Set("X-Admin", "1")
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated || w.Body.String() != `{"ok":true}` {
+		t.Fatalf("response = %d %q", w.Code, w.Body.String())
+	}
+	for name, want := range map[string]string{
+		"Authorization":   "Bearer t",
+		"Cookie":          "refresh=abc",
+		"Accept-Language": "vi",
+		"Content-Type":    "application/json", // mặc định khi client không gửi
+		"X-Forwarded-For": "203.0.113.7",      // IP đã resolve, không phải header client tự khai
+		"X-Admin":         "",
+	} {
+		if v := got.Header.Get(name); v != want {
+			t.Errorf("upstream %s = %q, want %q", name, v, want)
+		}
+	}
+	if got.URL.RawQuery != "x=1" || gotBody != `{"a":1}` {
+		t.Errorf("upstream query=%q body=%q", got.URL.RawQuery, gotBody)
+	}
+
+	if c := w.Header().Values("Set-Cookie"); len(c) != 2 {
+		t.Errorf("Set-Cookie = %v, want both cookies", c)
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=60" || w.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("caching headers not forwarded: %v", w.Header())
+	}
+	if w.Header().Get("X-Internal-Debug") != "" {
+		t.Error("header outside the allowlist leaked to the client")
+	}
+}
+
+func TestHTTPForwardCustomAllowlist(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Header().Set("Set-Cookie", "a=1")
+		w.Header().Set("X-Version", "7")
+	}))
+	defer upstream.Close()
+
+	f := &HTTPForward{Target: upstream.URL, Name: "up", RequestHeaders: []string{"X-Tenant"}, ResponseHeaders: []string{"X-Version"}}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Tenant", "t1")
+	r.Header.Set("Cookie", "s=1")
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, r)
+
+	if got.Get("X-Tenant") != "t1" || got.Get("Cookie") != "" {
+		t.Errorf("upstream headers = %v", got)
+	}
+	if w.Header().Get("X-Version") != "7" || w.Header().Get("Set-Cookie") != "" {
+		t.Errorf("response headers = %v", w.Header())
+	}
+}
+
+func TestHTTPForwardUpstreamDown(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	f := &HTTPForward{Target: upstream.URL, Name: "auth-service"}
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", w.Code)
+	}
+}
+
+func TestHTTPForwardMapErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantCode    string
+		wantDetail  string
+	}{
+		{"conflict", http.StatusConflict, "application/json", `{"error":"email already registered","request_id":"up-1"}`, "ALREADY_EXISTS", "email already registered"},
+		{"bad request", http.StatusBadRequest, "application/json", `{"error":"Invalid JSON"}`, "INVALID_ARGUMENT", "Invalid JSON"},
+		{"internal message hidden", http.StatusInternalServerError, "application/json", `{"error":"mongo: connection refused"}`, "INTERNAL", ""},
+		{"non JSON body", http.StatusBadGateway, "text/plain", "upstream exploded", "UNAVAILABLE", ""},
+		{"problem passthrough", http.StatusTooManyRequests, "application/problem+json", `{"status":429,"code":"CUSTOM"}`, "CUSTOM", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer upstream.Close()
+
+			f := &HTTPForward{Target: upstream.URL, Name: "auth-service", MapErrors: true}
+			w := httptest.NewRecorder()
+			w.Header().Set(platform.RequestIDHeader, "gw-1")
+			f.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader("{}")))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var p util.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("body %q: %v", w.Body, err)
+			}
+			if p.Code != tt.wantCode || p.Detail != tt.wantDetail {
+				t.Errorf("problem = %+v", p)
+			}
+			if tt.contentType != "application/problem+json" && p.RequestID != "gw-1" {
+				t.Errorf("request_id = %q, want gateway id", p.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+)
+
+// GET /api/me  (cần JWT) — trả về user_id trong token
+func Me(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromCtx(r)
+	if !ok || uid == "" {
+		util.Error(w, http.StatusUnauthorized, "missing user")
+		return
+	}
+	util.JSON(w, http.StatusOK, map[string]string{"user_id": uid})
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Session là chế độ cookie cho frontend trình duyệt (SESSION_COOKIES=true): /auth/login
+// không trả token trong body mà đặt hai cookie HttpOnly để JS không đọc được token:
+//   - access (AccessCookie, Path=/): JWT ngắn hạn, cùng định dạng và secret với token của
+//     auth-service nên middleware.JWT và các service đọc như token thường
+//   - refresh (RefreshCookie, Path=/auth): JWT dài hạn ký bằng khoá riêng (không dùng được
+//     làm access token), chỉ gửi kèm /auth/refresh và /auth/logout
+//
+// Cả hai token mang claim "sid" (mã phiên, giữ nguyên khi refresh) để token CSRF gắn với
+// phiên (xem ID). Refresh token không lưu phía server: /auth/logout xoá cookie trên trình
+// duyệt, token đã bị lộ vẫn dùng được tới khi hết hạn.
+type Session struct {
+	Enabled       bool
+	JWTSecret     string
+	AccessCookie  string
+	RefreshCookie string
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+	Domain        string // rỗng = chỉ host của gateway
+	Secure        bool
+	SameSite      string // lax | strict | none
+}
+
+// DefaultSession: tắt, cookie access_token / refresh_token, access 15 phút, refresh 7 ngày
+func DefaultSession() Session {
+	return Session{
+		AccessCookie:  "access_token",
+		RefreshCookie: "refresh_token",
+		AccessTTL:     15 * time.Minute,
+		RefreshTTL:    7 * 24 * time.Hour,
+		Secure:        true,
+		SameSite:      "lax",
+	}
+}
+
+func (s Session) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if s.AccessCookie == "" || s.RefreshCookie == "" || s.AccessCookie == s.RefreshCookie {
+		return errors.New("SESSION_ACCESS_COOKIE and SESSION_REFRESH_COOKIE must be set and differ")
+	}
+	if s.AccessTTL <= 0 || s.RefreshTTL < s.AccessTTL {
+		return errors.New("SESSION_ACCESS_TTL must be positive and not longer than SESSION_REFRESH_TTL")
+	}
+	ss, err := parseSameSite(s.SameSite)
+	if err != nil {
+		return err
+	}
+	if ss == http.SameSiteNoneMode && !s.Secure {
+		return errors.New("SESSION_COOKIE_SAMESITE=none requires SESSION_COOKIE_SECURE=true")
+	}
+	if s.JWTSecret == "" {
+		return errors.New("SESSION_COOKIES requires JWT_SECRET_KEY")
+	}
+	return nil
+}
+
+func parseSameSite(v string) (http.SameSite, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unknown SESSION_COOKIE_SAMESITE %q (want lax, strict or none)", v)
+	}
+}
+
+// refreshKey tách khỏi JWTSecret để refresh token không qua được middleware.JWT
+func (s Session) refreshKey() []byte {
+	m := hmac.New(sha256.New, []byte(s.JWTSecret))
+	m.Write([]byte("gateway refresh token"))
+	return m.Sum(nil)
+}
+
+// newSessionID: mã phiên ngẫu nhiên, cấp lúc login
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// issue đặt cặp cookie mới cho userID trong phiên sid (login và mỗi lần refresh — refresh
+// token được xoay vòng, sid giữ nguyên)
+func (s Session) issue(w http.ResponseWriter, userID, sid string) error {
+	now := time.Now()
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"sid":     sid,
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.AccessTTL).Unix(),
+	}).SignedString([]byte(s.JWTSecret))
+	if err != nil {
+		return err
+	}
+	jti := make([]byte, 16)
+	_, _ = rand.Read(jti)
+	refresh, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"typ":     "refresh",
+		"sid":     sid,
+		"jti":     hex.EncodeToString(jti),
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.RefreshTTL).Unix(),
+	}).SignedString(s.refreshKey())
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, s.cookie(s.AccessCookie, access, "/", s.AccessTTL))
+	http.SetCookie(w, s.cookie(s.RefreshCookie, refresh, "/auth", s.RefreshTTL))
+	w.Header().Set("Cache-Control", "no-store")
+	return nil
+}
+
+func (s Session) clear(w http.ResponseWriter) {
+	http.SetCookie(w, s.cookie(s.AccessCookie, "", "/", -1))
+	http.SetCookie(w, s.cookie(s.RefreshCookie, "", "/auth", -1))
+}
+
+func (s Session) cookie(name, value, path string, ttl time.Duration) *http.Cookie {
+	ss, _ := parseSameSite(s.SameSite) // đã kiểm tra trong Validate
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.Domain,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: ss,
+	}
+	if ttl < 0 {
+		c.MaxAge = -1
+	} else {
+		c.MaxAge = int(ttl.Seconds())
+		c.Expires = time.Now().Add(ttl)
+	}
+	return c
+}
+
+// refreshUser đọc user_id và sid từ refresh cookie còn hạn
+func (s Session) refreshUser(r *http.Request) (uid, sid string, err error) {
+	c, err := r.Cookie(s.RefreshCookie)
+	if err != nil {
+		return "", "", errors.New("missing refresh token")
+	}
+	claims, err := s.parseRefresh(c.Value, jwt.WithExpirationRequired())
+	if err != nil {
+		return "", "", err
+	}
+	uid, _ = claims["user_id"].(string)
+	if uid == "" {
+		return "", "", errors.New("invalid refresh token")
+	}
+	if sid, _ = claims["sid"].(string); sid == "" {
+		sid = newSessionID() // token cấp trước khi có sid
+	}
+	return uid, sid, nil
+}
+
+func (s Session) parseRefresh(token string, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return s.refreshKey(), nil
+	}, opts...); err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if typ, _ := claims["typ"].(string); typ != "refresh" {
+		return nil, errors.New("invalid refresh token")
+	}
+	return claims, nil
+}
+
+// ID trả sid của phiên đăng nhập bằng cookie (access cookie, không có thì refresh cookie —
+// chỉ gửi kèm /auth/*), "" nếu request không có phiên hợp lệ. Chữ ký được kiểm tra nhưng
+// token hết hạn vẫn được tính: access cookie hết hạn không làm đổi phiên, client vẫn
+// gọi được /auth/refresh với cùng token CSRF. Dùng làm middleware.CSRF.Identity.
+func (s Session) ID(r *http.Request) string {
+	if c, err := r.Cookie(s.AccessCookie); err == nil {
+		claims := jwt.MapClaims{}
+		if _, err := jwt.ParseWithClaims(c.Value, claims, func(*jwt.Token) (any, error) {
+			return []byte(s.JWTSecret), nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithoutClaimsValidation()); err == nil {
+			if sid, _ := claims["sid"].(string); sid != "" {
+				return sid
+			}
+		}
+	}
+	if c, err := r.Cookie(s.RefreshCookie); err == nil {
+		if claims, err := s.parseRefresh(c.Value, jwt.WithoutClaimsValidation()); err == nil {
+			sid, _ := claims["sid"].(string)
+			return sid
+		}
+	}
+	return ""
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gwmw "github.com/RibunLoc/WebPersonalBackend/api-gateway/middleware"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testSession() Session {
+	s := DefaultSession()
+	s.Enabled = true
+	s.JWTSecret = "test-secret"
+	return s
+}
+
+func cookiesByName(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+	out := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		out[c.Name] = c
+	}
+	return out
+}
+
+// issued cấp phiên cho u1 / sid rồi trả cookie access và refresh
+func issued(t *testing.T, s Session, sid string) (access, refresh *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := s.issue(w, "u1", sid); err != nil {
+		t.Fatal(err)
+	}
+	c := cookiesByName(w)
+	return c[s.AccessCookie], c[s.RefreshCookie]
+}
+
+func signed(t *testing.T, key []byte, claims jwt.MapClaims) string {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func TestSessionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*Session)
+		wantErr bool
+	}{
+		{"defaults", func(*Session) {}, false},
+		{"disabled ignores rest", func(s *Session) { s.Enabled = false; s.JWTSecret = "" }, false},
+		{"same cookie names", func(s *Session) { s.RefreshCookie = s.AccessCookie }, true},
+		{"empty cookie name", func(s *Session) { s.AccessCookie = "" }, true},
+		{"refresh shorter than access", func(s *Session) { s.RefreshTTL = time.Minute }, true},
+		{"zero access ttl", func(s *Session) { s.AccessTTL = 0 }, true},
+		{"unknown samesite", func(s *Session) { s.SameSite = "loose" }, true},
+		{"samesite none without secure", func(s *Session) { s.SameSite = "none"; s.Secure = false }, true},
+		{"samesite none with secure", func(s *Session) { s.SameSite = "None" }, false},
+		{"no secret", func(s *Session) { s.JWTSecret = "" }, true},
+	}
+	for _, tt := range tests {
+		s := testSession()
+		tt.edit(&s)
+		if err := s.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSessionIssue(t *testing.T) {
+	s := testSession()
+	s.Domain = "holoc.id.vn"
+	s.SameSite = "strict"
+	w := httptest.NewRecorder()
+	if err := s.issue(w, "u1", "sid-1"); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q", w.Header().Get("Cache-Control"))
+	}
+	c := cookiesByName(w)
+	access, refresh := c[s.AccessCookie], c[s.RefreshCookie]
+	if access == nil || refresh == nil {
+		t.Fatalf("cookies = %v", c)
+	}
+	for _, ck := range []*http.Cookie{access, refresh} {
+		if !ck.HttpOnly || !ck.Secure || ck.SameSite != http.SameSiteStrictMode || ck.Domain != "holoc.id.vn" {
+			t.Errorf("%s attributes = %+v", ck.Name, ck)
+		}
+	}
+	if access.Path != "/" || access.MaxAge != int(s.AccessTTL.Seconds()) {
+		t.Errorf("access path/max-age = %q/%d", access.Path, access.MaxAge)
+	}
+	// refresh chỉ gửi kèm /auth/*
+	if refresh.Path != "/auth" || refresh.MaxAge != int(s.RefreshTTL.Seconds()) {
+		t.Errorf("refresh path/max-age = %q/%d", refresh.Path, refresh.MaxAge)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(access.Value, claims, func(*jwt.Token) (any, error) { return []byte(s.JWTSecret), nil }); err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+	if claims["user_id"] != "u1" || claims["sid"] != "sid-1" {
+		t.Errorf("access claims = %v", claims)
+	}
+	if uid, sid, err := s.refreshUser(requestWith(refresh)); uid != "u1" || sid != "sid-1" || err != nil {
+		t.Errorf("refreshUser = %q, %q, %v", uid, sid, err)
+	}
+}
+
+func requestWith(cookies ...*http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestSessionRefreshUser(t *testing.T) {
+	s := testSession()
+	access, refresh := issued(t, s, "sid-1")
+	now := time.Now()
+	expired := signed(t, s.refreshKey(), jwt.MapClaims{"user_id": "u1", "typ": "refresh", "sid": "sid-1", "exp": now.Add(-time.Minute).Unix()})
+	noExp := signed(t, s.refreshKey(), jwt.MapClaims{"user_id": "u1", "typ": "refresh"})
+	wrongTyp := signed(t, s.refreshKey(), jwt.MapClaims{"user_id": "u1", "typ": "access", "exp": now.Add(time.Hour).Unix()})
+	legacy := signed(t, s.refreshKey(), jwt.MapClaims{"user_id": "u1", "typ": "refresh", "exp": now.Add(time.Hour).Unix()})
+
+	for name, c := range map[string]*http.Cookie{
+		"missing":              nil,
+		"access token":         {Name: s.RefreshCookie, Value: access.Value},
+		"expired":              {Name: s.RefreshCookie, Value: expired},
+		"no expiry":            {Name: s.RefreshCookie, Value: noExp},
+		"wrong typ":            {Name: s.RefreshCookie, Value: wrongTyp},
+		"signed with jwt key":  {Name: s.RefreshCookie, Value: signed(t, []byte(s.JWTSecret), jwt.MapClaims{"user_id": "u1", "typ": "refresh", "exp": now.Add(time.Hour).Unix()})},
+		"refresh in access ck": {Name: s.AccessCookie, Value: refresh.Value},
+	} {
+		r := requestWith()
+		if c != nil {
+			r = requestWith(c)
+		}
+		if _, _, err := s.refreshUser(r); err == nil {
+			t.Errorf("%s: refreshUser succeeded", name)
+		}
+	}
+
+	// token cấp trước khi có sid: phiên mới
+	_, sid, err := s.refreshUser(requestWith(&http.Cookie{Name: s.RefreshCookie, Value: legacy}))
+	if err != nil || sid == "" {
+		t.Errorf("legacy refresh token: sid %q, %v", sid, err)
+	}
+}
+
+func TestSessionID(t *testing.T) {
+	s := testSession()
+	access, refresh := issued(t, s, "sid-1")
+	expiredAccess := &http.Cookie{Name: s.AccessCookie, Value: signed(t, []byte(s.JWTSecret),
+		jwt.MapClaims{"user_id": "u1", "sid": "sid-1", "exp": time.Now().Add(-time.Hour).Unix()})}
+	forged := &http.Cookie{Name: s.AccessCookie, Value: signed(t, []byte("other-secret"), jwt.MapClaims{"user_id": "u1", "sid": "sid-evil"})}
+
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		want    string
+	}{
+		{"none", nil, ""},
+		{"access", []*http.Cookie{access}, "sid-1"},
+		{"refresh only", []*http.Cookie{refresh}, "sid-1"},
+		{"expired access", []*http.Cookie{expiredAccess}, "sid-1"},
+		{"forged access", []*http.Cookie{forged}, ""},
+		{"forged access with refresh", []*http.Cookie{forged, refresh}, "sid-1"},
+		{"refresh in access cookie", []*http.Cookie{{Name: s.AccessCookie, Value: refresh.Value}}, ""},
+	}
+	for _, tt := range tests {
+		if got := s.ID(requestWith(tt.cookies...)); got != tt.want {
+			t.Errorf("%s: ID = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSessionCookieAcceptedByJWT(t *testing.T) {
+	s := testSession()
+	access, refresh := issued(t, s, "sid-1")
+	m := gwmw.JWT{Secret: s.JWTSecret, Cookie: s.AccessCookie}
+	var uid string
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ = gwmw.UserIDFromCtx(r)
+	}))
+
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		bearer  string
+		want    int
+	}{
+		{"access cookie", []*http.Cookie{access}, "", http.StatusOK},
+		// refresh token ký bằng khoá riêng: không dùng làm access token được
+		{"refresh as access", []*http.Cookie{{Name: s.AccessCookie, Value: refresh.Value}}, "", http.StatusUnauthorized},
+		{"header wins over cookie", []*http.Cookie{access}, "garbage", http.StatusUnauthorized},
+		{"no credentials", nil, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		uid = ""
+		r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		for _, c := range tt.cookies {
+			r.AddCookie(c)
+		}
+		if tt.bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.bearer)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && uid != "u1" {
+			t.Errorf("%s: user_id = %q", tt.name, uid)
+		}
+	}
+}
+
+func TestAuthProxyRefreshAndLogout(t *testing.T) {
+	h := &AuthProxy{Session: testSession()}
+	s := h.Session
+	_, refresh := issued(t, s, "sid-1")
+
+	w := httptest.NewRecorder()
+	h.Refresh(w, requestWith(refresh))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("refresh: %d %s", w.Code, w.Body)
+	}
+	c := cookiesByName(w)
+	if c[s.AccessCookie] == nil || c[s.RefreshCookie] == nil || c[s.RefreshCookie].Value == refresh.Value {
+		t.Fatalf("refresh did not rotate cookies: %v", c)
+	}
+	// cùng phiên sau khi xoay vòng
+	if sid := s.ID(requestWith(c[s.AccessCookie])); sid != "sid-1" {
+		t.Errorf("sid after refresh = %q", sid)
+	}
+
+	w = httptest.NewRecorder()
+	h.Refresh(w, requestWith(&http.Cookie{Name: s.RefreshCookie, Value: "garbage"}))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with bad token: %d", w.Code)
+	}
+	for _, ck := range cookiesByName(w) {
+		if ck.MaxAge >= 0 {
+			t.Errorf("bad refresh did not clear %s", ck.Name)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.Logout(w, requestWith(refresh))
+	cleared := cookiesByName(w)
+	if w.Code != http.StatusNoContent || len(cleared) != 2 {
+		t.Fatalf("logout: %d, cookies %v", w.Code, cleared)
+	}
+	for _, ck := range cleared {
+		if ck.MaxAge >= 0 || ck.Value != "" {
+			t.Errorf("logout cookie %s = %+v", ck.Name, ck)
+		}
+	}
+	if cleared[s.RefreshCookie].Path != "/auth" {
+		t.Errorf("refresh cookie cleared on path %q, browser keeps the /auth one", cleared[s.RefreshCookie].Path)
+	}
+
+	// chế độ cookie tắt: không có /auth/refresh, /auth/logout
+	off := &AuthProxy{}
+	for name, fn := range map[string]http.HandlerFunc{"refresh": off.Refresh, "logout": off.Logout} {
+		w := httptest.NewRecorder()
+		fn(w, requestWith(refresh))
+		if w.Code != http.StatusNotFound || strings.Contains(w.Header().Get("Set-Cookie"), s.AccessCookie) {
+			t.Errorf("%s with sessions disabled: %d", name, w.Code)
+		}
+	}
+}
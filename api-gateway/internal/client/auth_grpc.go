@@ -6,40 +6,33 @@ import (
 
 	authv1 "github.com/RibunLoc/WebPersonalBackend/gen/auth/v1"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/backoff"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type AuthGRPC struct {
-	cl authv1.UserServiceClient
+	cl      authv1.UserServiceClient
+	Timeout time.Duration
 }
 
 func NewAuthGRPC(addr string) (*AuthGRPC, func() error, error) {
-	conn, err := grpc.Dial(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithConnectParams(grpc.ConnectParams{
-			MinConnectTimeout: 2 * time.Second,
-			Backoff: backoff.Config{
-				BaseDelay:  200 * time.Millisecond,
-				Multiplier: 1.6,
-				Jitter:     0.2,
-				MaxDelay:   3 * time.Second,
-			},
-		}),
-	)
+	conn, err := Dial("auth", addr)
 	if err != nil {
 		return nil, nil, err
 	}
-	return &AuthGRPC{cl: authv1.NewUserServiceClient(conn)}, conn.Close, nil
+	return NewAuthGRPCConn(conn), conn.Close, nil
+}
+
+// NewAuthGRPCConn dùng một kết nối có sẵn (caller chịu trách nhiệm đóng)
+func NewAuthGRPCConn(conn grpc.ClientConnInterface) *AuthGRPC {
+	return &AuthGRPC{cl: authv1.NewUserServiceClient(conn), Timeout: 5 * time.Second}
 }
 
 type LoginInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,maxbytes=72"`
 }
 
 type LoginResult struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"` // rỗng ở chế độ cookie (SESSION_COOKIES)
 	User  struct {
 		ID       string `json:"id"`
 		Email    string `json:"email"`
@@ -49,7 +42,7 @@ type LoginResult struct {
 }
 
 func (a *AuthGRPC) Login(ctx context.Context, in LoginInput) (*LoginResult, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, a.Timeout)
 	defer cancel()
 
 	res, err := a.cl.Login(ctx, &authv1.LoginRequest{
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen được trả khi breaker của upstream đang mở (fail fast, không gọi backend)
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Outcome là kết quả một lời gọi báo lại cho breaker
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeFailure
+	OutcomeIgnored // vd. bản hedge thua bị huỷ: không tính là thành công hay lỗi
+)
+
+// Breaker mở sau Threshold lỗi liên tiếp; sau OpenTimeout chuyển half-open và
+// cho đúng một request thử: thành công thì đóng lại, lỗi thì mở tiếp.
+//
+// Mỗi lần đổi trạng thái tăng generation; kết quả của lời gọi được cho qua ở thế hệ
+// trước (vd. request chậm bắt đầu khi còn đóng, xong khi đã half-open) chỉ vào thống kê.
+type Breaker struct {
+	Threshold   int
+	OpenTimeout time.Duration
+
+	mu         sync.Mutex
+	state      BreakerState
+	generation uint64
+	failures   int // lỗi liên tiếp
+	openedAt   time.Time
+	probing    bool // half-open: đã có request thử đang chạy
+
+	onChange func(BreakerState)
+
+	// thống kê cho /admin/upstreams
+	successTotal  uint64
+	failureTotal  uint64
+	rejectedTotal uint64
+}
+
+// Allow trả hàm done phải gọi đúng một lần khi lời gọi kết thúc,
+// hoặc ErrCircuitOpen nếu breaker đang chặn.
+func (b *Breaker) Allow() (func(Outcome), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.OpenTimeout {
+		b.setState(BreakerHalfOpen)
+	}
+	switch {
+	case b.state == BreakerOpen, b.state == BreakerHalfOpen && b.probing:
+		b.rejectedTotal++
+		return nil, ErrCircuitOpen
+	case b.state == BreakerHalfOpen:
+		b.probing = true
+	}
+
+	gen := b.generation
+	var once sync.Once
+	return func(o Outcome) { once.Do(func() { b.record(gen, o) }) }, nil
+}
+
+func (b *Breaker) record(gen uint64, o Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch o {
+	case OutcomeSuccess:
+		b.successTotal++
+	case OutcomeFailure:
+		b.failureTotal++
+	}
+	if gen != b.generation {
+		return // kết quả muộn của trạng thái cũ
+	}
+	b.probing = false
+	switch o {
+	case OutcomeIgnored:
+		return
+	case OutcomeSuccess:
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *Breaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	b.generation++
+	if s == BreakerClosed {
+		b.failures = 0
+	}
+	if b.onChange != nil {
+		b.onChange(s)
+	}
+}
+
+// BreakerSnapshot là trạng thái breaker tại một thời điểm
+type BreakerSnapshot struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	SuccessTotal        uint64     `json:"success_total"`
+	FailureTotal        uint64     `json:"failure_total"`
+	RejectedTotal       uint64     `json:"rejected_total"`
+}
+
+func (b *Breaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerSnapshot{
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+		SuccessTotal:        b.successTotal,
+		FailureTotal:        b.failureTotal,
+		RejectedTotal:       b.rejectedTotal,
+	}
+	if b.state != BreakerClosed {
+		t := b.openedAt
+		s.OpenedAt = &t
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustAllow(t *testing.T, b *Breaker) func(Outcome) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow: %v (state %s)", err, b.Snapshot().State)
+	}
+	return done
+}
+
+func TestBreakerOpensAndProbes(t *testing.T) {
+	b := &Breaker{Threshold: 2, OpenTimeout: 10 * time.Millisecond}
+	mustAllow(t, b)(OutcomeFailure)
+	mustAllow(t, b)(OutcomeSuccess) // thành công reset đếm lỗi liên tiếp
+	mustAllow(t, b)(OutcomeFailure)
+	mustAllow(t, b)(OutcomeFailure)
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("breaker not open after threshold: %v", err)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	probe := mustAllow(t, b)
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("second request allowed while probing")
+	}
+	probe(OutcomeFailure)
+	if s := b.Snapshot().State; s != "open" {
+		t.Fatalf("failed probe: state %s, want open", s)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	mustAllow(t, b)(OutcomeSuccess)
+	if s := b.Snapshot(); s.State != "closed" || s.ConsecutiveFailures != 0 {
+		t.Errorf("successful probe: %+v", s)
+	}
+}
+
+func TestBreakerIgnoresLateResults(t *testing.T) {
+	b := &Breaker{Threshold: 1, OpenTimeout: 10 * time.Millisecond}
+	slow := mustAllow(t, b) // bắt đầu khi breaker còn đóng
+	mustAllow(t, b)(OutcomeFailure)
+
+	time.Sleep(15 * time.Millisecond)
+	probe := mustAllow(t, b)
+	slow(OutcomeSuccess) // xong muộn: không được đóng breaker hay bỏ cờ probing
+	if s := b.Snapshot().State; s != "half_open" {
+		t.Fatalf("late success changed state to %s", s)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("late result released the probe slot")
+	}
+	probe(OutcomeFailure)
+	if s := b.Snapshot(); s.State != "open" || s.SuccessTotal != 1 {
+		t.Errorf("after failed probe: %+v", s)
+	}
+}
+
+func TestBreakerIgnoredOutcome(t *testing.T) {
+	b := &Breaker{Threshold: 1, OpenTimeout: 10 * time.Millisecond}
+	mustAllow(t, b)(OutcomeIgnored)
+	if s := b.Snapshot(); s.State != "closed" || s.SuccessTotal != 0 || s.FailureTotal != 0 {
+		t.Fatalf("ignored outcome counted: %+v", s)
+	}
+
+	mustAllow(t, b)(OutcomeFailure)
+	time.Sleep(15 * time.Millisecond)
+	mustAllow(t, b)(OutcomeIgnored) // probe bị huỷ: nhả chỗ thử, giữ half-open
+	if s := b.Snapshot().State; s != "half_open" {
+		t.Fatalf("state %s, want half_open", s)
+	}
+	mustAllow(t, b)(OutcomeSuccess)
+	if s := b.Snapshot().State; s != "closed" {
+		t.Errorf("state %s, want closed", s)
+	}
+}
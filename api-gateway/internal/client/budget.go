@@ -0,0 +1,70 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// retryBudget giới hạn tỉ lệ retry trên số request trong khoảng window gần nhất
+// (ước lượng bằng cửa sổ hiện tại + cửa sổ trước, giống sliding window của rate limit)
+type retryBudget struct {
+	ratio  float64
+	min    int
+	window time.Duration
+
+	mu                  sync.Mutex
+	start               time.Time
+	requests, retries   int
+	prevReqs, prevRetry int
+}
+
+// BudgetSnapshot là số request / retry trong khoảng window gần nhất
+type BudgetSnapshot struct {
+	Requests int `json:"requests"`
+	Retries  int `json:"retries"`
+	Limit    int `json:"limit"`
+}
+
+func (b *retryBudget) roll(now time.Time) {
+	start := now.Truncate(b.window)
+	if start.Equal(b.start) {
+		return
+	}
+	if start.Sub(b.start) == b.window {
+		b.prevReqs, b.prevRetry = b.requests, b.retries
+	} else {
+		b.prevReqs, b.prevRetry = 0, 0
+	}
+	b.requests, b.retries, b.start = 0, 0, start
+}
+
+func (b *retryBudget) limit() int {
+	return max(b.min, int(b.ratio*float64(b.requests+b.prevReqs)))
+}
+
+// request ghi nhận một lời gọi gốc (không tính retry)
+func (b *retryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(time.Now())
+	b.requests++
+}
+
+// withdraw lấy một lượt retry/hedge; false nếu đã hết budget
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(time.Now())
+	if b.retries+b.prevRetry >= b.limit() {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+func (b *retryBudget) snapshot() BudgetSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(time.Now())
+	return BudgetSnapshot{Requests: b.requests + b.prevReqs, Retries: b.retries + b.prevRetry, Limit: b.limit()}
+}
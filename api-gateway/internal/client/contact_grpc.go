@@ -6,39 +6,32 @@ import (
 
 	contactv1 "github.com/RibunLoc/WebPersonalBackend/gen/contact/v1"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/backoff"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type ContactGRPC struct {
-	cl contactv1.ContactServiceClient
+	cl      contactv1.ContactServiceClient
+	Timeout time.Duration
 }
 
 func NewContactGRPC(addr string) (*ContactGRPC, func() error, error) {
-	conn, err := grpc.Dial(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithConnectParams(grpc.ConnectParams{
-			MinConnectTimeout: 2 * time.Second,
-			Backoff: backoff.Config{
-				BaseDelay:  200 * time.Millisecond,
-				Multiplier: 1.6,
-				Jitter:     0.2,
-				MaxDelay:   3 * time.Second,
-			},
-		}),
-	)
+	conn, err := Dial("contact", addr)
 	if err != nil {
 		return nil, nil, err
 	}
-	return &ContactGRPC{cl: contactv1.NewContactServiceClient(conn)}, conn.Close, nil
+	return NewContactGRPCConn(conn), conn.Close, nil
+}
+
+// NewContactGRPCConn dùng một kết nối có sẵn (caller chịu trách nhiệm đóng)
+func NewContactGRPCConn(conn grpc.ClientConnInterface) *ContactGRPC {
+	return &ContactGRPC{cl: contactv1.NewContactServiceClient(conn), Timeout: 8 * time.Second}
 }
 
 type ContactSubmitInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Message  string `json:"message"`
-	Token    string `json:"turnstile_token,omitempty"`
-	CFToken  string `json:"cf_turnstile_token,omitempty"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Message  string `json:"message" validate:"required,min=5,max=5000"`
+	Token    string `json:"turnstile_token,omitempty" validate:"max=2048"`
+	CFToken  string `json:"cf_turnstile_token,omitempty" validate:"max=2048"`
 	RemoteIP string `json:"-"` // sẽ set từ header
 }
 
@@ -52,7 +45,7 @@ func (c *ContactGRPC) Submit(ctx context.Context, in ContactSubmitInput) (*Conta
 		tok = in.CFToken
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
 	defer cancel()
 
 	res, err := c.cl.Submit(ctx, &contactv1.ContactRequest{
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Dial mở kết nối gRPC (lazy) với cấu hình backoff chung của gateway.
+// name là tên upstream dùng làm label metrics.
+func Dial(name, addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return DialBalanced(name, addr, DefaultBalancing(), opts...)
+}
+
+// DialBalanced như Dial, addr có thể là danh sách / dns:/// / file:// (xem Balancing)
+func DialBalanced(name, addr string, lb Balancing, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	target, lbOpts, err := lb.dialTarget(addr)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			MinConnectTimeout: 2 * time.Second,
+			Backoff: backoff.Config{
+				BaseDelay:  200 * time.Millisecond,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   3 * time.Second,
+			},
+		}),
+		grpc.WithChainUnaryInterceptor(platform.UnaryClientRequestIDInterceptor, loggingInterceptor, metricsInterceptor(name)),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()), // span client + traceparent trong metadata
+	}, lbOpts...), opts...)
+	return grpc.Dial(target, opts...)
+}
+
+// loggingInterceptor ghi method, status code, latency, upstream và request_id của lời gọi gRPC ra backend
+func loggingInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code != codes.OK {
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.String("peer", cc.Target()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	slog.LogAttrs(ctx, level, "grpc call", attrs...)
+	return err
+}
+
+type clientIPKey struct{}
+
+// WithClientIP gắn IP client (đã resolve ở tầng HTTP) vào context
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPInterceptor ghi đè field string `field` của request bằng IP trong context
+// (nếu có). Dùng cho request transcode từ JSON để client không tự khai remote_ip.
+func ClientIPInterceptor(field string) grpc.UnaryClientInterceptor {
+	name := protoreflect.Name(field)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if ip, ok := ctx.Value(clientIPKey{}).(string); ok {
+			if m, ok := req.(proto.Message); ok {
+				msg := m.ProtoReflect()
+				if fd := msg.Descriptor().Fields().ByName(name); fd != nil && fd.Kind() == protoreflect.StringKind && !fd.IsList() {
+					msg.Set(fd, protoreflect.ValueOfString(ip))
+				}
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
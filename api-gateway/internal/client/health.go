@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthCheck trả hàm gọi grpc.health.v1 Health/Check trên backend.
+// service rỗng = trạng thái chung của cả server.
+func HealthCheck(conn *grpc.ClientConn, service string) func(context.Context) error {
+	hc := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := hc.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("backend reports %s", resp.GetStatus())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Latency gateway -> backend, theo tên upstream ("auth", "contact", tên trong route table)
+var upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "gateway_upstream_request_duration_seconds",
+	Help:    "Latency of requests from the gateway to each backend.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"upstream", "protocol", "code"})
+
+// ObserveUpstream ghi một lời gọi upstream; code là gRPC code hoặc HTTP status
+func ObserveUpstream(upstream, protocol, code string, start time.Time) {
+	upstreamDuration.WithLabelValues(upstream, protocol, code).Observe(time.Since(start).Seconds())
+}
+
+func metricsInterceptor(upstream string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		ObserveUpstream(upstream, "grpc", status.Code(err).String(), start)
+		return err
+	}
+}
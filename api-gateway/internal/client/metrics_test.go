@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// upstreamSamples là số lần quan sát của series upstream/protocol/code
+func upstreamSamples(t *testing.T, upstream, protocol, code string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := upstreamDuration.WithLabelValues(upstream, protocol, code).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	intercept := metricsInterceptor("metrics-test")
+	for _, err := range []error{nil, nil, status.Error(codes.Unavailable, "down"), status.Error(codes.DeadlineExceeded, "slow")} {
+		_ = intercept(context.Background(), "/svc/M", nil, nil, nil,
+			func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return err })
+	}
+	for code, want := range map[string]uint64{"OK": 2, "Unavailable": 1, "DeadlineExceeded": 1, "Internal": 0} {
+		if got := upstreamSamples(t, "metrics-test", "grpc", code); got != want {
+			t.Errorf("code %s: %d samples, want %d", code, got, want)
+		}
+	}
+
+	ObserveUpstream("metrics-test", "http", "502", time.Now().Add(-time.Second))
+	var m dto.Metric
+	_ = upstreamDuration.WithLabelValues("metrics-test", "http", "502").(prometheus.Metric).Write(&m)
+	if h := m.GetHistogram(); h.GetSampleCount() != 1 || h.GetSampleSum() < 1 {
+		t.Errorf("http 502: count %d sum %v, want one sample of about 1s", h.GetSampleCount(), h.GetSampleSum())
+	}
+}
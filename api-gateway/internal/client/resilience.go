@@ -0,0 +1,547 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ResilienceConfig cấu hình breaker, retry và hedging cho mọi upstream
+type ResilienceConfig struct {
+	BreakerThreshold   int           // số lỗi liên tiếp để mở breaker
+	BreakerOpenTimeout time.Duration // thời gian mở trước khi cho request thử (half-open)
+
+	MaxAttempts int           // tổng số lần gọi, kể cả lần đầu (1 = không retry)
+	BackoffBase time.Duration // backoff = random(0, min(BackoffMax, BackoffBase*2^n)) (full jitter)
+	BackoffMax  time.Duration
+
+	// Retry budget: trong 10s gần nhất, số retry không vượt quá
+	// max(BudgetMin, BudgetRatio * số request) để retry không nhân tải khi backend đang quá tải
+	BudgetRatio float64
+	BudgetMin   int
+
+	// HedgeDelay > 0: lời gọi idempotent chưa xong sau HedgeDelay thì gửi thêm một bản,
+	// lấy kết quả về trước và huỷ bản còn lại. Bản hedge tính vào retry budget.
+	HedgeDelay time.Duration
+
+	// Method gRPC idempotent ngoài các method khai báo idempotency_level / GET trong proto
+	// (dạng "/package.Service/Method")
+	IdempotentMethods []string
+}
+
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		BreakerThreshold:   5,
+		BreakerOpenTimeout: 30 * time.Second,
+		MaxAttempts:        3,
+		BackoffBase:        50 * time.Millisecond,
+		BackoffMax:         time.Second,
+		BudgetRatio:        0.2,
+		BudgetMin:          10,
+	}
+}
+
+// Resilience giữ breaker + retry budget của từng upstream. Một instance dùng chung
+// cho mọi thế hệ backend để trạng thái breaker không bị reset khi reload.
+type Resilience struct {
+	cfg        ResilienceConfig
+	idempotent map[string]bool
+
+	mu        sync.Mutex
+	upstreams map[string]*upstreamState
+}
+
+type upstreamState struct {
+	breaker *Breaker
+	budget  *retryBudget
+}
+
+var (
+	upstreamRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_upstream_retries_total",
+		Help: "Retries sent by the gateway to each backend.",
+	}, []string{"upstream"})
+	upstreamHedges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_upstream_hedges_total",
+		Help: "Hedged requests sent by the gateway to each backend.",
+	}, []string{"upstream"})
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gateway_upstream_breaker_state",
+		Help: "Circuit breaker state per backend (0 closed, 1 half-open, 2 open).",
+	}, []string{"upstream"})
+)
+
+func NewResilience(cfg ResilienceConfig) *Resilience {
+	r := &Resilience{cfg: cfg, idempotent: map[string]bool{}, upstreams: map[string]*upstreamState{}}
+	if r.cfg.MaxAttempts < 1 {
+		r.cfg.MaxAttempts = 1
+	}
+	for _, m := range cfg.IdempotentMethods {
+		r.idempotent[m] = true
+	}
+	return r
+}
+
+func (r *Resilience) upstream(name string) *upstreamState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.upstreams[name]; ok {
+		return u
+	}
+	u := &upstreamState{
+		breaker: &Breaker{
+			Threshold:   r.cfg.BreakerThreshold,
+			OpenTimeout: r.cfg.BreakerOpenTimeout,
+			onChange:    func(s BreakerState) { breakerState.WithLabelValues(name).Set(float64(s)) },
+		},
+		budget: &retryBudget{ratio: r.cfg.BudgetRatio, min: r.cfg.BudgetMin, window: 10 * time.Second},
+	}
+	breakerState.WithLabelValues(name).Set(float64(BreakerClosed))
+	r.upstreams[name] = u
+	return u
+}
+
+// UpstreamReport là trạng thái một upstream cho /admin/upstreams
+type UpstreamReport struct {
+	Name    string          `json:"name"`
+	Breaker BreakerSnapshot `json:"breaker"`
+	Budget  BudgetSnapshot  `json:"retry_budget"`
+}
+
+// Snapshot trả trạng thái các upstream đã từng được gọi, sắp theo tên
+func (r *Resilience) Snapshot() []UpstreamReport {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.upstreams))
+	for name := range r.upstreams {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	out := make([]UpstreamReport, 0, len(names))
+	for _, name := range names {
+		u := r.upstream(name)
+		out = append(out, UpstreamReport{Name: name, Breaker: u.breaker.Snapshot(), Budget: u.budget.snapshot()})
+	}
+	return out
+}
+
+// backoff: full jitter, n là số lần đã thử
+func (r *Resilience) backoff(n int) time.Duration {
+	d := r.cfg.BackoffBase << (n - 1)
+	if d <= 0 || d > r.cfg.BackoffMax {
+		d = r.cfg.BackoffMax
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// sleep chờ d hoặc tới khi ctx bị huỷ
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ---- gRPC ----
+
+// DialOption gắn interceptor resilience của upstream name vào kết nối
+func (r *Resilience) DialOption(name string) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(r.UnaryInterceptor(name))
+}
+
+// UnaryInterceptor: breaker cho mọi lời gọi; retry ABORTED / RESOURCE_EXHAUSTED, UNAVAILABLE
+// và hedging cho method idempotent. Method khác chỉ được retry UNAVAILABLE khi chắc chắn request
+// chưa rời gateway (lời gọi chưa có kết nối tới backend nào).
+func (r *Resilience) UnaryInterceptor(name string) grpc.UnaryClientInterceptor {
+	up := r.upstream(name)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		idem := r.isIdempotent(method)
+		// sent (nil với bản hedge) nhận về việc lời gọi đã tới một kết nối hay chưa
+		attempt := func(ctx context.Context, reply any, sent *bool) error {
+			done, err := up.breaker.Allow()
+			if err != nil {
+				return circuitOpenStatus(name)
+			}
+			var p peer.Peer
+			err = invoker(ctx, method, req, reply, cc, append(opts[:len(opts):len(opts)], grpc.Peer(&p))...)
+			if sent != nil {
+				*sent = p.Addr != nil
+			}
+			done(grpcOutcome(ctx, err))
+			return err
+		}
+		var sent bool
+		call := func() error { return attempt(ctx, reply, &sent) }
+		if idem && r.cfg.HedgeDelay > 0 {
+			msg, ok := reply.(proto.Message)
+			if ok {
+				call = func() error {
+					return r.hedgeUnary(ctx, name, up, msg, func(ctx context.Context, reply any) error { return attempt(ctx, reply, nil) })
+				}
+			}
+		}
+
+		up.budget.request()
+		for n := 1; ; n++ {
+			err := call()
+			if err == nil || n >= r.cfg.MaxAttempts || !retryableGRPC(err, idem, sent) || !up.budget.withdraw() {
+				return err
+			}
+			if sleep(ctx, r.backoff(n)) != nil {
+				return err
+			}
+			upstreamRetries.WithLabelValues(name).Inc()
+		}
+	}
+}
+
+// hedgeUnary: mỗi bản gọi dùng reply riêng, bản thắng được merge vào reply của caller
+func (r *Resilience) hedgeUnary(ctx context.Context, name string, up *upstreamState, reply proto.Message, attempt func(context.Context, any) error) error {
+	res, cancel, err := hedge(ctx, r.cfg.HedgeDelay,
+		func() bool {
+			if !up.budget.withdraw() {
+				return false
+			}
+			upstreamHedges.WithLabelValues(name).Inc()
+			return true
+		},
+		func(ctx context.Context) (proto.Message, error) {
+			m := reply.ProtoReflect().New().Interface()
+			return m, attempt(ctx, m)
+		},
+		func(proto.Message) {},
+	)
+	defer cancel()
+	if err != nil {
+		return err
+	}
+	proto.Reset(reply)
+	proto.Merge(reply, res)
+	return nil
+}
+
+func (r *Resilience) isIdempotent(method string) bool {
+	if r.idempotent[method] {
+		return true
+	}
+	// "/package.Service/Method" -> package.Service.Method
+	full := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(full)
+	if err != nil {
+		return false
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return false
+	}
+	opts, _ := md.Options().(*descriptorpb.MethodOptions)
+	switch opts.GetIdempotencyLevel() {
+	case descriptorpb.MethodOptions_NO_SIDE_EFFECTS, descriptorpb.MethodOptions_IDEMPOTENT:
+		return true
+	}
+	rule, _ := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	return rule.GetGet() != ""
+}
+
+// grpcOutcome: lời gọi bị huỷ (bản hedge thua, client bỏ đi) không tính; các code cho thấy
+// backend có vấn đề là lỗi; lỗi nghiệp vụ (INVALID_ARGUMENT, NOT_FOUND, ...) là thành công
+func grpcOutcome(ctx context.Context, err error) Outcome {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return OutcomeIgnored
+	}
+	switch status.Code(err) {
+	case codes.Canceled:
+		return OutcomeIgnored
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.DataLoss:
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// retryableGRPC: sent = lời gọi đã tới một kết nối (backend có thể đã xử lý)
+func retryableGRPC(err error, idempotent, sent bool) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return !isCircuitOpen(err) && (idempotent || !sent)
+	case codes.Aborted, codes.ResourceExhausted:
+		return idempotent
+	}
+	return false
+}
+
+const circuitOpenReason = "CIRCUIT_OPEN"
+
+func circuitOpenStatus(name string) error {
+	st, _ := status.New(codes.Unavailable, name+" unavailable (circuit open)").WithDetails(&errdetails.ErrorInfo{
+		Reason:   circuitOpenReason,
+		Domain:   "gateway.holoc.id.vn",
+		Metadata: map[string]string{"upstream": name},
+	})
+	return st.Err()
+}
+
+func isCircuitOpen(err error) bool {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetReason() == circuitOpenReason {
+			return true
+		}
+	}
+	return false
+}
+
+// ---- HTTP ----
+
+// Transport bọc base (nil = http.DefaultTransport) bằng breaker + retry + hedging của upstream name.
+// Retry chỉ khi body đọc lại được (GetBody): method idempotent (hoặc có Idempotency-Key)
+// được retry khi lỗi transport / 502 / 503 / 504, method khác chỉ khi chưa kết nối được.
+func (r *Resilience) Transport(name string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &resilientTransport{r: r, name: name, up: r.upstream(name), base: base}
+}
+
+type resilientTransport struct {
+	r    *Resilience
+	name string
+	up   *upstreamState
+	base http.RoundTripper
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idem := idempotentHTTP(req)
+	rewindable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	ctx := req.Context()
+
+	call := func() (*http.Response, error) { return t.attempt(req) }
+	if idem && rewindable && t.r.cfg.HedgeDelay > 0 {
+		call = func() (*http.Response, error) { return t.hedge(req) }
+	}
+
+	t.up.budget.request()
+	for n := 1; ; n++ {
+		resp, err := call()
+		if n >= t.r.cfg.MaxAttempts || !rewindable || !retryableHTTP(resp, err, idem) || !t.up.budget.withdraw() {
+			return resp, err
+		}
+		if sleep(ctx, t.r.backoff(n)) != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		next, cerr := rewind(req, ctx)
+		if cerr != nil {
+			return nil, cerr
+		}
+		req = next
+		upstreamRetries.WithLabelValues(t.name).Inc()
+	}
+}
+
+func (t *resilientTransport) attempt(req *http.Request) (*http.Response, error) {
+	done, err := t.up.breaker.Allow()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", t.name, err)
+	}
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case errors.Is(req.Context().Err(), context.Canceled):
+		done(OutcomeIgnored) // bản hedge thua hoặc client bỏ đi
+	case err == nil && resp.StatusCode < http.StatusInternalServerError:
+		done(OutcomeSuccess)
+	default:
+		done(OutcomeFailure)
+	}
+	return resp, err
+}
+
+func (t *resilientTransport) hedge(req *http.Request) (*http.Response, error) {
+	resp, cancel, err := hedge(req.Context(), t.r.cfg.HedgeDelay,
+		func() bool {
+			if !t.up.budget.withdraw() {
+				return false
+			}
+			upstreamHedges.WithLabelValues(t.name).Inc()
+			return true
+		},
+		func(ctx context.Context) (*http.Response, error) {
+			r, err := rewind(req, ctx)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := t.attempt(r)
+			if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+				// 5xx: để bản còn lại có cơ hội thắng
+				return resp, &statusError{resp}
+			}
+			return resp, err
+		},
+		func(resp *http.Response) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+		},
+	)
+	var se *statusError
+	if errors.As(err, &se) {
+		resp, err = se.resp, nil
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// context của bản được trả (kể cả phản hồi 5xx) chỉ được huỷ khi caller đọc xong body
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type statusError struct{ resp *http.Response }
+
+func (e *statusError) Error() string { return e.resp.Status }
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// rewind tạo bản sao request với body mới (GetBody) và context ctx
+func rewind(req *http.Request, ctx context.Context) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+func idempotentHTTP(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func retryableHTTP(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		// chưa kết nối được: request chắc chắn chưa tới backend
+		var op *net.OpError
+		if errors.As(err, &op) && op.Op == "dial" {
+			return true
+		}
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// ---- hedging ----
+
+// hedge chạy fn; nếu sau delay chưa có kết quả và allow() cho phép thì chạy thêm một bản.
+// Trả kết quả thành công đầu tiên, hoặc kết quả lỗi cuối cùng nếu mọi bản đều lỗi;
+// cả hai trường hợp đều kèm cancel của bản được trả, caller gọi khi dùng xong.
+// Bản thua bị huỷ và mọi kết quả không trả cho caller đều được đưa vào discard.
+func hedge[T any](ctx context.Context, delay time.Duration, allow func() bool, fn func(context.Context) (T, error), discard func(T)) (T, context.CancelFunc, error) {
+	type result struct {
+		i   int
+		v   T
+		err error
+	}
+	results := make(chan result, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		actx, cancel := context.WithCancel(ctx)
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			v, err := fn(actx)
+			results <- result{i, v, err}
+		}()
+	}
+
+	launch()
+	running := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var last *result
+	for running > 0 {
+		select {
+		case res := <-results:
+			running--
+			if last != nil {
+				discard(last.v)
+			}
+			if res.err != nil {
+				last = &res
+				continue
+			}
+			for i, c := range cancels {
+				if i != res.i {
+					c()
+				}
+			}
+			if running > 0 {
+				go func() { discard((<-results).v) }()
+			}
+			return res.v, cancels[res.i], nil
+		case <-timer.C:
+			if len(cancels) == 1 && allow() {
+				launch()
+				running++
+			}
+		}
+	}
+	for i, c := range cancels {
+		if i != last.i {
+			c()
+		}
+	}
+	return last.v, cancels[last.i], last.err
+}
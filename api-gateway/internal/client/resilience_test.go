@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func testResilience(mut func(*ResilienceConfig)) *Resilience {
+	cfg := DefaultResilienceConfig()
+	cfg.BackoffBase, cfg.BackoffMax = time.Millisecond, time.Millisecond
+	if mut != nil {
+		mut(&cfg)
+	}
+	return NewResilience(cfg)
+}
+
+// invokerReaching giả lập lời gọi đã (reached=true) hoặc chưa tới được backend
+func invokerReaching(reached bool, calls *atomic.Int32, err error) grpc.UnaryInvoker {
+	return func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls.Add(1)
+		if reached {
+			for _, o := range opts {
+				if p, ok := o.(grpc.PeerCallOption); ok {
+					*p.PeerAddr = peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50051}}
+				}
+			}
+		}
+		return err
+	}
+}
+
+func TestUnaryRetryUnavailable(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	tests := []struct {
+		name    string
+		method  string
+		reached bool
+		want    int32
+	}{
+		{"non-idempotent never sent", "/test.Orders/Create", false, 3},
+		{"non-idempotent reached backend", "/test.Orders/Create", true, 1},
+		{"idempotent reached backend", "/test.Orders/Get", true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testResilience(func(c *ResilienceConfig) { c.IdempotentMethods = []string{"/test.Orders/Get"} })
+			var calls atomic.Int32
+			err := r.UnaryInterceptor("orders")(context.Background(), tt.method, nil, nil, nil, invokerReaching(tt.reached, &calls, unavailable))
+			if status.Code(err) != codes.Unavailable {
+				t.Errorf("err = %v", err)
+			}
+			if calls.Load() != tt.want {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryHedgeLoserIsNeutral(t *testing.T) {
+	r := testResilience(func(c *ResilienceConfig) {
+		c.HedgeDelay = 10 * time.Millisecond
+		c.IdempotentMethods = []string{"/test.Orders/Get"}
+	})
+	var calls atomic.Int32
+	loserDone := make(chan struct{})
+	invoker := func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		if calls.Add(1) == 1 {
+			<-ctx.Done() // bản đầu treo tới khi bị huỷ
+			defer close(loserDone)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.(*healthpb.HealthCheckResponse).Status = healthpb.HealthCheckResponse_SERVING
+		return nil
+	}
+
+	reply := &healthpb.HealthCheckResponse{}
+	if err := r.UnaryInterceptor("orders")(context.Background(), "/test.Orders/Get", nil, reply, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("reply not merged from winner: %v", reply)
+	}
+	<-loserDone
+	checkBreaker(t, r, "orders", 1, 0)
+}
+
+func TestHTTPHedgeLoserIsNeutral(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	r := testResilience(func(c *ResilienceConfig) { c.HedgeDelay = 10 * time.Millisecond })
+	loserDone := make(chan struct{})
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			defer close(loserDone)
+		}
+		return resp, err
+	})
+	c := &http.Client{Transport: r.Transport("posts", base)}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	<-loserDone
+	checkBreaker(t, r, "posts", 1, 0)
+}
+
+func TestHTTPHedgeAllFailKeepsBody(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(30 * time.Millisecond) // để bản hedge được phát
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.(http.Flusher).Flush()
+		// body tới sau khi hedge đã trả phản hồi cho caller
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("boom"))
+	}))
+	defer srv.Close()
+
+	r := testResilience(func(c *ResilienceConfig) { c.HedgeDelay = 10 * time.Millisecond })
+	c := &http.Client{Transport: r.Transport("posts", nil)}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "boom" {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || calls.Load() != 2 {
+		t.Errorf("status = %d, calls = %d", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestHTTPRetryOnlyIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c := &http.Client{Transport: testResilience(nil).Transport("posts", nil)}
+
+	resp, _ := c.Post(srv.URL, "application/json", nil)
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("POST without Idempotency-Key sent %d times", calls.Load())
+	}
+
+	calls.Store(0)
+	resp, _ = c.Get(srv.URL)
+	resp.Body.Close()
+	if calls.Load() != 3 {
+		t.Errorf("GET sent %d times, want 3", calls.Load())
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// checkBreaker đợi một chút để bản thua kịp báo kết quả cho breaker rồi so thống kê
+func checkBreaker(t *testing.T, r *Resilience, name string, success, failure uint64) {
+	t.Helper()
+	time.Sleep(20 * time.Millisecond)
+	if s := r.upstream(name).breaker.Snapshot(); s.SuccessTotal != success || s.FailureTotal != failure {
+		t.Errorf("breaker %s: success %d failure %d, want %d/%d", name, s.SuccessTotal, s.FailureTotal, success, failure)
+	}
+}
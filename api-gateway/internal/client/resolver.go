@@ -0,0 +1,231 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/balancer/leastrequest" // least_request_experimental
+	_ "google.golang.org/grpc/health"                // health check phía client (healthCheckConfig)
+	"google.golang.org/grpc/resolver"
+)
+
+// Balancing cấu hình cách gateway chọn instance khi một upstream có nhiều địa chỉ.
+// Địa chỉ upstream có thể là:
+//
+//	host:port                    một instance (như trước)
+//	host1:port,host2:port        danh sách tĩnh
+//	dns:///contact-service:50052 mọi bản ghi A/AAAA của tên (headless service, docker compose scale)
+//	file:///etc/gateway/contact  file, mỗi dòng một host:port; file đổi thì danh sách đổi theo
+type Balancing struct {
+	Policy      string        // round_robin (mặc định) | least_request | pick_first
+	HealthCheck bool          // loại instance báo NOT_SERVING qua grpc.health.v1
+	FileRefresh time.Duration // chu kỳ kiểm tra file:// thay đổi
+}
+
+func DefaultBalancing() Balancing {
+	return Balancing{Policy: "round_robin", HealthCheck: true, FileRefresh: 5 * time.Second}
+}
+
+// Validate kiểm tra Policy
+func (b Balancing) Validate() error {
+	_, err := b.lbConfig()
+	return err
+}
+
+func (b Balancing) lbConfig() (map[string]any, error) {
+	switch b.Policy {
+	case "", "round_robin":
+		return map[string]any{"round_robin": map[string]any{}}, nil
+	case "least_request":
+		return map[string]any{"least_request_experimental": map[string]any{"choiceCount": 2}}, nil
+	case "pick_first":
+		return map[string]any{"pick_first": map[string]any{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown load balancing policy %q (want round_robin, least_request or pick_first)", b.Policy)
+	}
+}
+
+// dialTarget chuyển địa chỉ cấu hình thành target gRPC, kèm resolver + service config
+func (b Balancing) dialTarget(addr string) (string, []grpc.DialOption, error) {
+	lb, err := b.lbConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	sc := map[string]any{"loadBalancingConfig": []any{lb}}
+	if b.HealthCheck {
+		sc["healthCheckConfig"] = map[string]any{"serviceName": ""} // trạng thái chung của server
+	}
+	js, err := json.Marshal(sc)
+	if err != nil {
+		return "", nil, err
+	}
+	opts := []grpc.DialOption{grpc.WithDefaultServiceConfig(string(js))}
+
+	addr = strings.TrimSpace(addr)
+	switch {
+	case strings.HasPrefix(addr, "file://"):
+		refresh := b.FileRefresh
+		if refresh <= 0 {
+			refresh = DefaultBalancing().FileRefresh
+		}
+		opts = append(opts, grpc.WithResolvers(&fileResolverBuilder{refresh: refresh}))
+		return addr, opts, nil
+	case strings.Contains(addr, ","):
+		var addrs []string
+		for _, a := range strings.Split(addr, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				addrs = append(addrs, a)
+			}
+		}
+		if len(addrs) == 0 {
+			return "", nil, fmt.Errorf("address list %q has no addresses", addr)
+		}
+		opts = append(opts, grpc.WithResolvers(&staticResolverBuilder{addrs: addrs}))
+		return "static:///" + addrs[0], opts, nil
+	case addr == "":
+		return "", nil, errors.New("empty address")
+	default:
+		return addr, opts, nil
+	}
+}
+
+// ---- danh sách tĩnh ----
+
+type staticResolverBuilder struct{ addrs []string }
+
+func (*staticResolverBuilder) Scheme() string { return "static" }
+
+func (b *staticResolverBuilder) Build(_ resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	if err := cc.UpdateState(resolver.State{Endpoints: endpoints(b.addrs)}); err != nil {
+		return nil, err
+	}
+	return staticResolver{}, nil
+}
+
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+func (staticResolver) Close()                                {}
+
+// ---- file ----
+
+type fileResolverBuilder struct{ refresh time.Duration }
+
+func (*fileResolverBuilder) Scheme() string { return "file" }
+
+func (b *fileResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	path := target.URL.Path
+	if target.URL.Host != "" { // file://relative/path
+		path = target.URL.Host + path
+	}
+	r := &fileResolver{path: path, cc: cc, now: make(chan struct{}, 1), done: make(chan struct{})}
+	if err := r.update(); err != nil {
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.watch(b.refresh)
+	return r, nil
+}
+
+// fileResolver đọc lại file khi mtime đổi (hoặc khi gRPC yêu cầu ResolveNow)
+type fileResolver struct {
+	path    string
+	cc      resolver.ClientConn
+	modTime time.Time
+	addrs   string
+
+	now  chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (r *fileResolver) watch(refresh time.Duration) {
+	defer r.wg.Done()
+	t := time.NewTicker(refresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+		case <-r.now:
+		}
+		if err := r.update(); err != nil {
+			slog.Warn("upstream address file unreadable, keeping previous list", "file", r.path, "error", err)
+			r.cc.ReportError(err)
+		}
+	}
+}
+
+func (r *fileResolver) update() error {
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("upstream address file: %w", err)
+	}
+	if fi.ModTime().Equal(r.modTime) {
+		return nil
+	}
+	addrs, err := readAddrFile(r.path)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("upstream address file %s: no addresses", r.path)
+	}
+	r.modTime = fi.ModTime()
+	if joined := strings.Join(addrs, ","); joined != r.addrs {
+		r.addrs = joined
+		slog.Info("upstream addresses updated", "file", r.path, "addrs", addrs)
+	}
+	return r.cc.UpdateState(resolver.State{Endpoints: endpoints(addrs)})
+}
+
+func (r *fileResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *fileResolver) Close() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// readAddrFile: mỗi dòng một host:port, dòng trống và phần sau # được bỏ qua
+func readAddrFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("upstream address file: %w", err)
+	}
+	defer f.Close()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("upstream address file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+func endpoints(addrs []string) []resolver.Endpoint {
+	eps := make([]resolver.Endpoint, 0, len(addrs))
+	for _, a := range addrs {
+		eps = append(eps, resolver.Endpoint{Addresses: []resolver.Address{{Addr: a}}})
+	}
+	return eps
+}
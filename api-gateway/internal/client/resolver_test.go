@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// lbServer là một instance upstream: đếm số RPC Check nhận được (Watch của
+// health check phía client là stream nên không bị đếm)
+type lbServer struct {
+	addr   string
+	health *health.Server
+	calls  atomic.Int32
+}
+
+func startLBServer(t *testing.T) *lbServer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &lbServer{addr: lis.Addr().String(), health: health.NewServer()}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+		s.calls.Add(1)
+		return h(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, s.health)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return s
+}
+
+func dialLB(t *testing.T, addr string, lb Balancing) healthpb.HealthClient {
+	t.Helper()
+	cc, err := DialBalanced("test", addr, lb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return healthpb.NewHealthClient(cc)
+}
+
+// callN gọi Check n lần, trả về số lần mỗi server nhận được trong đợt đó
+func callN(t *testing.T, c healthpb.HealthClient, n int, servers ...*lbServer) []int32 {
+	t.Helper()
+	before := make([]int32, len(servers))
+	for i, s := range servers {
+		before[i] = s.calls.Load()
+	}
+	for range n {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := c.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		cancel()
+		if err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	got := make([]int32, len(servers))
+	for i, s := range servers {
+		got[i] = s.calls.Load() - before[i]
+	}
+	return got
+}
+
+// eventually gọi lại cond tới khi đúng hoặc hết hạn
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestBalancingValidate(t *testing.T) {
+	for _, p := range []string{"", "round_robin", "least_request", "pick_first"} {
+		if err := (Balancing{Policy: p}).Validate(); err != nil {
+			t.Errorf("Validate(%q) = %v", p, err)
+		}
+	}
+	if err := (Balancing{Policy: "random"}).Validate(); err == nil {
+		t.Error("Validate(random) = nil, want error")
+	}
+	if _, err := DialBalanced("test", "127.0.0.1:1", Balancing{Policy: "random"}); err == nil {
+		t.Error("DialBalanced with unknown policy succeeded")
+	}
+}
+
+func TestDialTarget(t *testing.T) {
+	cases := []struct{ addr, want string }{
+		{"auth-service:50051", "auth-service:50051"},
+		{" a:1 , b:2 ,", "static:///a:1"},
+		{"dns:///contact-service:50052", "dns:///contact-service:50052"},
+		{"file:///etc/gateway/contact", "file:///etc/gateway/contact"},
+	}
+	for _, c := range cases {
+		got, _, err := DefaultBalancing().dialTarget(c.addr)
+		if err != nil || got != c.want {
+			t.Errorf("dialTarget(%q) = %q, %v; want %q", c.addr, got, err, c.want)
+		}
+	}
+	// danh sách chỉ có dấu phẩy / khoảng trắng: lỗi cấu hình, không panic
+	for _, addr := range []string{",", " , ", ",,", "", "  "} {
+		if _, _, err := DefaultBalancing().dialTarget(addr); err == nil {
+			t.Errorf("dialTarget(%q) = nil error", addr)
+		}
+	}
+}
+
+func TestReadAddrFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addrs")
+	os.WriteFile(path, []byte("# upstream\n a:1 \n\nb:2 # zone b\n#c:3\n"), 0o600)
+	got, err := readAddrFile(path)
+	if err != nil || strings.Join(got, ",") != "a:1,b:2" {
+		t.Errorf("readAddrFile = %v, %v; want [a:1 b:2]", got, err)
+	}
+	if _, err := readAddrFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("readAddrFile(missing) = nil error")
+	}
+}
+
+func TestBalancingSpreadsLoad(t *testing.T) {
+	for _, policy := range []string{"round_robin", "least_request"} {
+		t.Run(policy, func(t *testing.T) {
+			a, b := startLBServer(t), startLBServer(t)
+			c := dialLB(t, a.addr+","+b.addr, Balancing{Policy: policy, HealthCheck: true})
+
+			got := callN(t, c, 40, a, b)
+			if got[0] == 0 || got[1] == 0 {
+				t.Errorf("calls per instance = %v, want both > 0", got)
+			}
+		})
+	}
+
+	t.Run("pick_first", func(t *testing.T) {
+		a, b := startLBServer(t), startLBServer(t)
+		c := dialLB(t, a.addr+","+b.addr, Balancing{Policy: "pick_first"})
+		if got := callN(t, c, 10, a, b); got[0] != 10 || got[1] != 0 {
+			t.Errorf("calls per instance = %v, want [10 0]", got)
+		}
+	})
+}
+
+func TestHealthCheckEjectsNotServing(t *testing.T) {
+	a, b := startLBServer(t), startLBServer(t)
+	c := dialLB(t, a.addr+","+b.addr, Balancing{Policy: "round_robin", HealthCheck: true})
+	if got := callN(t, c, 20, a, b); got[1] == 0 {
+		t.Fatalf("calls per instance = %v, want b in rotation", got)
+	}
+
+	b.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	eventually(t, "b ejected", func() bool { return callN(t, c, 10, a, b)[1] == 0 })
+	if got := callN(t, c, 20, a, b); got[1] != 0 {
+		t.Errorf("NOT_SERVING instance still got %d calls", got[1])
+	}
+
+	b.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	eventually(t, "b back in rotation", func() bool { return callN(t, c, 10, a, b)[1] > 0 })
+}
+
+func TestFileResolverFollowsFile(t *testing.T) {
+	a, b := startLBServer(t), startLBServer(t)
+	path := filepath.Join(t.TempDir(), "contact")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// mtime tường minh: ghi liên tiếp trong cùng tick đồng hồ vẫn được coi là đổi
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write(a.addr+"\n", start)
+
+	c := dialLB(t, "file://"+path, Balancing{Policy: "round_robin", FileRefresh: 10 * time.Millisecond})
+	if got := callN(t, c, 10, a, b); got[0] != 10 {
+		t.Fatalf("calls per instance = %v, want all on a", got)
+	}
+
+	write("# a đang bảo trì\n"+b.addr+"\n", start.Add(time.Minute))
+	eventually(t, "switch to b", func() bool { return callN(t, c, 5, a, b)[1] == 5 })
+
+	// file hỏng / rỗng: giữ danh sách cũ
+	write("", start.Add(2*time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if got := callN(t, c, 5, a, b); got[1] != 5 {
+		t.Errorf("after empty file calls per instance = %v, want previous list kept", got)
+	}
+
+	write(a.addr+"\n"+b.addr+"\n", start.Add(3*time.Minute))
+	eventually(t, "both in rotation", func() bool {
+		got := callN(t, c, 10, a, b)
+		return got[0] > 0 && got[1] > 0
+	})
+}
+
+func TestFileResolverMissingFile(t *testing.T) {
+	// grpc.Dial dựng resolver ngay nên file thiếu là lỗi lúc khởi động, không phải lúc gọi
+	if _, err := DialBalanced("test", "file://"+filepath.Join(t.TempDir(), "missing"), DefaultBalancing()); err == nil {
+		t.Error("DialBalanced with missing address file succeeded")
+	}
+}
+
+func TestDNSResolver(t *testing.T) {
+	a := startLBServer(t)
+	_, port, _ := net.SplitHostPort(a.addr)
+	c := dialLB(t, "dns:///localhost:"+port, DefaultBalancing())
+	if got := callN(t, c, 5, a); got[0] != 5 {
+		t.Errorf("calls = %v, want 5", got)
+	}
+}
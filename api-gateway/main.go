@@ -2,26 +2,41 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
+	"time"
 
 	"github.com/RibunLoc/WebPersonalBackend/api-gateway/application"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
+	slog.SetDefault(platform.NewLogger("api-gateway"))
+
+	// SIGINT/SIGTERM được xử lý trong App.Start (platform.Lifecycle) để drain có thứ tự
+	ctx := context.Background()
+
+	shutdownTracing, err := platform.InitTracing(ctx, "api-gateway")
+	if err != nil {
+		slog.Error("failed to init tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
 
 	cfg := application.LoadConfig()
 
 	app, err := application.New(ctx, cfg)
 	if err != nil {
-		fmt.Println("failed to init app:", err)
-		return
+		slog.Error("failed to init app", "error", err)
+		os.Exit(1)
 	}
 
 	if err := app.Start(ctx); err != nil {
-		fmt.Println("server error:", err)
+		slog.Error("server error", "error", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+)
+
+// AdminToken bảo vệ các route /admin bằng một bearer token tĩnh.
+// Token rỗng nghĩa là admin API bị tắt.
+type AdminToken struct {
+	Token string
+}
+
+func (m AdminToken) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.Token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") ||
+			subtle.ConstantTimeCompare([]byte(parts[1]), []byte(m.Token)) != 1 {
+			util.Error(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+)
+
+// BodyLimit giới hạn kích thước body (byte); đọc vượt quá sẽ trả *http.MaxBytesError.
+// BodyLimit đặt sau thay thế giới hạn đặt trước (vd. giới hạn riêng của route thay cho
+// BODY_LIMIT_DEFAULT của router), nên route có thể nới lên hoặc siết xuống.
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if n > 0 && r.Body != nil && r.Body != http.NoBody {
+				orig := r.Body
+				if lb, ok := orig.(*limitedBody); ok {
+					orig = lb.orig
+				}
+				r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, orig, n), orig: orig}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	orig io.ReadCloser
+}
@@ -0,0 +1,321 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache lưu response GET công khai theo Cache-Control của upstream:
+//   - thời gian tươi: s-maxage, rồi max-age, rồi Expires; không có gì thì DefaultTTL (0 = không cache)
+//   - hết tươi nhưng còn trong stale-while-revalidate: trả bản cũ và làm mới ở nền
+//     (must-revalidate / proxy-revalidate: không dùng bản cũ)
+//   - không lưu khi có no-store / private / no-cache, Set-Cookie, Vary: * hoặc Vary theo
+//     header không nằm trong VaryHeaders (Accept-Encoding chỉ được bỏ qua khi body chưa nén)
+//
+// Key gồm path, query (đã sắp xếp) và giá trị các header trong VaryHeaders. Các request miss
+// cùng key được gộp (singleflight): chỉ một request tới backend. Request có Bypass(r) = true
+// (vd. đã đăng nhập) hoặc Cache-Control: no-store đi thẳng backend; no-cache thì lấy mới và lưu lại.
+type Cache struct {
+	Store       CacheStore
+	DefaultTTL  time.Duration
+	DefaultSWR  time.Duration // stale-while-revalidate khi upstream không khai báo
+	VaryHeaders []string
+	MaxBody     int // response lớn hơn thì không lưu (mặc định 1 MiB)
+	Bypass      func(r *http.Request) bool
+	Timeout     time.Duration // thời hạn gọi backend khi miss và khi làm mới ở nền (mặc định 30s)
+}
+
+// CacheHeader cho biết response lấy từ đâu: HIT | STALE | MISS | BYPASS
+const CacheHeader = "X-Cache"
+
+var cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_cache_requests_total",
+	Help: "GET requests through the response cache by result (hit, stale, miss, coalesced, bypass, error).",
+}, []string{"result"})
+
+// header theo từng kết nối / từng request, không lưu cùng response
+var uncachedHeaders = []string{"Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade", "Set-Cookie", "Age", CacheHeader, platform.RequestIDHeader}
+
+func (c Cache) Middleware(next http.Handler) http.Handler {
+	if c.Store == nil {
+		c.Store = NewMemoryCacheStore(10000, 64<<20)
+	}
+	if c.MaxBody <= 0 {
+		c.MaxBody = 1 << 20
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	var group singleflight.Group
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || reqCC.has("no-store") ||
+			r.Header.Get("Range") != "" || (c.Bypass != nil && c.Bypass(r)) {
+			cacheRequests.WithLabelValues("bypass").Inc()
+			w.Header().Set(CacheHeader, "BYPASS")
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		key := c.key(r)
+
+		if !reqCC.has("no-cache") {
+			cached, err := c.Store.Get(ctx, key)
+			if err != nil {
+				cacheRequests.WithLabelValues("error").Inc()
+				slog.WarnContext(ctx, "cache store unavailable", "error", err)
+			}
+			if cached != nil {
+				age := cached.age(time.Now())
+				if age < cached.Fresh {
+					cacheRequests.WithLabelValues("hit").Inc()
+					c.serve(w, r, cached, "HIT")
+					return
+				}
+				if age < cached.Fresh+cached.Stale {
+					cacheRequests.WithLabelValues("stale").Inc()
+					c.serve(w, r, cached, "STALE")
+					// làm mới ở nền; singleflight để chỉ một lần làm mới cho mỗi key
+					bg := r.Clone(context.WithoutCancel(ctx))
+					go func() {
+						ctx, cancel := context.WithTimeout(bg.Context(), c.Timeout)
+						defer cancel()
+						_, _, _ = group.Do(key, func() (any, error) { return c.fetch(next, bg.WithContext(ctx), key), nil })
+					}()
+					return
+				}
+			}
+		}
+
+		// miss: request đầu tiên gọi backend, các request cùng key chờ và dùng chung kết quả
+		// nếu kết quả lưu được (công khai); không thì mỗi request tự gọi backend
+		// Request dẫn đầu không bị huỷ theo client (các request khác đang chờ kết quả) nên cần
+		// thời hạn riêng: backend treo thì cả nhóm chờ tối đa Timeout
+		leader := false
+		v, _, _ := group.Do(key, func() (any, error) {
+			leader = true
+			fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.Timeout)
+			defer cancel()
+			return c.fetch(next, r.WithContext(fctx), key), nil
+		})
+		res := v.(*cacheFetch)
+		switch {
+		case leader:
+			cacheRequests.WithLabelValues("miss").Inc()
+			c.serve(w, r, res.resp, "MISS")
+		case res.stored:
+			cacheRequests.WithLabelValues("coalesced").Inc()
+			c.serve(w, r, res.resp, "HIT")
+		default:
+			cacheRequests.WithLabelValues("miss").Inc()
+			w.Header().Set(CacheHeader, "MISS")
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+type cacheFetch struct {
+	resp   *CachedResponse
+	stored bool
+}
+
+// fetch gọi backend (luôn bằng GET, bỏ header điều kiện để có response đầy đủ), ghi vào
+// bộ nhớ đệm rồi lưu vào store nếu được phép
+func (c Cache) fetch(next http.Handler, r *http.Request, key string) *cacheFetch {
+	r = r.Clone(r.Context())
+	r.Method = http.MethodGet
+	for _, h := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since"} {
+		r.Header.Del(h)
+	}
+	rec := &bufferedResponse{header: http.Header{}}
+	// util.WriteProblem lấy request_id từ header của response
+	if id := platform.RequestIDFromContext(r.Context()); id != "" {
+		rec.header.Set(platform.RequestIDHeader, id)
+	}
+	next.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	for _, h := range uncachedHeaders {
+		if h != "Set-Cookie" {
+			rec.header.Del(h)
+		}
+	}
+	resp := &CachedResponse{Status: rec.status, Header: rec.header, Body: rec.buf.Bytes(), StoredAt: time.Now()}
+
+	fresh, swr, ok := c.freshness(resp, rec.buf.Len())
+	if !ok {
+		return &cacheFetch{resp: resp}
+	}
+	resp.Fresh, resp.Stale = fresh, swr
+	if err := c.Store.Set(r.Context(), key, resp, fresh+swr); err != nil {
+		cacheRequests.WithLabelValues("error").Inc()
+		slog.WarnContext(r.Context(), "cache store failed, response not cached", "error", err)
+		return &cacheFetch{resp: resp}
+	}
+	return &cacheFetch{resp: resp, stored: true}
+}
+
+// freshness: thời gian tươi + stale-while-revalidate, ok=false nếu không được lưu
+func (c Cache) freshness(resp *CachedResponse, size int) (fresh, swr time.Duration, ok bool) {
+	switch resp.Status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return 0, 0, false
+	}
+	if size > c.MaxBody || len(resp.Header.Values("Set-Cookie")) > 0 {
+		return 0, 0, false
+	}
+	// problem+json chứa request_id của request đã gọi backend, không dùng lại cho request khác
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		return 0, 0, false
+	}
+	// key chỉ phân biệt theo VaryHeaders; Accept-Encoding thì Compress lo, trừ khi upstream đã tự nén
+	identity := resp.Header.Get("Content-Encoding") == ""
+	for _, v := range resp.Header.Values("Vary") {
+		for _, h := range strings.Split(v, ",") {
+			h = http.CanonicalHeaderKey(strings.TrimSpace(h))
+			if h == "" || containsFold(c.VaryHeaders, h) || (h == "Accept-Encoding" && identity) {
+				continue
+			}
+			return 0, 0, false
+		}
+	}
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if cc.has("no-store") || cc.has("private") || cc.has("no-cache") {
+		return 0, 0, false
+	}
+	switch {
+	case cc.has("s-maxage"):
+		fresh = cc.seconds("s-maxage")
+	case cc.has("max-age"):
+		fresh = cc.seconds("max-age")
+	case resp.Header.Get("Expires") != "":
+		// Expires sai định dạng (vd. "0") nghĩa là đã hết hạn
+		if t, err := http.ParseTime(resp.Header.Get("Expires")); err == nil {
+			fresh = time.Until(t).Truncate(time.Second)
+		}
+	case resp.Status == http.StatusOK && resp.Header.Get("Cache-Control") == "":
+		fresh = c.DefaultTTL // chỉ khi upstream không nói gì
+	}
+	switch {
+	case cc.has("must-revalidate") || cc.has("proxy-revalidate"):
+		swr = 0
+	case cc.has("stale-while-revalidate"):
+		swr = cc.seconds("stale-while-revalidate")
+	default:
+		swr = c.DefaultSWR
+	}
+	return fresh, swr, fresh > 0
+}
+
+func (c Cache) serve(w http.ResponseWriter, r *http.Request, resp *CachedResponse, result string) {
+	h := w.Header()
+	// response có Set-Cookie không bao giờ được lưu, nên chỉ request tự gọi backend nhận cookie
+	for k, v := range resp.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	if result != "MISS" {
+		h.Set("Age", strconv.Itoa(int(resp.age(time.Now()).Seconds())))
+	}
+	h.Set(CacheHeader, result)
+	w.WriteHeader(resp.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(resp.Body)
+	}
+}
+
+// key: path + query đã sắp xếp + giá trị VaryHeaders (hash). Path đứng đầu để Purge theo tiền tố path.
+func (c Cache) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.URL.Path)
+	if q := r.URL.Query(); len(q) > 0 {
+		b.WriteString("?" + q.Encode()) // Encode sắp xếp theo key
+	}
+	if len(c.VaryHeaders) > 0 {
+		h := sha256.New()
+		for _, name := range c.VaryHeaders {
+			h.Write([]byte(name + ":" + strings.Join(r.Header.Values(name), ",") + "\n"))
+		}
+		b.WriteString("#" + hex.EncodeToString(h.Sum(nil)[:8]))
+	}
+	return b.String()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl là các directive của header Cache-Control (tên viết thường)
+type cacheControl map[string]string
+
+func parseCacheControl(v string) cacheControl {
+	cc := cacheControl{}
+	for _, part := range strings.Split(v, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			cc[k] = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(k string) bool { _, ok := cc[k]; return ok }
+
+func (cc cacheControl) seconds(k string) time.Duration {
+	n, err := strconv.ParseInt(cc[k], 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// bufferedResponse là ResponseWriter ghi toàn bộ vào bộ nhớ
+type bufferedResponse struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status == 0 && code >= 200 {
+		b.status = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.buf.Write(p)
+}
+
+// CachePurgePrefix chuẩn hoá tiền tố purge: chấp nhận cả URL đầy đủ hoặc path
+func CachePurgePrefix(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		s = u.RequestURI()
+	}
+	return s
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// CachedResponse là một response GET đã lưu
+type CachedResponse struct {
+	Status   int           `json:"status"`
+	Header   http.Header   `json:"header"`
+	Body     []byte        `json:"body"`
+	StoredAt time.Time     `json:"stored_at"`
+	Fresh    time.Duration `json:"fresh"` // max-age / s-maxage
+	Stale    time.Duration `json:"stale"` // stale-while-revalidate sau khi hết Fresh
+}
+
+func (c *CachedResponse) age(now time.Time) time.Duration { return now.Sub(c.StoredAt) }
+
+func (c *CachedResponse) size() int {
+	n := len(c.Body)
+	for k, vs := range c.Header {
+		n += len(k)
+		for _, v := range vs {
+			n += len(v)
+		}
+	}
+	return n
+}
+
+// CacheStore lưu response theo key; Get trả nil, nil khi không có.
+// Purge xoá mọi key bắt đầu bằng prefix ("" = tất cả) và trả số key đã xoá.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+	Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error
+	Purge(ctx context.Context, prefix string) (int, error)
+}
+
+// ---- MemoryCacheStore ----
+
+// MemoryCacheStore là LRU trong RAM, giới hạn theo số entry và tổng byte
+type MemoryCacheStore struct {
+	MaxEntries int
+	MaxBytes   int
+
+	mu    sync.Mutex
+	ll    *list.List // phần tử đầu = mới dùng nhất
+	items map[string]*list.Element
+	bytes int
+}
+
+type memCacheItem struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+func NewMemoryCacheStore(maxEntries, maxBytes int) *MemoryCacheStore {
+	return &MemoryCacheStore{MaxEntries: maxEntries, MaxBytes: maxBytes, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+func (s *MemoryCacheStore) Get(_ context.Context, key string) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	it := el.Value.(*memCacheItem)
+	if time.Now().After(it.expires) {
+		s.remove(el)
+		return nil, nil
+	}
+	s.ll.MoveToFront(el)
+	return it.resp, nil
+}
+
+func (s *MemoryCacheStore) Set(_ context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.MaxBytes > 0 && resp.size() > s.MaxBytes {
+		return nil // lớn hơn cả cache: bỏ qua
+	}
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+	s.items[key] = s.ll.PushFront(&memCacheItem{key: key, resp: resp, expires: time.Now().Add(ttl)})
+	s.bytes += resp.size()
+	for (s.MaxEntries > 0 && s.ll.Len() > s.MaxEntries) || (s.MaxBytes > 0 && s.bytes > s.MaxBytes) {
+		s.remove(s.ll.Back())
+	}
+	return nil
+}
+
+func (s *MemoryCacheStore) Purge(_ context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for k, el := range s.items {
+		if strings.HasPrefix(k, prefix) {
+			s.remove(el)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryCacheStore) remove(el *list.Element) {
+	it := el.Value.(*memCacheItem)
+	s.ll.Remove(el)
+	delete(s.items, it.key)
+	s.bytes -= it.resp.size()
+}
+
+// ---- RedisCacheStore ----
+
+// RedisCacheStore dùng chung cache giữa nhiều instance gateway; Redis tự xoá theo TTL
+// (nên đặt maxmemory-policy allkeys-lru hoặc volatile-lru cho instance dùng làm cache)
+type RedisCacheStore struct {
+	Client *redis.Client
+	Prefix string // tiền tố key, mặc định "httpcache:"
+}
+
+func NewRedisCacheStore(client *redis.Client) *RedisCacheStore {
+	return &RedisCacheStore{Client: client, Prefix: "httpcache:"}
+}
+
+func (s *RedisCacheStore) Get(ctx context.Context, key string) (*CachedResponse, error) {
+	v, err := s.Client.Get(ctx, s.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(v, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *RedisCacheStore) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	v, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.Client.Set(ctx, s.Prefix+key, v, ttl).Err()
+}
+
+// Purge duyệt bằng SCAN (không chặn Redis như KEYS) rồi UNLINK theo lô
+func (s *RedisCacheStore) Purge(ctx context.Context, prefix string) (int, error) {
+	pattern := s.Prefix + escapeRedisGlob(prefix) + "*"
+	n := 0
+	iter := s.Client.Scan(ctx, 0, pattern, 500).Iterator()
+	batch := make([]string, 0, 500)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		deleted, err := s.Client.Unlink(ctx, batch...).Result()
+		n += int(deleted)
+		batch = batch[:0]
+		return err
+	}
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// escapeRedisGlob: prefix là chuỗi thường, không phải pattern
+func escapeRedisGlob(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return r.Replace(s)
+}
@@ -0,0 +1,347 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+func TestCacheErrorResponseKeepsRequestID(t *testing.T) {
+	calls := 0
+	h := platform.RequestID(Cache{DefaultTTL: 60e9}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		util.Error(w, http.StatusNotFound, "no such post")
+	})))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/posts/1", nil))
+		var p util.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		if id := w.Header().Get(platform.RequestIDHeader); id == "" || p.RequestID != id {
+			t.Errorf("request %d: request_id = %q, header = %q", i, p.RequestID, id)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("backend called %d times, problem responses must not be cached", calls)
+	}
+}
+
+// cacheGet gửi GET qua h, trả response
+func cacheGet(h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCacheHonoursUpstreamHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string // header response của upstream
+		status int
+		body   string
+		stored bool
+	}{
+		{"max-age", map[string]string{"Cache-Control": "public, max-age=60"}, 0, "", true},
+		{"s-maxage wins", map[string]string{"Cache-Control": "max-age=60, s-maxage=0"}, 0, "", false},
+		{"no cache-control uses default ttl", nil, 0, "", true},
+		{"default ttl only for 200", nil, http.StatusNotFound, "", false},
+		{"cache-control without max-age", map[string]string{"Cache-Control": "public"}, 0, "", false},
+		{"private", map[string]string{"Cache-Control": "private, max-age=60"}, 0, "", false},
+		{"no-store", map[string]string{"Cache-Control": "no-store"}, 0, "", false},
+		{"no-cache", map[string]string{"Cache-Control": "no-cache, max-age=60"}, 0, "", false},
+		{"set-cookie", map[string]string{"Cache-Control": "max-age=60", "Set-Cookie": "sid=1"}, 0, "", false},
+		{"vary star", map[string]string{"Cache-Control": "max-age=60", "Vary": "*"}, 0, "", false},
+		{"vary on header outside key", map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Language, Cookie"}, 0, "", false},
+		{"vary on key header", map[string]string{"Cache-Control": "max-age=60", "Vary": "accept-language"}, 0, "", true},
+		{"vary accept-encoding", map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Encoding"}, 0, "", true},
+		{"vary accept-encoding on encoded body", map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Encoding", "Content-Encoding": "gzip"}, 0, "", false},
+		{"expires", map[string]string{"Expires": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}, 0, "", true},
+		{"expires in the past", map[string]string{"Expires": time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}, 0, "", false},
+		{"invalid expires", map[string]string{"Expires": "0"}, 0, "", false},
+		{"server error", map[string]string{"Cache-Control": "max-age=60"}, http.StatusBadGateway, "", false},
+		{"problem", map[string]string{"Cache-Control": "max-age=60", "Content-Type": "application/problem+json"}, http.StatusNotFound, "", false},
+		{"too large", map[string]string{"Cache-Control": "max-age=60"}, 0, strings.Repeat("x", 2048), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := Cache{DefaultTTL: time.Minute, VaryHeaders: []string{"Accept-Language"}, MaxBody: 1024}.Middleware(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					calls.Add(1)
+					for k, v := range tt.header {
+						w.Header().Set(k, v)
+					}
+					if tt.status != 0 {
+						w.WriteHeader(tt.status)
+					}
+					io.WriteString(w, tt.body)
+				}))
+			first := cacheGet(h, "/v1/posts")
+			second := cacheGet(h, "/v1/posts")
+			if first.Header().Get(CacheHeader) != "MISS" {
+				t.Errorf("first X-Cache = %q", first.Header().Get(CacheHeader))
+			}
+			want, wantCalls := "MISS", int32(2)
+			if tt.stored {
+				want, wantCalls = "HIT", 1
+			}
+			if got := second.Header().Get(CacheHeader); got != want {
+				t.Errorf("second X-Cache = %q, want %q", got, want)
+			}
+			if n := calls.Load(); n != wantCalls {
+				t.Errorf("backend called %d times, want %d", n, wantCalls)
+			}
+			if second.Body.String() != tt.body {
+				t.Errorf("body = %q", second.Body)
+			}
+		})
+	}
+}
+
+func TestCacheSetCookieNotShared(t *testing.T) {
+	// response có Set-Cookie chỉ đến đúng request đã gọi backend, kể cả khi các request bị gộp
+	release := make(chan struct{})
+	var calls atomic.Int32
+	h := Cache{}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		if n == 1 {
+			<-release
+		}
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Header().Set("Set-Cookie", "sid="+strconv.Itoa(int(n)))
+		io.WriteString(w, "page")
+	}))
+
+	const n = 5
+	var wg sync.WaitGroup
+	cookies := make([]string, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cookies[i] = cacheGet(h, "/v1/page").Header().Get("Set-Cookie")
+		}()
+	}
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // các request còn lại đang chờ request dẫn đầu
+	close(release)
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, c := range cookies {
+		if c == "" || seen[c] {
+			t.Errorf("cookies = %v, want a distinct cookie per request", cookies)
+			break
+		}
+		seen[c] = true
+	}
+	if w := cacheGet(h, "/v1/page"); w.Header().Get(CacheHeader) != "MISS" {
+		t.Errorf("response with Set-Cookie was cached (X-Cache %q)", w.Header().Get(CacheHeader))
+	}
+}
+
+func TestCacheVaryKey(t *testing.T) {
+	var calls atomic.Int32
+	h := Cache{VaryHeaders: []string{"Accept-Language"}}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		io.WriteString(w, "lang="+r.Header.Get("Accept-Language"))
+	}))
+	for _, step := range []struct{ lang, cache, body string }{
+		{"vi", "MISS", "lang=vi"},
+		{"en", "MISS", "lang=en"},
+		{"vi", "HIT", "lang=vi"},
+		{"en", "HIT", "lang=en"},
+	} {
+		w := cacheGet(h, "/v1/about", "Accept-Language", step.lang)
+		if w.Header().Get(CacheHeader) != step.cache || w.Body.String() != step.body {
+			t.Errorf("%s: X-Cache %q body %q, want %s %q", step.lang, w.Header().Get(CacheHeader), w.Body, step.cache, step.body)
+		}
+	}
+	// query được sắp xếp: cùng key
+	cacheGet(h, "/v1/about?a=1&b=2", "Accept-Language", "vi")
+	if w := cacheGet(h, "/v1/about?b=2&a=1", "Accept-Language", "vi"); w.Header().Get(CacheHeader) != "HIT" {
+		t.Errorf("reordered query: X-Cache %q", w.Header().Get(CacheHeader))
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("backend called %d times, want 3", n)
+	}
+}
+
+func TestCacheRequestDirectives(t *testing.T) {
+	var calls atomic.Int32
+	h := Cache{Bypass: func(r *http.Request) bool { return r.Header.Get("Authorization") != "" }}.Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Cache-Control", "max-age=60")
+			io.WriteString(w, "v")
+		}))
+	for _, step := range []struct {
+		header []string
+		want   string
+	}{
+		{nil, "MISS"},
+		{nil, "HIT"},
+		{[]string{"Authorization", "Bearer t"}, "BYPASS"},
+		{[]string{"Cache-Control", "no-store"}, "BYPASS"},
+		{[]string{"Range", "bytes=0-1"}, "BYPASS"},
+		{[]string{"Cache-Control", "no-cache"}, "MISS"}, // lấy mới và lưu lại
+		{nil, "HIT"},
+	} {
+		if w := cacheGet(h, "/v1/x", step.header...); w.Header().Get(CacheHeader) != step.want {
+			t.Errorf("%v: X-Cache %q, want %q", step.header, w.Header().Get(CacheHeader), step.want)
+		}
+	}
+	if n := calls.Load(); n != 5 {
+		t.Errorf("backend called %d times, want 5", n)
+	}
+}
+
+// storeAged ghi sẵn một response đã lưu được age trước vào store
+func storeAged(t *testing.T, s CacheStore, key string, age, fresh, stale time.Duration) {
+	t.Helper()
+	resp := &CachedResponse{Status: http.StatusOK, Header: http.Header{}, Body: []byte("old"),
+		StoredAt: time.Now().Add(-age), Fresh: fresh, Stale: stale}
+	if err := s.Set(context.Background(), key, resp, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	store := NewMemoryCacheStore(100, 1<<20)
+	var calls atomic.Int32
+	h := Cache{Store: store}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60, stale-while-revalidate=30")
+		io.WriteString(w, "new")
+	}))
+
+	storeAged(t, store, "/v1/x", 70*time.Second, time.Minute, 30*time.Second)
+	w := cacheGet(h, "/v1/x")
+	if w.Header().Get(CacheHeader) != "STALE" || w.Body.String() != "old" || w.Header().Get("Age") != "70" {
+		t.Fatalf("X-Cache %q Age %q body %q", w.Header().Get(CacheHeader), w.Header().Get("Age"), w.Body)
+	}
+	// đợi lần làm mới ở nền ghi vào store
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if got, _ := store.Get(context.Background(), "/v1/x"); got != nil && string(got.Body) == "new" {
+			break
+		}
+	}
+	w = cacheGet(h, "/v1/x")
+	if w.Header().Get(CacheHeader) != "HIT" || w.Body.String() != "new" || calls.Load() != 1 {
+		t.Errorf("after refresh: X-Cache %q body %q, backend called %d times", w.Header().Get(CacheHeader), w.Body, calls.Load())
+	}
+
+	// quá cả stale-while-revalidate: gọi backend ngay
+	storeAged(t, store, "/v1/y", 100*time.Second, time.Minute, 30*time.Second)
+	if w := cacheGet(h, "/v1/y"); w.Header().Get(CacheHeader) != "MISS" || w.Body.String() != "new" {
+		t.Errorf("expired: X-Cache %q body %q", w.Header().Get(CacheHeader), w.Body)
+	}
+}
+
+func TestCacheMustRevalidate(t *testing.T) {
+	c := Cache{DefaultSWR: time.Minute}
+	for cc, want := range map[string]time.Duration{
+		"max-age=60":                                             time.Minute,
+		"max-age=60, stale-while-revalidate=5":                   5 * time.Second,
+		"max-age=60, must-revalidate":                            0,
+		"max-age=60, proxy-revalidate, stale-while-revalidate=5": 0,
+	} {
+		resp := &CachedResponse{Status: http.StatusOK, Header: http.Header{"Cache-Control": {cc}}}
+		if _, swr, ok := c.freshness(resp, 0); !ok || swr != want {
+			t.Errorf("%s: stale-while-revalidate = %v (ok %v), want %v", cc, swr, ok, want)
+		}
+	}
+}
+
+func TestCacheCoalescesMisses(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	h := Cache{}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, "shared")
+	}))
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]*httptest.ResponseRecorder, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = cacheGet(h, "/v1/hot")
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if c := calls.Load(); c != 1 {
+		t.Errorf("backend called %d times, want 1", c)
+	}
+	for i, w := range results {
+		if w.Code != http.StatusOK || w.Body.String() != "shared" {
+			t.Errorf("request %d: %d %q", i, w.Code, w.Body)
+		}
+	}
+}
+
+func TestCacheLeaderTimeout(t *testing.T) {
+	// backend treo: request dẫn đầu (không bị huỷ theo client) vẫn phải dừng sau Timeout
+	var calls atomic.Int32
+	h := Cache{Timeout: 50 * time.Millisecond}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			io.WriteString(w, "direct") // các request chờ tự gọi backend khi kết quả không lưu được
+			return
+		}
+		<-r.Context().Done()
+		util.Error(w, http.StatusGatewayTimeout, "upstream timeout")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // client đã đi: không huỷ request dẫn đầu, chỉ Timeout mới huỷ
+	done := make(chan *httptest.ResponseRecorder, 2)
+	go func() {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/slow", nil).WithContext(ctx))
+		done <- w
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() { done <- cacheGet(h, "/v1/slow") }()
+
+	var codes []int
+	for range 2 {
+		select {
+		case w := <-done:
+			codes = append(codes, w.Code)
+		case <-time.After(5 * time.Second):
+			t.Fatal("cache fetch did not time out")
+		}
+	}
+	if codes[0] != http.StatusGatewayTimeout && codes[1] != http.StatusGatewayTimeout {
+		t.Errorf("statuses = %v, want the leader to get 504", codes)
+	}
+}
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Compress nén response theo Accept-Encoding (br hoặc gzip, chọn theo q-value, bằng nhau thì br).
+// Chỉ nén khi Content-Type nằm trong Types và body đạt MinSize byte; response đã có
+// Content-Encoding, Cache-Control: no-transform, 204/206/304 và HEAD giữ nguyên.
+type Compress struct {
+	MinSize int      // mặc định 1024
+	Types   []string // media type được nén; "text/*" khớp cả nhóm; rỗng = DefaultCompressTypes
+}
+
+// DefaultCompressTypes: các kiểu văn bản gateway trả ra
+var DefaultCompressTypes = []string{
+	"application/json", "application/problem+json", "application/*+json",
+	"application/javascript", "application/xml", "image/svg+xml", "text/*",
+}
+
+var (
+	gzipPool   = sync.Pool{New: func() any { w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression); return w }}
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, 4) }}
+)
+
+func (c Compress) Middleware(next http.Handler) http.Handler {
+	if c.MinSize <= 0 {
+		c.MinSize = 1024
+	}
+	if len(c.Types) == 0 {
+		c.Types = DefaultCompressTypes
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, cfg: &c, encoding: enc}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding chọn "br" | "gzip" | "" theo Accept-Encoding (RFC 9110 §12.5.3)
+func negotiateEncoding(accept string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		v := 1.0
+		if k, val, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+				v = f
+			}
+		}
+		q[name] = v
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range []string{"br", "gzip"} {
+		v, ok := q[enc]
+		if !ok {
+			v, ok = q["*"]
+		}
+		if ok && v > bestQ {
+			best, bestQ = enc, v
+		}
+	}
+	return best
+}
+
+func (c *Compress) typeAllowed(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.Types {
+		switch {
+		case t == mt:
+			return true
+		case strings.HasSuffix(t, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(t, "*")):
+			return true
+		case strings.HasPrefix(t, "application/*+") && strings.HasPrefix(mt, "application/") &&
+			strings.HasSuffix(mt, strings.TrimPrefix(t, "application/*")):
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter giữ header + tối đa MinSize byte đầu để quyết định có nén hay không
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *Compress
+	encoding string
+
+	status   int
+	eligible bool // loại response nén được, chỉ còn chờ đủ MinSize
+	buf      bytes.Buffer
+	decided  bool
+	enc      io.WriteCloser // nil = không nén
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.status != 0 {
+		return
+	}
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.status = code
+	if cw.eligible = cw.checkEligible(); !cw.eligible {
+		_ = cw.start(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.cfg.MinSize {
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// checkEligible: response có thể nén (chưa xét kích thước)
+func (cw *compressWriter) checkEligible() bool {
+	h := cw.Header()
+	if ct := h.Get("Content-Type"); ct != "" && cw.cfg.typeAllowed(ct) {
+		h.Add("Vary", "Accept-Encoding") // cache phải tách bản nén / không nén
+	} else {
+		return false
+	}
+	switch cw.status {
+	case http.StatusNoContent, http.StatusPartialContent, http.StatusNotModified:
+		return false
+	}
+	return h.Get("Content-Encoding") == "" &&
+		!strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-transform")
+}
+
+// start gửi header và phần đã buffer; compress=true thì bật encoder
+func (cw *compressWriter) start(compress bool) error {
+	if cw.decided {
+		return nil
+	}
+	cw.decided = true
+	if compress {
+		h := cw.Header()
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		// representation khác bản gốc: ETag mạnh phải thành ETag yếu
+		if et := h.Get("ETag"); et != "" && !strings.HasPrefix(et, "W/") {
+			h.Set("ETag", "W/"+et)
+		}
+		switch cw.encoding {
+		case "br":
+			bw := brotliPool.Get().(*brotli.Writer)
+			bw.Reset(cw.ResponseWriter)
+			cw.enc = bw
+		default:
+			gw := gzipPool.Get().(*gzip.Writer)
+			gw.Reset(cw.ResponseWriter)
+			cw.enc = gw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+	cw.buf.Reset()
+	return err
+}
+
+// Flush (streaming): quyết định ngay với phần đã có rồi đẩy ra client
+func (cw *compressWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		_ = cw.start(cw.eligible)
+	}
+	switch e := cw.enc.(type) {
+	case *gzip.Writer:
+		_ = e.Flush()
+	case *brotli.Writer:
+		_ = e.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) close() {
+	if cw.status == 0 {
+		return // handler không ghi gì (hoặc panic): để net/http / Recoverer xử lý
+	}
+	if !cw.decided {
+		_ = cw.start(false) // nhỏ hơn MinSize
+	}
+	if cw.enc == nil {
+		return
+	}
+	_ = cw.enc.Close()
+	switch e := cw.enc.(type) {
+	case *gzip.Writer:
+		e.Reset(io.Discard)
+		gzipPool.Put(e)
+	case *brotli.Writer:
+		e.Reset(io.Discard)
+		brotliPool.Put(e)
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter { return cw.ResponseWriter }
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br":       "br",
+		"br;q=0.5, gzip":          "gzip",
+		"gzip;q=0.8, br;q=0.8":    "br",
+		"GZIP":                    "gzip",
+		"*":                       "br",
+		"*;q=0.5, br;q=0":         "gzip",
+		"gzip;q=0, br;q=0":        "",
+		"gzip;q=abc":              "gzip", // q hỏng: coi như 1
+		" deflate , gzip ; q=0.1": "gzip",
+	}
+	for accept, want := range tests {
+		if got := negotiateEncoding(accept); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestCompressTypeAllowed(t *testing.T) {
+	c := Compress{Types: DefaultCompressTypes}
+	for ct, want := range map[string]bool{
+		"application/json; charset=utf-8": true,
+		"application/problem+json":        true,
+		"application/vnd.api+json":        true,
+		"text/html":                       true,
+		"image/svg+xml":                   true,
+		"image/png":                       false,
+		"application/octet-stream":        false,
+		"application/jsonx":               false,
+		"":                                false,
+		"not a media type;;":              false,
+	} {
+		if got := c.typeAllowed(ct); got != want {
+			t.Errorf("typeAllowed(%q) = %v, want %v", ct, got, want)
+		}
+	}
+}
+
+// decode giải nén body theo Content-Encoding của response
+func decode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = w.Body
+	switch w.Header().Get("Content-Encoding") {
+	case "gzip":
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	case "br":
+		r = brotli.NewReader(w.Body)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCompress(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("a", 2048) + `"}`
+	tests := []struct {
+		name     string
+		method   string
+		accept   string
+		header   map[string]string // header của request
+		ct       string
+		extra    map[string]string // header của response
+		status   int
+		body     string
+		encoding string
+		vary     bool
+	}{
+		{name: "gzip", accept: "gzip", ct: "application/json", body: large, encoding: "gzip", vary: true},
+		{name: "brotli preferred", accept: "gzip, br", ct: "application/json", body: large, encoding: "br", vary: true},
+		{name: "no accept-encoding", ct: "application/json", body: large},
+		{name: "below min size", accept: "gzip", ct: "application/json", body: `{"ok":true}`, vary: true},
+		{name: "type not allowed", accept: "gzip", ct: "image/png", body: large},
+		{name: "no content type", accept: "gzip", body: large},
+		{name: "already encoded", accept: "gzip", ct: "application/json", extra: map[string]string{"Content-Encoding": "br"}, body: large, vary: true},
+		{name: "no-transform", accept: "gzip", ct: "application/json", extra: map[string]string{"Cache-Control": "public, no-transform"}, body: large, vary: true},
+		{name: "error status", accept: "gzip", ct: "application/problem+json", status: http.StatusBadGateway, body: large, encoding: "gzip", vary: true},
+		{name: "partial content", accept: "gzip", ct: "text/plain", status: http.StatusPartialContent, body: large, vary: true},
+		{name: "range request", accept: "gzip", header: map[string]string{"Range": "bytes=0-10"}, ct: "text/plain", body: large},
+		{name: "head", method: http.MethodHead, accept: "gzip", ct: "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compress{MinSize: 1024}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.ct != "" {
+					w.Header().Set("Content-Type", tt.ct)
+				}
+				for k, v := range tt.extra {
+					w.Header().Set(k, v)
+				}
+				w.Header().Set("Content-Length", "999")
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// ghi nhiều lần để đi qua nhánh buffer rồi nhánh ghi thẳng
+				for i := 0; i < len(tt.body); i += 500 {
+					io.WriteString(w, tt.body[i:min(i+500, len(tt.body))])
+				}
+			}))
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			want := tt.status
+			if want == 0 {
+				want = http.StatusOK
+			}
+			if w.Code != want {
+				t.Errorf("status = %d, want %d", w.Code, want)
+			}
+			got := w.Header().Get("Content-Encoding")
+			if tt.extra["Content-Encoding"] == "" && got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if tt.encoding != "" {
+				if cl := w.Header().Get("Content-Length"); cl != "" {
+					t.Errorf("Content-Length = %q kept on compressed response", cl)
+				}
+				if body := decode(t, w); body != tt.body {
+					t.Errorf("decoded body mismatch (%d bytes, want %d)", len(body), len(tt.body))
+				}
+			} else if w.Body.String() != tt.body {
+				t.Errorf("body changed (%d bytes, want %d)", w.Body.Len(), len(tt.body))
+			}
+			if v := w.Header().Get("Vary") == "Accept-Encoding"; v != tt.vary {
+				t.Errorf("Vary = %q, want Accept-Encoding: %v", w.Header().Get("Vary"), tt.vary)
+			}
+		})
+	}
+}
+
+func TestCompressWeakensETag(t *testing.T) {
+	body := strings.Repeat("x", 2048)
+	for etag, want := range map[string]string{`"v1"`: `W/"v1"`, `W/"v1"`: `W/"v1"`} {
+		h := Compress{}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("ETag", etag)
+			io.WriteString(w, body)
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("ETag"); got != want {
+			t.Errorf("ETag %s -> %s, want %s", etag, got, want)
+		}
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	// streaming: Flush quyết định nén ngay dù chưa đủ MinSize, mỗi phần đến được client
+	var w *httptest.ResponseRecorder
+	h := Compress{}.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(rw, "data: 1\n\n")
+		rw.(http.Flusher).Flush()
+		if !w.Flushed || w.Body.Len() == 0 {
+			t.Error("first event not flushed to the client")
+		}
+		io.WriteString(rw, "data: 2\n\n")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+	}
+	if got := decode(t, w); got != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestCompressNoWrite(t *testing.T) {
+	// handler không ghi gì: không tự gửi header, để net/http trả 200 rỗng
+	h := Compress{}.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("got %d %q encoding=%q", w.Code, w.Body, w.Header().Get("Content-Encoding"))
+	}
+}
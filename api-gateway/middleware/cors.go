@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/cors"
+)
+
+// CORS là chính sách CORS cho một nhóm route (public / api / admin).
+//
+// Origins hỗ trợ:
+//   - "https://holoc.id.vn"    khớp chính xác
+//   - "https://*.holoc.id.vn"  khớp mọi subdomain (không khớp chính domain gốc)
+//   - "*"                      mọi origin (không dùng được cùng Credentials)
+//
+// Origins rỗng nghĩa là không cho phép cross-origin.
+type CORS struct {
+	Origins     []string
+	Methods     []string
+	Headers     []string
+	Exposed     []string
+	Credentials bool
+	MaxAge      int // giây
+}
+
+func (c CORS) Validate() error {
+	for _, o := range c.Origins {
+		if o == "*" && c.Credentials {
+			return errors.New("cors: wildcard origin \"*\" cannot be combined with credentials")
+		}
+		if o != "*" && strings.Contains(o, "*") && (strings.Count(o, "*") != 1 || !strings.Contains(o, "://*.")) {
+			return errors.New("cors: invalid origin pattern " + o)
+		}
+	}
+	return nil
+}
+
+func (c CORS) Middleware(next http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		// luôn dùng AllowOriginFunc: danh sách rỗng của go-chi/cors lại có nghĩa là "cho tất cả"
+		AllowOriginFunc:  func(_ *http.Request, origin string) bool { return c.AllowOrigin(origin) },
+		AllowedMethods:   c.Methods,
+		AllowedHeaders:   c.Headers,
+		ExposedHeaders:   c.Exposed,
+		AllowCredentials: c.Credentials,
+		MaxAge:           c.MaxAge,
+	})(next)
+}
+
+func (c CORS) AllowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, p := range c.Origins {
+		if matchOrigin(strings.ToLower(p), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" || pattern == origin {
+		return true
+	}
+	i := strings.Index(pattern, "://*.")
+	if i < 0 {
+		return false
+	}
+	scheme, base := pattern[:i], pattern[i+len("://*."):]
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != scheme || u.Path != "" || u.Host == "" {
+		return false
+	}
+	// base có thể kèm port: "*.example.com:8443"
+	host := u.Host
+	if !strings.Contains(base, ":") {
+		if u.Port() != "" {
+			return false
+		}
+		host = u.Hostname()
+	}
+	sub, ok := strings.CutSuffix(host, "."+base)
+	if !ok || sub == "" {
+		return false
+	}
+	// mỗi label phải hợp lệ (chặn kiểu "https://a..holoc.id.vn")
+	for _, label := range strings.Split(sub, ".") {
+		if label == "" || strings.Trim(label, "abcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import "testing"
+
+func TestCORSAllowOrigin(t *testing.T) {
+	c := CORS{Origins: []string{"https://holoc.id.vn", "https://*.holoc.id.vn", "http://*.local.test:8443"}}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://holoc.id.vn", true},
+		{"HTTPS://HOLOC.ID.VN", true},
+		{"https://a.holoc.id.vn", true},
+		{"https://a.b.holoc.id.vn", true},
+		{"http://a.holoc.id.vn", false},       // sai scheme
+		{"https://a.holoc.id.vn:8443", false}, // pattern không có port
+		{"https://a..holoc.id.vn", false},     // label rỗng
+		{"https://evilholoc.id.vn", false},    // không phải subdomain
+		{"https://holoc.id.vn.evil.com", false},
+		{"http://x.local.test:8443", true},
+		{"http://x.local.test", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		if got := c.AllowOrigin(tt.origin); got != tt.want {
+			t.Errorf("AllowOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	tests := []struct {
+		c       CORS
+		wantErr bool
+	}{
+		{CORS{Origins: []string{"*"}}, false},
+		{CORS{Origins: []string{"*"}, Credentials: true}, true},
+		{CORS{Origins: []string{"https://*.holoc.id.vn"}, Credentials: true}, false},
+		{CORS{Origins: []string{"https://a*.holoc.id.vn"}}, true},
+		{CORS{Origins: []string{"https://*.*.holoc.id.vn"}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.c.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%v) error = %v, wantErr %v", tt.c.Origins, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// CSRFHeader là header client gửi lại token CSRF
+const CSRFHeader = "X-CSRF-Token"
+
+// CSRF chống CSRF cho client xác thực bằng cookie, kiểu double-submit có ký:
+// cookie CookieName chứa token "<random>.<hmac(Secret, phiên + random)>" (JS đọc được),
+// request thay đổi trạng thái phải gửi lại đúng token đó trong header X-CSRF-Token.
+// Trang của origin khác không đọc được cookie nên không tự dựng được header; token gắn
+// với phiên đăng nhập (Identity) nên token lấy từ phiên khác (vd. của kẻ tấn công, cài
+// vào trình duyệt nạn nhân qua subdomain) không dùng được. Phiên đổi (login, logout)
+// thì token cũ hết hiệu lực và request kế tiếp được cấp token mới.
+//
+// Chỉ kiểm tra request POST/PUT/PATCH/DELETE mang một trong AuthCookies và không có
+// header Authorization: bearer token thì trình duyệt không tự gửi kèm nên không bị CSRF.
+// Request đó phải có Origin (hoặc Referer) là chính gateway hoặc thuộc TrustedOrigins.
+type CSRF struct {
+	Secret         []byte
+	CookieName     string   // mặc định "csrf_token"
+	CookieDomain   string   // vd "holoc.id.vn" để frontend ở domain cha đọc được cookie
+	CookieSecure   bool     // cờ Secure; sau proxy kết thúc TLS thì r.TLS = nil nên phải bật qua cấu hình
+	AuthCookies    []string // cookie đăng nhập cần bảo vệ
+	TrustedOrigins []string // origin frontend được phép, vd "https://holoc.id.vn"
+	// Identity trả mã phiên đăng nhập của request ("" = chưa đăng nhập), vd. handler.Session.ID.
+	// nil: dùng giá trị của cookie đầu tiên trong AuthCookies có mặt.
+	Identity func(*http.Request) string
+}
+
+var csrfRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_csrf_rejected_total",
+	Help: "State-changing cookie-authenticated requests rejected by the CSRF check.",
+}, []string{"reason"})
+
+func (c CSRF) cookieName() string {
+	if c.CookieName == "" {
+		return "csrf_token"
+	}
+	return c.CookieName
+}
+
+func (c CSRF) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, valid := c.cookieToken(r)
+
+		if isUnsafeMethod(r.Method) && c.cookieAuthenticated(r) {
+			reason := ""
+			switch {
+			case r.Header.Get("Origin") == "" && r.Referer() == "":
+				reason = "missing_origin"
+			case !c.originAllowed(r):
+				reason = "origin"
+			case !valid:
+				reason = "missing_cookie"
+			case subtle.ConstantTimeCompare([]byte(r.Header.Get(CSRFHeader)), []byte(token)) != 1:
+				reason = "token_mismatch"
+			}
+			if reason != "" {
+				csrfRejected.WithLabelValues(reason).Inc()
+				if !valid {
+					c.issue(w, r) // token gắn với phiên hiện tại để client lấy lại và thử lại
+				}
+				util.WriteProblem(w, util.Problem{Status: http.StatusForbidden, Code: "CSRF_TOKEN_INVALID", Detail: "missing or invalid CSRF token"})
+				return
+			}
+		}
+		if !valid {
+			c.issue(w, r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TokenHandler (GET) trả token hiện tại (cấp mới nếu chưa có) trong body và header
+// X-CSRF-Token, cho frontend khác domain không đọc được cookie của gateway
+func (c CSRF) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := c.cookieToken(r)
+	if !ok {
+		token = c.issue(w, r)
+	}
+	w.Header().Set(CSRFHeader, token)
+	w.Header().Set("Cache-Control", "no-store")
+	util.JSON(w, http.StatusOK, map[string]string{"csrf_token": token})
+}
+
+// Key tách theo token CSRF hợp lệ trong cookie (chỉ dùng hash, không lộ token); token do gateway
+// ký nên client không tự dựng được token trùng của người khác. Chưa có token hợp lệ thì dùng fallback.
+func (c CSRF) Key(fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		if token, ok := c.cookieToken(r); ok {
+			sum := sha256.Sum256([]byte(token))
+			return "csrf:" + hex.EncodeToString(sum[:16])
+		}
+		return fallback(r)
+	}
+}
+
+// cookieToken: token trong cookie nếu hợp lệ với phiên hiện tại của request
+func (c CSRF) cookieToken(r *http.Request) (string, bool) {
+	ck, err := r.Cookie(c.cookieName())
+	if err != nil || !c.verify(ck.Value, c.identity(r)) {
+		return "", false
+	}
+	return ck.Value, true
+}
+
+func (c CSRF) identity(r *http.Request) string {
+	if c.Identity != nil {
+		return c.Identity(r)
+	}
+	for _, name := range c.AuthCookies {
+		if ck, err := r.Cookie(name); err == nil {
+			return ck.Value
+		}
+	}
+	return ""
+}
+
+func (c CSRF) issue(w http.ResponseWriter, r *http.Request) string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	token := nonce + "." + c.sign(nonce, c.identity(r))
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.cookieName(),
+		Value:    token,
+		Path:     "/",
+		Domain:   c.CookieDomain,
+		Secure:   c.CookieSecure || r.TLS != nil,
+		HttpOnly: false, // double-submit: JS phải đọc được để gửi lại trong header
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token
+}
+
+func (c CSRF) sign(nonce, identity string) string {
+	m := hmac.New(sha256.New, c.Secret)
+	// độ dài đứng trước để cặp (identity, nonce) khác nhau không ghép ra cùng chuỗi
+	fmt.Fprintf(m, "csrf:%d:%s:%s", len(identity), identity, nonce)
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func (c CSRF) verify(token, identity string) bool {
+	nonce, sig, ok := strings.Cut(token, ".")
+	return ok && nonce != "" && hmac.Equal([]byte(sig), []byte(c.sign(nonce, identity)))
+}
+
+func (c CSRF) cookieAuthenticated(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	for _, name := range c.AuthCookies {
+		if _, err := r.Cookie(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CSRF) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		ref, err := url.Parse(r.Referer())
+		if err != nil || ref.Host == "" {
+			// trình duyệt luôn gửi Origin với POST/PUT/PATCH/DELETE; thiếu cả hai là request
+			// không rõ nguồn gốc (hoặc đã bị trang gọi tới gỡ header), không cho qua
+			return false
+		}
+		origin = ref.Scheme + "://" + ref.Host
+	}
+	u, err := url.Parse(origin)
+	if err != nil || origin == "null" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range c.TrustedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnsafeMethod(m string) bool {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
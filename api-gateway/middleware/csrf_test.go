@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testCSRF() CSRF {
+	return CSRF{
+		Secret:         []byte("csrf-secret"),
+		AuthCookies:    []string{"access_token", "refresh_token"},
+		TrustedOrigins: []string{"https://holoc.id.vn/"},
+	}
+}
+
+// csrfToken lấy token (cookie) mà c cấp cho request mang các cookie cho trước
+func csrfToken(t *testing.T, c CSRF, cookies ...*http.Cookie) string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "http://gw.local/auth/csrf", nil)
+	for _, ck := range cookies {
+		r.AddCookie(ck)
+	}
+	w := httptest.NewRecorder()
+	c.TokenHandler(w, r)
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "csrf_token" {
+			if ck.Value != w.Header().Get(CSRFHeader) {
+				t.Fatalf("cookie token %q != header token %q", ck.Value, w.Header().Get(CSRFHeader))
+			}
+			return ck.Value
+		}
+	}
+	t.Fatal("no csrf cookie issued")
+	return ""
+}
+
+func TestCSRF(t *testing.T) {
+	c := testCSRF()
+	session := &http.Cookie{Name: "access_token", Value: "session-a"}
+	token := csrfToken(t, c, session)
+	anonToken := csrfToken(t, c)
+	otherToken := csrfToken(t, c, &http.Cookie{Name: "access_token", Value: "session-b"})
+
+	tests := []struct {
+		name    string
+		method  string
+		cookies []*http.Cookie
+		header  map[string]string
+		want    int
+	}{
+		{"safe method", http.MethodGet, []*http.Cookie{session}, nil, http.StatusOK},
+		{"no auth cookie", http.MethodPost, nil, nil, http.StatusOK},
+		{"bearer token", http.MethodPost, []*http.Cookie{session}, map[string]string{"Authorization": "Bearer x"}, http.StatusOK},
+		{"valid same origin", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{CSRFHeader: token, "Origin": "http://gw.local"}, http.StatusOK},
+		{"valid trusted origin", http.MethodDelete, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{CSRFHeader: token, "Origin": "https://holoc.id.vn"}, http.StatusOK},
+		{"valid referer", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{CSRFHeader: token, "Referer": "https://holoc.id.vn/blog/1"}, http.StatusOK},
+		{"no origin or referer", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{CSRFHeader: token}, http.StatusForbidden},
+		{"untrusted origin", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{CSRFHeader: token, "Origin": "https://evil.example"}, http.StatusForbidden},
+		{"null origin", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{CSRFHeader: token, "Origin": "null"}, http.StatusForbidden},
+		{"missing header", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: token}},
+			map[string]string{"Origin": "http://gw.local"}, http.StatusForbidden},
+		{"missing cookie", http.MethodPost, []*http.Cookie{session},
+			map[string]string{CSRFHeader: token, "Origin": "http://gw.local"}, http.StatusForbidden},
+		{"forged signature", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: "abc.def"}},
+			map[string]string{CSRFHeader: "abc.def", "Origin": "http://gw.local"}, http.StatusForbidden},
+		// token hợp lệ nhưng của phiên khác (vd. cài qua subdomain): không dùng được
+		{"anonymous token", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: anonToken}},
+			map[string]string{CSRFHeader: anonToken, "Origin": "http://gw.local"}, http.StatusForbidden},
+		{"other session token", http.MethodPost, []*http.Cookie{session, {Name: "csrf_token", Value: otherToken}},
+			map[string]string{CSRFHeader: otherToken, "Origin": "http://gw.local"}, http.StatusForbidden},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://gw.local/api/x", nil)
+			for _, ck := range tt.cookies {
+				r.AddCookie(ck)
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			c.Middleware(ok).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestCSRFReissuesForNewSession(t *testing.T) {
+	c := testCSRF()
+	anonToken := csrfToken(t, c)
+
+	// sau khi đăng nhập, token cũ không khớp phiên mới: request kế tiếp được cấp token mới
+	r := httptest.NewRequest(http.MethodPost, "http://gw.local/api/x", nil)
+	r.AddCookie(&http.Cookie{Name: "access_token", Value: "session-a"})
+	r.AddCookie(&http.Cookie{Name: "csrf_token", Value: anonToken})
+	r.Header.Set(CSRFHeader, anonToken)
+	r.Header.Set("Origin", "http://gw.local")
+	w := httptest.NewRecorder()
+	c.Middleware(http.NotFoundHandler()).ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", w.Code)
+	}
+	var fresh string
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "csrf_token" {
+			fresh = ck.Value
+		}
+	}
+	if fresh == "" || fresh == anonToken {
+		t.Fatalf("no fresh token after rejection (got %q)", fresh)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://gw.local/api/x", nil)
+	r.AddCookie(&http.Cookie{Name: "access_token", Value: "session-a"})
+	r.AddCookie(&http.Cookie{Name: "csrf_token", Value: fresh})
+	r.Header.Set(CSRFHeader, fresh)
+	r.Header.Set("Origin", "http://gw.local")
+	w = httptest.NewRecorder()
+	c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("retry with fresh token: status = %d", w.Code)
+	}
+}
+
+func TestCSRFIdentityFunc(t *testing.T) {
+	c := testCSRF()
+	// Identity tuỳ chỉnh: cookie đổi giá trị (xoay vòng) nhưng cùng phiên thì token vẫn dùng được
+	c.Identity = func(r *http.Request) string { return r.Header.Get("X-Test-Session") }
+	issue := httptest.NewRequest(http.MethodGet, "http://gw.local/", nil)
+	issue.Header.Set("X-Test-Session", "sid-1")
+	w := httptest.NewRecorder()
+	c.Middleware(http.NotFoundHandler()).ServeHTTP(w, issue)
+	var token string
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "csrf_token" {
+			token = ck.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("no token issued")
+	}
+
+	for sid, want := range map[string]int{"sid-1": http.StatusOK, "sid-2": http.StatusForbidden} {
+		r := httptest.NewRequest(http.MethodPost, "http://gw.local/", nil)
+		r.Header.Set("X-Test-Session", sid)
+		r.AddCookie(&http.Cookie{Name: "access_token", Value: "rotated-" + sid})
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		r.Header.Set(CSRFHeader, token)
+		r.Header.Set("Origin", "http://gw.local")
+		w := httptest.NewRecorder()
+		c.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("session %s: status = %d, want %d", sid, w.Code, want)
+		}
+	}
+}
+
+func TestCSRFCookieSecure(t *testing.T) {
+	// sau proxy kết thúc TLS request tới bằng HTTP thường: Secure theo cấu hình
+	for _, tt := range []struct {
+		secure, tls, want bool
+	}{
+		{true, false, true},
+		{false, false, false},
+		{false, true, true},
+	} {
+		c := testCSRF()
+		c.CookieSecure = tt.secure
+		r := httptest.NewRequest(http.MethodGet, "http://gw.local/auth/csrf", nil)
+		if tt.tls {
+			r = httptest.NewRequest(http.MethodGet, "https://gw.local/auth/csrf", nil)
+		}
+		w := httptest.NewRecorder()
+		c.TokenHandler(w, r)
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Secure != tt.want {
+			t.Errorf("CookieSecure=%v TLS=%v: cookies %v, want Secure=%v", tt.secure, tt.tls, cookies, tt.want)
+		}
+	}
+}
+
+func TestCSRFKey(t *testing.T) {
+	c := testCSRF()
+	key := c.Key(func(*http.Request) string { return "fallback" })
+	withToken := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://gw.local/contact", nil)
+		if token != "" {
+			r.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		}
+		return r
+	}
+
+	t1, t2 := csrfToken(t, c), csrfToken(t, c)
+	k1 := key(withToken(t1))
+	if !strings.HasPrefix(k1, "csrf:") || strings.Contains(k1, t1) {
+		t.Errorf("key = %q", k1)
+	}
+	if key(withToken(t1)) != k1 || key(withToken(t2)) == k1 {
+		t.Error("key not stable per token or shared between tokens")
+	}
+	// token không do gateway ký thì không tách được không gian key riêng
+	for _, token := range []string{"", "forged.signature", t1 + "x"} {
+		if k := key(withToken(token)); k != "fallback" {
+			t.Errorf("token %q: key = %q, want fallback", token, k)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETag gắn weak ETag (W/"<sha256 của body>") cho response 200 của GET/HEAD chưa có ETag
+// và trả 304 khi If-None-Match khớp (so sánh yếu, RFC 9110 §13.1.2). Response được buffer
+// toàn bộ; lớn hơn MaxSize thì chuyển sang ghi thẳng, không gắn ETag.
+// Response Cache-Control: no-store (vd. token) không được gắn ETag.
+type ETag struct {
+	MaxSize int // mặc định 1 MiB
+}
+
+func (e ETag) Middleware(next http.Handler) http.Handler {
+	if e.MaxSize <= 0 {
+		e.MaxSize = 1 << 20
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ew := &etagWriter{ResponseWriter: w, max: e.MaxSize}
+		next.ServeHTTP(ew, r)
+		ew.finish(r)
+	})
+}
+
+type etagWriter struct {
+	http.ResponseWriter
+	max         int
+	status      int
+	buf         bytes.Buffer
+	passthrough bool // đã ghi thẳng ra client (quá lớn, flush, hoặc không phải 200)
+}
+
+func (ew *etagWriter) WriteHeader(code int) {
+	if ew.status != 0 {
+		return
+	}
+	if code >= 100 && code < 200 {
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	ew.status = code
+	if code != http.StatusOK {
+		ew.pass()
+	}
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(b)
+	}
+	if ew.buf.Len()+len(b) > ew.max {
+		if err := ew.pass(); err != nil {
+			return 0, err
+		}
+		return ew.ResponseWriter.Write(b)
+	}
+	return ew.buf.Write(b)
+}
+
+// pass gửi header + phần đã buffer, từ đó ghi thẳng
+func (ew *etagWriter) pass() error {
+	if ew.passthrough {
+		return nil
+	}
+	ew.passthrough = true
+	ew.ResponseWriter.WriteHeader(ew.status)
+	if ew.buf.Len() == 0 {
+		return nil
+	}
+	_, err := ew.ResponseWriter.Write(ew.buf.Bytes())
+	ew.buf.Reset()
+	return err
+}
+
+func (ew *etagWriter) Flush() {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	_ = ew.pass() // streaming: không đợi hết body được
+	if f, ok := ew.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (ew *etagWriter) finish(r *http.Request) {
+	if ew.passthrough || ew.status == 0 {
+		return
+	}
+	h := ew.Header()
+	if !strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-store") {
+		tag := h.Get("ETag")
+		if tag == "" {
+			sum := sha256.Sum256(ew.buf.Bytes())
+			tag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			h.Set("ETag", tag)
+		}
+		if etagMatch(r.Header.Get("If-None-Match"), tag) {
+			for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+				h.Del(k)
+			}
+			ew.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	_ = ew.pass()
+}
+
+// etagMatch: If-None-Match ("*" hoặc danh sách ETag) khớp tag theo so sánh yếu
+func etagMatch(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(tag, "W/")
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (ew *etagWriter) Unwrap() http.ResponseWriter { return ew.ResponseWriter }
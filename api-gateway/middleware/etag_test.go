@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	const body = `{"id":1}`
+	plain := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+	h := ETag{}.Middleware(http.HandlerFunc(plain))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/posts/1", nil))
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != body {
+		t.Fatalf("got %d %q", w.Code, w.Body)
+	}
+	if !strings.HasPrefix(tag, `W/"`) {
+		t.Fatalf("ETag = %q, want weak tag", tag)
+	}
+	// cùng body: cùng tag
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/posts/1", nil))
+	if w.Header().Get("ETag") != tag {
+		t.Errorf("ETag not stable: %q != %q", w.Header().Get("ETag"), tag)
+	}
+
+	strong := strings.TrimPrefix(tag, "W/")
+	for inm, want := range map[string]int{
+		tag:                                   http.StatusNotModified,
+		strong:                                http.StatusNotModified, // so sánh yếu
+		`"other", ` + tag:                     http.StatusNotModified,
+		"*":                                   http.StatusNotModified,
+		`"other"`:                             http.StatusOK,
+		`W/"` + strings.Repeat("0", 32) + `"`: http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/posts/1", nil)
+		r.Header.Set("If-None-Match", inm)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("If-None-Match %s: status = %d, want %d", inm, w.Code, want)
+			continue
+		}
+		if want == http.StatusNotModified {
+			if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+				t.Errorf("If-None-Match %s: 304 with body %q / Content-Type %q", inm, w.Body, w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("ETag") != tag {
+				t.Errorf("If-None-Match %s: 304 without ETag", inm)
+			}
+		}
+	}
+}
+
+func TestETagSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+		max     int
+		status  int
+		body    string
+	}{
+		{"post", http.MethodPost, func(w http.ResponseWriter, _ *http.Request) { io.WriteString(w, "created") }, 0, http.StatusOK, "created"},
+		{"error status", http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "missing")
+		}, 0, http.StatusNotFound, "missing"},
+		{"no-store", http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Cache-Control", "No-Store")
+			io.WriteString(w, `{"token":"x"}`)
+		}, 0, http.StatusOK, `{"token":"x"}`},
+		{"larger than max size", http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+			io.WriteString(w, "0123456789")
+			io.WriteString(w, "0123456789")
+		}, 15, http.StatusOK, "01234567890123456789"},
+		{"flushed", http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+			io.WriteString(w, "data: 1\n\n")
+			w.(http.Flusher).Flush()
+		}, 0, http.StatusOK, "data: 1\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set("If-None-Match", "*")
+			w := httptest.NewRecorder()
+			ETag{MaxSize: tt.max}.Middleware(tt.handler).ServeHTTP(w, r)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+			if tag := w.Header().Get("ETag"); tag != "" {
+				t.Errorf("ETag = %q, want none", tag)
+			}
+		})
+	}
+}
+
+func TestETagKeepsUpstreamTag(t *testing.T) {
+	h := ETag{}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("ETag", `"rev-7"`)
+		io.WriteString(w, "post")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", `W/"rev-7"`)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified || w.Header().Get("ETag") != `"rev-7"` {
+		t.Errorf("got %d ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestETagWithCompress(t *testing.T) {
+	// thứ tự như gateway: Compress bọc ngoài ETag, tag tính trên body chưa nén nên
+	// client nhận bản gzip hay bản thường đều revalidate được với cùng tag
+	body := strings.Repeat(`{"k":"v"}`, 300)
+	h := Compress{}.Middleware(ETag{}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})))
+
+	get := func(accept, inm string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if accept != "" {
+			r.Header.Set("Accept-Encoding", accept)
+		}
+		if inm != "" {
+			r.Header.Set("If-None-Match", inm)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	gz := get("gzip", "")
+	identity := get("", "")
+	if gz.Header().Get("Content-Encoding") != "gzip" || decode(t, gz) != body {
+		t.Fatalf("compressed response: encoding %q", gz.Header().Get("Content-Encoding"))
+	}
+	tag := gz.Header().Get("ETag")
+	if tag == "" || tag != identity.Header().Get("ETag") {
+		t.Fatalf("ETag gzip %q, identity %q", tag, identity.Header().Get("ETag"))
+	}
+	w := get("gzip", tag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("revalidate: %d encoding %q body %d bytes", w.Code, w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// HSTS gắn Strict-Transport-Security cho response trả qua TLS
+// (request HTTP thường không được gắn, theo RFC 6797). MaxAge 0 = tắt.
+type HSTS struct {
+	MaxAge            int // giây
+	IncludeSubdomains bool
+	Preload           bool
+}
+
+func (h HSTS) Middleware(next http.Handler) http.Handler {
+	if h.MaxAge <= 0 {
+		return next
+	}
+	value := "max-age=" + strconv.Itoa(h.MaxAge)
+	if h.IncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if h.Preload {
+		value += "; preload"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// IdempotencyHeader là header client gửi kèm request POST có thể retry
+// (draft IETF "The Idempotency-Key HTTP Header Field")
+const IdempotencyHeader = "Idempotency-Key"
+
+// Idempotency ghi nhớ response của request mang Idempotency-Key để client retry
+// (mạng chập chờn, timeout) không tạo bản ghi / gửi email lần hai:
+//   - key mới: chạy handler, lưu response trong TTL (lỗi 5xx thì không lưu để retry được thật)
+//   - trùng key, cùng body: phát lại response đã lưu, kèm header Idempotent-Replayed: true
+//   - trùng key, request đầu chưa xong: 409
+//   - trùng key, body khác: 422
+//
+// Request không có header đi qua như bình thường. Store lỗi thì cho request đi qua.
+type Idempotency struct {
+	Name    string // namespace trong store, mỗi route một tên
+	Store   IdempotencyStore
+	TTL     time.Duration // thời gian giữ response, mặc định 24h
+	LockTTL time.Duration // thời gian giữ key khi request đang xử lý, nên dài hơn timeout upstream
+	Scope   KeyFunc       // tách key theo client (vd. user); nil = dùng chung
+	MaxBody int           // response lớn hơn thì không lưu (mặc định 1 MiB)
+}
+
+var idempotencyTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_idempotency_total",
+	Help: "Requests carrying an Idempotency-Key by outcome (stored, replayed, in_progress, mismatch, not_stored).",
+}, []string{"route", "result"})
+
+// chỉ phát lại các header mô tả response, không phát lại Set-Cookie / RateLimit-* / request id
+var replayHeaders = []string{"Content-Type", "Location", "Content-Location", "Cache-Control", "ETag", "Last-Modified", "Link"}
+
+func (m Idempotency) Middleware(next http.Handler) http.Handler {
+	if m.Store == nil {
+		m.Store = NewMemoryIdempotencyStore()
+	}
+	if m.TTL <= 0 {
+		m.TTL = 24 * time.Hour
+	}
+	if m.LockTTL <= 0 {
+		m.LockTTL = time.Minute
+	}
+	if m.MaxBody <= 0 {
+		m.MaxBody = 1 << 20
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idemKey := r.Header.Get(IdempotencyHeader)
+		if idemKey == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(idemKey) > 255 || !printableASCII(idemKey) {
+			util.ValidationError(w, []util.FieldViolation{{Field: IdempotencyHeader, Description: "must be 1-255 printable ASCII characters"}})
+			return
+		}
+
+		// fingerprint = method + path + body; body được đọc trước (đã giới hạn bởi BodyLimit) rồi trả lại cho handler
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				util.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			util.Error(w, http.StatusBadRequest, "cannot read body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h := sha256.New()
+		h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		h.Write(body)
+		fp := hex.EncodeToString(h.Sum(nil))
+
+		key := m.Name + ":"
+		if m.Scope != nil {
+			key += m.Scope(r) + ":"
+		}
+		key += idemKey
+		owner := make([]byte, 16)
+		_, _ = rand.Read(owner)
+		rec := IdempotencyRecord{Fingerprint: fp, Owner: hex.EncodeToString(owner)}
+
+		ctx := r.Context()
+		cur, acquired, err := m.Store.Begin(ctx, key, rec, m.LockTTL)
+		if err != nil {
+			slog.WarnContext(ctx, "idempotency store unavailable, processing request", "route", m.Name, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !acquired {
+			switch {
+			case cur.Fingerprint != fp:
+				idempotencyTotal.WithLabelValues(m.Name, "mismatch").Inc()
+				util.WriteProblem(w, util.Problem{Status: http.StatusUnprocessableEntity, Code: "IDEMPOTENCY_KEY_REUSED",
+					Detail: "Idempotency-Key was already used with a different request"})
+			case !cur.Done:
+				idempotencyTotal.WithLabelValues(m.Name, "in_progress").Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(1))
+				util.WriteProblem(w, util.Problem{Status: http.StatusConflict, Code: "IDEMPOTENCY_IN_PROGRESS",
+					Detail: "a request with this Idempotency-Key is still being processed"})
+			default:
+				idempotencyTotal.WithLabelValues(m.Name, "replayed").Inc()
+				for _, k := range replayHeaders {
+					if v := cur.Header.Values(k); len(v) > 0 {
+						w.Header()[k] = v
+					}
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(cur.Status)
+				_, _ = w.Write(cur.Body)
+			}
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w, max: m.MaxBody}
+		defer func() {
+			// client ngắt kết nối giữa chừng vẫn phải lưu / nhả key
+			ctx := context.WithoutCancel(ctx)
+			// panic / lỗi 5xx / response quá lớn: nhả key để client retry được
+			if rw.status == 0 || rw.status >= 500 || rw.overflow {
+				idempotencyTotal.WithLabelValues(m.Name, "not_stored").Inc()
+				if err := m.Store.Release(ctx, key, rec.Owner); err != nil {
+					slog.WarnContext(ctx, "idempotency release failed", "route", m.Name, "error", err)
+				}
+				return
+			}
+			rec.Done, rec.Status, rec.Body = true, rw.status, rw.buf.Bytes()
+			rec.Header = http.Header{}
+			for _, k := range replayHeaders {
+				if v := w.Header().Values(k); len(v) > 0 {
+					rec.Header[k] = v
+				}
+			}
+			if err := m.Store.Complete(ctx, key, rec, m.TTL); err != nil {
+				slog.WarnContext(ctx, "idempotency store failed, response not saved", "route", m.Name, "error", err)
+				return
+			}
+			idempotencyTotal.WithLabelValues(m.Name, "stored").Inc()
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// KeyBody tách theo hash của body request (đọc hết rồi trả lại body cho handler, nên phải đặt sau
+// BodyLimit); body rỗng thì dùng fallback. Dùng cho Idempotency.Scope của client ẩn danh: retry cùng
+// key, cùng body được phát lại dù đổi IP, còn client khác dùng trùng key với body khác không đụng nhau.
+func KeyBody(fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		body, err := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil || len(body) == 0 {
+			return fallback(r)
+		}
+		sum := sha256.Sum256(body)
+		return "body:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+func printableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter ghi response ra client đồng thời giữ bản sao (tối đa max byte)
+type recordingWriter struct {
+	http.ResponseWriter
+	status   int
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if !rw.overflow {
+		if rw.buf.Len()+len(b) > rw.max {
+			rw.overflow = true
+			rw.buf.Reset()
+		} else {
+			rw.buf.Write(b)
+		}
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
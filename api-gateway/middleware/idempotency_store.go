@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyRecord là trạng thái của một Idempotency-Key: đang xử lý (Done=false)
+// hoặc đã có response để phát lại
+type IdempotencyRecord struct {
+	Fingerprint string      `json:"fp"`
+	Owner       string      `json:"owner"` // request đang giữ key; chỉ owner được Complete/Release
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// IdempotencyStore giữ Idempotency-Key.
+// Begin ghi rec (đang xử lý) nếu key chưa có và trả acquired=true, ngược lại trả bản ghi hiện tại.
+// Khoá đang xử lý tự hết hạn sau lockTTL để request bị treo / instance chết không giữ key mãi.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key string, rec IdempotencyRecord, lockTTL time.Duration) (cur *IdempotencyRecord, acquired bool, err error)
+	Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key, owner string) error
+}
+
+// ---- MemoryIdempotencyStore ----
+
+// MemoryIdempotencyStore lưu trong RAM của một instance gateway
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idemEntry
+	sweep   time.Time
+}
+
+type idemEntry struct {
+	rec     IdempotencyRecord
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]*idemEntry{}}
+}
+
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key string, rec IdempotencyRecord, lockTTL time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweep) > time.Minute {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now
+	}
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		cur := e.rec
+		return &cur, false, nil
+	}
+	s.entries[key] = &idemEntry{rec: rec, expires: now.Add(lockTTL)}
+	return nil, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.rec.Owner != rec.Owner {
+		return nil // khoá đã hết hạn và bị request khác lấy
+	}
+	s.entries[key] = &idemEntry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.rec.Owner == owner && !e.rec.Done {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// ---- RedisIdempotencyStore ----
+
+// RedisIdempotencyStore dùng chung giữa nhiều instance gateway; mỗi key là một chuỗi JSON
+type RedisIdempotencyStore struct {
+	Client *redis.Client
+	Prefix string // tiền tố key, mặc định "idempotency:"
+}
+
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{Client: client, Prefix: "idempotency:"}
+}
+
+// KEYS[1] = key; ARGV = owner, record JSON, ttl (ms). Chỉ ghi khi key trống hoặc còn của owner.
+var idemCompleteScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v and cjson.decode(v).owner ~= ARGV[1] then
+  return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// KEYS[1] = key; ARGV = owner. Chỉ xoá khoá đang xử lý của chính owner.
+var idemReleaseScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v then
+  local r = cjson.decode(v)
+  if r.owner == ARGV[1] and not r.done then
+    return redis.call('DEL', KEYS[1])
+  end
+end
+return 0
+`)
+
+func (s *RedisIdempotencyStore) Begin(ctx context.Context, key string, rec IdempotencyRecord, lockTTL time.Duration) (*IdempotencyRecord, bool, error) {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return nil, false, err
+	}
+	// key vừa hết hạn giữa SETNX và GET thì thử lại một lần
+	for i := 0; i < 2; i++ {
+		ok, err := s.Client.SetNX(ctx, s.Prefix+key, raw, lockTTL).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return nil, true, nil
+		}
+		v, err := s.Client.Get(ctx, s.Prefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		var cur IdempotencyRecord
+		if err := json.Unmarshal(v, &cur); err != nil {
+			return nil, false, err
+		}
+		return &cur, false, nil
+	}
+	return nil, false, errors.New("idempotency key changed concurrently")
+}
+
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return idemCompleteScript.Run(ctx, s.Client, []string{s.Prefix + key}, rec.Owner, raw, ttl.Milliseconds()).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key, owner string) error {
+	return idemReleaseScript.Run(ctx, s.Client, []string{s.Prefix + key}, owner).Err()
+}
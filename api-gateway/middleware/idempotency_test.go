@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyBody(t *testing.T) {
+	key := KeyBody(func(*http.Request) string { return "fallback" })
+	send := func(body string) (string, string) {
+		r := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(body))
+		k := key(r)
+		rest, _ := io.ReadAll(r.Body)
+		return k, string(rest)
+	}
+
+	k1, rest := send(`{"m":1}`)
+	if !strings.HasPrefix(k1, "body:") || rest != `{"m":1}` {
+		t.Errorf("key = %q, body left for handler = %q", k1, rest)
+	}
+	if k, _ := send(`{"m":1}`); k != k1 {
+		t.Error("same body gave different keys")
+	}
+	if k, _ := send(`{"m":2}`); k == k1 {
+		t.Error("different bodies share a key")
+	}
+	if k, _ := send(""); k != "fallback" {
+		t.Errorf("empty body: key = %q", k)
+	}
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency{
+		Name:    "test",
+		Store:   NewMemoryIdempotencyStore(),
+		TTL:     time.Minute,
+		LockTTL: time.Second,
+		Scope:   KeyUser(KeyIP),
+	}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func(ip, user string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(`{"m":1}`))
+		r.RemoteAddr = ip + ":1234"
+		r.Header.Set(IdempotencyHeader, "same-key")
+		if user != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userIDKey, user))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		ip, user string
+		replayed bool
+	}{
+		{"anonymous first", "203.0.113.1", "", false},
+		{"anonymous retry same ip", "203.0.113.1", "", true},
+		// khách khác cùng key không nhận response của khách trước
+		{"anonymous other ip", "203.0.113.2", "", false},
+		{"user first", "203.0.113.1", "u1", false},
+		// user được nhận ra dù đổi mạng
+		{"user retry other ip", "198.51.100.7", "u1", true},
+		{"other user", "198.51.100.7", "u2", false},
+	}
+	want := int32(0)
+	for _, tt := range tests {
+		w := send(tt.ip, tt.user)
+		if got := w.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+			t.Errorf("%s: replayed = %v, want %v", tt.name, got, tt.replayed)
+		}
+		if !tt.replayed {
+			want++
+		}
+		if w.Code != http.StatusCreated {
+			t.Errorf("%s: status = %d", tt.name, w.Code)
+		}
+	}
+	if calls.Load() != want {
+		t.Errorf("handler called %d times, want %d", calls.Load(), want)
+	}
+}
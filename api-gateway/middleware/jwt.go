@@ -9,8 +9,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// JWT xác thực token trong header Authorization (Bearer) hoặc, nếu Cookie khác rỗng,
+// trong cookie HttpOnly đó (chế độ cookie, xem handler.Session). Header được ưu tiên.
 type JWT struct {
 	Secret string
+	Cookie string
 }
 
 type ctxKey string
@@ -19,15 +22,14 @@ const userIDKey ctxKey = "user_id"
 
 func (m JWT) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authz := r.Header.Get("Authorization")
-		parts := strings.SplitN(authz, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		raw, ok := m.token(r)
+		if !ok {
 			util.Error(w, http.StatusUnauthorized, "missing bearer token")
 			return
 		}
-		token, err := jwt.Parse(parts[1], func(t *jwt.Token) (any, error) {
+		token, err := jwt.Parse(raw, func(t *jwt.Token) (any, error) {
 			return []byte(m.Secret), nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 		if err != nil || !token.Valid {
 			util.Error(w, http.StatusUnauthorized, "invalid token")
 			return
@@ -43,6 +45,22 @@ func (m JWT) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+func (m JWT) token(r *http.Request) (string, bool) {
+	if authz := r.Header.Get("Authorization"); authz != "" {
+		parts := strings.SplitN(authz, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return "", false
+		}
+		return parts[1], true
+	}
+	if m.Cookie != "" {
+		if c, err := r.Cookie(m.Cookie); err == nil && c.Value != "" {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
 // Helper lấy user_id từ context trong handler sau này
 func UserIDFromCtx(r *http.Request) (string, bool) {
 	uid, ok := r.Context().Value(userIDKey).(string)
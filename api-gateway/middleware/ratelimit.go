@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RateAlgorithm là thuật toán giới hạn
+type RateAlgorithm string
+
+const (
+	// TokenBucket: bucket đầy Requests token, nạp lại đều Requests/Per; cho phép burst ngắn
+	TokenBucket RateAlgorithm = "token_bucket"
+	// SlidingWindow: đếm theo cửa sổ trượt (ước lượng từ cửa sổ hiện tại + cửa sổ trước)
+	SlidingWindow RateAlgorithm = "sliding_window"
+)
+
+// ParseAlgorithm đọc tên thuật toán; rỗng = SlidingWindow
+func ParseAlgorithm(s string) (RateAlgorithm, error) {
+	switch a := RateAlgorithm(strings.ToLower(strings.TrimSpace(s))); a {
+	case "":
+		return SlidingWindow, nil
+	case TokenBucket, SlidingWindow:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown rate limit algorithm %q (want token_bucket or sliding_window)", s)
+	}
+}
+
+// RateLimit giới hạn số request trong Per cho mỗi key (IP, user, API key).
+// Trạng thái nằm trong Store nên nhiều instance gateway có thể dùng chung (RedisStore).
+type RateLimit struct {
+	Name      string // namespace trong store, mỗi route một tên
+	Requests  int
+	Per       time.Duration
+	Algorithm RateAlgorithm
+	Key       KeyFunc
+	Store     RateStore // nil = MemoryStore riêng của limiter
+}
+
+// RateResult là kết quả một lần Take
+type RateResult struct {
+	Allowed    bool
+	Remaining  int           // số request còn được phép
+	Reset      time.Duration // thời gian tới khi quota hồi đầy / cửa sổ hiện tại kết thúc
+	RetryAfter time.Duration // chỉ có nghĩa khi !Allowed
+}
+
+var rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_rate_limited_total",
+	Help: "Requests rejected by the gateway rate limiter.",
+}, []string{"limiter"})
+
+// ParseRate đọc chuỗi dạng "10/1m", "100/h", "5/s"
+func ParseRate(s string) (int, time.Duration, error) {
+	n, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid rate %q, want N/duration", s)
+	}
+	reqs, err := strconv.Atoi(n)
+	if err != nil || reqs <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q: bad request count", s)
+	}
+	switch per {
+	case "s":
+		per = "1s"
+	case "m":
+		per = "1m"
+	case "h":
+		per = "1h"
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q: bad duration", s)
+	}
+	return reqs, d, nil
+}
+
+func NewRateLimit(spec string, key KeyFunc) (*RateLimit, error) {
+	n, per, err := ParseRate(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimit{Requests: n, Per: per, Algorithm: SlidingWindow, Key: key}, nil
+}
+
+// Middleware trả 429 khi vượt giới hạn và luôn gắn header RateLimit-* (draft IETF
+// RateLimit header fields). Store lỗi (vd. Redis down) thì cho request đi qua.
+func (l *RateLimit) Middleware(next http.Handler) http.Handler {
+	if l.Store == nil {
+		l.Store = NewMemoryStore()
+	}
+	policy := fmt.Sprintf("%d;w=%d", l.Requests, int(math.Ceil(l.Per.Seconds())))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := l.Store.Take(r.Context(), l.Name+":"+l.Key(r), l.Algorithm, l.Requests, l.Per, time.Now())
+		if err != nil {
+			slog.WarnContext(r.Context(), "rate limit store unavailable, allowing request", "limiter", l.Name, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", policy)
+		h.Set("RateLimit-Limit", strconv.Itoa(l.Requests))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+		if !res.Allowed {
+			rateLimited.WithLabelValues(l.Name).Inc()
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			util.Error(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// KeyFunc chọn key giới hạn cho request
+type KeyFunc func(r *http.Request) string
+
+// APIKeyHeader là header mang API key của client
+const APIKeyHeader = "X-API-Key"
+
+// KeyIP giới hạn theo IP client (platform.ClientIP, đã xét TRUSTED_PROXIES)
+func KeyIP(r *http.Request) string {
+	return "ip:" + platform.ClientIP(r)
+}
+
+// KeyUser giới hạn theo user_id của JWT (đặt sau middleware JWT); chưa đăng nhập thì dùng fallback
+func KeyUser(fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		if uid, ok := UserIDFromCtx(r); ok && uid != "" {
+			return "user:" + uid
+		}
+		return fallback(r)
+	}
+}
+
+// APIKeys là tập API key hợp lệ; chỉ giữ hash SHA-256, không giữ key gốc
+type APIKeys map[[sha256.Size]byte]bool
+
+func NewAPIKeys(keys []string) APIKeys {
+	set := APIKeys{}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			set[sha256.Sum256([]byte(k))] = true
+		}
+	}
+	return set
+}
+
+// id trả định danh ngắn (hash) của key nếu key nằm trong tập
+func (s APIKeys) id(key string) (string, bool) {
+	sum := sha256.Sum256([]byte(key))
+	if key == "" || !s[sum] {
+		return "", false
+	}
+	return hex.EncodeToString(sum[:8]), true
+}
+
+// KeyAPIKey giới hạn theo API key đã xác minh trong keys; thiếu key hoặc key không hợp lệ thì dùng
+// fallback, để client không thể tự tạo bucket mới chỉ bằng cách đổi giá trị X-API-Key
+func KeyAPIKey(keys APIKeys, fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		if id, ok := keys.id(r.Header.Get(APIKeyHeader)); ok {
+			return "key:" + id
+		}
+		return fallback(r)
+	}
+}
+
+// ParseKeyFunc đọc kiểu key "ip" (mặc định) | "user" | "api_key" (xác minh theo keys)
+func ParseKeyFunc(kind string, keys APIKeys) (KeyFunc, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "ip":
+		return KeyIP, nil
+	case "user":
+		return KeyUser(KeyIP), nil
+	case "api_key":
+		return KeyAPIKey(keys, KeyIP), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key %q (want ip, user or api_key)", kind)
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RateStore giữ trạng thái rate limit. Take ghi nhận một request cho key
+// (nếu còn quota) và trả kết quả theo thuật toán.
+type RateStore interface {
+	Take(ctx context.Context, key string, alg RateAlgorithm, limit int, per time.Duration, now time.Time) (RateResult, error)
+}
+
+// ---- tính kết quả (dùng chung cho memory và Redis) ----
+
+// tokenBucketResult: tokens là số token còn lại sau lần lấy (nếu allowed)
+func tokenBucketResult(allowed bool, tokens float64, limit int, per time.Duration) RateResult {
+	rate := float64(limit) / per.Seconds() // token mỗi giây
+	res := RateResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
+
+// slidingWindowResult: curr/prev là số request trong cửa sổ hiện tại/trước,
+// elapsed là thời gian đã trôi qua của cửa sổ hiện tại
+func slidingWindowResult(allowed bool, curr, prev int, elapsed time.Duration, limit int, per time.Duration) RateResult {
+	weight := 1 - float64(elapsed)/float64(per)
+	used := float64(prev)*weight + float64(curr)
+	res := RateResult{
+		Allowed:   allowed,
+		Remaining: max(0, int(math.Floor(float64(limit)-used))),
+		Reset:     per - elapsed,
+	}
+	if !allowed {
+		// chờ tới khi phần cửa sổ trước giảm đủ cho một request nữa
+		res.RetryAfter = per - elapsed
+		if prev > 0 && curr < limit {
+			need := time.Duration((1 - float64(limit-curr-1)/float64(prev)) * float64(per))
+			res.RetryAfter = need - elapsed
+		}
+	}
+	return res
+}
+
+// ---- MemoryStore ----
+
+// MemoryStore lưu trạng thái trong RAM của một instance gateway
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*rateEntry
+	sweep   time.Time
+}
+
+type rateEntry struct {
+	expires time.Time
+
+	// token bucket
+	tokens float64
+	last   time.Time
+
+	// sliding window
+	start      time.Time
+	curr, prev int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]*rateEntry{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, alg RateAlgorithm, limit int, per time.Duration, now time.Time) (RateResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// dọn các key đã hết hạn để map không phình ra
+	if now.Sub(s.sweep) > time.Minute {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now
+	}
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = &rateEntry{tokens: float64(limit), last: now}
+		s.entries[key] = e
+	}
+	e.expires = now.Add(2 * per)
+
+	switch alg {
+	case TokenBucket:
+		elapsed := now.Sub(e.last).Seconds()
+		if elapsed > 0 {
+			e.tokens = math.Min(float64(limit), e.tokens+elapsed*float64(limit)/per.Seconds())
+			e.last = now
+		}
+		allowed := e.tokens >= 1
+		if allowed {
+			e.tokens--
+		}
+		return tokenBucketResult(allowed, e.tokens, limit, per), nil
+
+	default:
+		start := now.Truncate(per)
+		if !start.Equal(e.start) {
+			if start.Sub(e.start) == per {
+				e.prev = e.curr
+			} else {
+				e.prev = 0
+			}
+			e.curr, e.start = 0, start
+		}
+		elapsed := now.Sub(start)
+		used := float64(e.prev)*(1-float64(elapsed)/float64(per)) + float64(e.curr)
+		allowed := used+1 <= float64(limit)
+		if allowed {
+			e.curr++
+		}
+		return slidingWindowResult(allowed, e.curr, e.prev, elapsed, limit, per), nil
+	}
+}
+
+// ---- RedisStore ----
+
+// RedisStore dùng chung trạng thái giữa nhiều instance gateway.
+// Mỗi lần Take là một script Lua (atomic); thời gian lấy từ gateway.
+type RedisStore struct {
+	Client *redis.Client
+	Prefix string // tiền tố key, mặc định "ratelimit:"
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{Client: client, Prefix: "ratelimit:"}
+}
+
+// KEYS[1] = bucket; ARGV = limit, per (ms), now (ms)
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local per = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local s = redis.call('HMGET', KEYS[1], 't', 'l')
+local tokens = tonumber(s[1]) or limit
+local last = tonumber(s[2]) or now
+if now > last then
+  tokens = math.min(limit, tokens + (now - last) * limit / per)
+  last = now
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'l', tostring(last))
+redis.call('PEXPIRE', KEYS[1], per * 2)
+return {allowed, tostring(tokens)}
+`)
+
+// KEYS[1] = cửa sổ hiện tại, KEYS[2] = cửa sổ trước; ARGV = limit, per (ms), elapsed (ms)
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local per = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local curr = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+if prev * (1 - elapsed / per) + curr + 1 > limit then
+  return {0, curr, prev}
+end
+curr = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], per * 2)
+return {1, curr, prev}
+`)
+
+func (s *RedisStore) Take(ctx context.Context, key string, alg RateAlgorithm, limit int, per time.Duration, now time.Time) (RateResult, error) {
+	// {key} là hash tag: mọi key của một client nằm cùng slot khi dùng Redis Cluster
+	base := s.Prefix + "{" + key + "}"
+	perMS := per.Milliseconds()
+
+	switch alg {
+	case TokenBucket:
+		v, err := tokenBucketScript.Run(ctx, s.Client, []string{base + ":tb"}, limit, perMS, now.UnixMilli()).Slice()
+		if err != nil {
+			return RateResult{}, err
+		}
+		if len(v) != 2 {
+			return RateResult{}, fmt.Errorf("unexpected token bucket reply %v", v)
+		}
+		allowed, _ := v[0].(int64)
+		str, _ := v[1].(string)
+		tokens, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return RateResult{}, fmt.Errorf("unexpected token bucket reply %v", v)
+		}
+		return tokenBucketResult(allowed == 1, tokens, limit, per), nil
+
+	default:
+		start := now.Truncate(per)
+		elapsed := now.Sub(start)
+		keys := []string{
+			base + ":sw:" + strconv.FormatInt(start.UnixMilli(), 10),
+			base + ":sw:" + strconv.FormatInt(start.Add(-per).UnixMilli(), 10),
+		}
+		v, err := slidingWindowScript.Run(ctx, s.Client, keys, limit, perMS, elapsed.Milliseconds()).Int64Slice()
+		if err != nil {
+			return RateResult{}, err
+		}
+		if len(v) != 3 {
+			return RateResult{}, fmt.Errorf("unexpected sliding window reply %v", v)
+		}
+		return slidingWindowResult(v[0] == 1, int(v[1]), int(v[2]), elapsed, limit, per), nil
+	}
+}
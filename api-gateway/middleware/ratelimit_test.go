@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyAPIKey(t *testing.T) {
+	key := KeyAPIKey(NewAPIKeys([]string{"live-key-1", " live-key-2 ", ""}), KeyIP)
+	tests := []struct {
+		name   string
+		header string
+		prefix string
+	}{
+		{"verified key", "live-key-1", "key:"},
+		{"verified key trimmed in config", "live-key-2", "key:"},
+		{"unknown key falls back to IP", "made-up", "ip:"},
+		{"no key", "", "ip:"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set(APIKeyHeader, tt.header)
+		}
+		got := key(r)
+		if !strings.HasPrefix(got, tt.prefix) {
+			t.Errorf("%s: key = %q, want prefix %q", tt.name, got, tt.prefix)
+		}
+		if tt.prefix == "key:" && strings.Contains(got, tt.header) {
+			t.Errorf("%s: raw API key stored in rate limit key %q", tt.name, got)
+		}
+	}
+}
+
+func TestRateLimitUnknownAPIKeysShareIPBucket(t *testing.T) {
+	keys := NewAPIKeys([]string{"live-key-1"})
+	rl, err := NewRateLimit("2/1m", KeyAPIKey(keys, KeyIP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := rl.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	do := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "198.51.100.4:1000"
+		r.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// đổi key giả mỗi request không tạo bucket mới
+	for i, k := range []string{"fake-1", "fake-2", "fake-3"} {
+		w := do(k)
+		if want := i < 2; (w.Code == http.StatusOK) != want {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+		if i == 2 && (w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "") {
+			t.Errorf("limited response = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+	// key hợp lệ có bucket riêng
+	if w := do("live-key-1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("verified key: %d remaining %q", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func TestParseKeyFunc(t *testing.T) {
+	for _, kind := range []string{"", "ip", "USER", "api_key"} {
+		if _, err := ParseKeyFunc(kind, nil); err != nil {
+			t.Errorf("ParseKeyFunc(%q): %v", kind, err)
+		}
+	}
+	if _, err := ParseKeyFunc("header", nil); err == nil {
+		t.Error("unknown key kind accepted")
+	}
+}
+
+func TestMemoryStoreAlgorithms(t *testing.T) {
+	for _, alg := range []RateAlgorithm{SlidingWindow, TokenBucket} {
+		s := NewMemoryStore()
+		now := time.Now()
+		for i := 0; i < 3; i++ {
+			res, err := s.Take(context.Background(), "k", alg, 3, time.Minute, now)
+			if err != nil || !res.Allowed {
+				t.Fatalf("%s: request %d rejected (%v)", alg, i, err)
+			}
+		}
+		res, _ := s.Take(context.Background(), "k", alg, 3, time.Minute, now)
+		if res.Allowed || res.RetryAfter <= 0 {
+			t.Errorf("%s: 4th request = %+v", alg, res)
+		}
+		// sau một chu kỳ quota hồi lại
+		if res, _ := s.Take(context.Background(), "k", alg, 3, time.Minute, now.Add(2*time.Minute)); !res.Allowed {
+			t.Errorf("%s: quota not restored after window", alg)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SecurityHeaders gắn các header bảo vệ phía trình duyệt cho mọi response.
+// Giá trị rỗng hoặc "off" = không gắn header đó. Handler có thể ghi đè CSP
+// cho riêng mình (vd. /docs cần nạp script của Swagger UI).
+type SecurityHeaders struct {
+	CSP            string // Content-Security-Policy
+	FrameOptions   string // X-Frame-Options: DENY | SAMEORIGIN
+	ReferrerPolicy string // Referrer-Policy
+}
+
+// DefaultSecurityHeaders phù hợp cho API chỉ trả JSON: không cho nạp tài nguyên,
+// không cho nhúng frame, không gửi Referer ra ngoài
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		CSP:            "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'",
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "no-referrer",
+	}
+}
+
+func (s SecurityHeaders) Middleware(next http.Handler) http.Handler {
+	set := map[string]string{"X-Content-Type-Options": "nosniff"}
+	for k, v := range map[string]string{
+		"Content-Security-Policy": s.CSP,
+		"X-Frame-Options":         s.FrameOptions,
+		"Referrer-Policy":         s.ReferrerPolicy,
+	} {
+		if v = strings.TrimSpace(v); v != "" && !strings.EqualFold(v, "off") {
+			set[k] = v
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		for k, v := range set {
+			h.Set(k, v)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
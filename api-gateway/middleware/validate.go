@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/RibunLoc/WebPersonalBackend/api-gateway/util"
+)
+
+// ValidateJSON kiểm tra body theo kiểu T (util.BindJSON: Content-Type, field lạ, tag validate)
+// rồi trả lại nguyên body cho handler phía sau. Dùng cho route transcode /v1 để có cùng
+// luật kiểm tra với route viết tay tương ứng; đặt sau BodyLimit.
+func ValidateJSON[T any](next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				// util.DecodeJSON ghi đúng thông báo 413 cho lỗi này
+				r.Body = io.NopCloser(errReader{err})
+				util.DecodeJSON(w, r, new(T))
+				return
+			}
+			util.Error(w, http.StatusBadRequest, "cannot read body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if !util.BindJSON(w, r, new(T)) {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
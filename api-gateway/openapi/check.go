@@ -0,0 +1,46 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// Missing trả về các route có trong router nhưng chưa được khai báo trong tài liệu.
+// Bỏ qua OPTIONS (preflight CORS). Route mount dạng "/v1/*" được coi là đủ nếu
+// tài liệu có ít nhất một path nằm dưới prefix đó.
+func (d *Document) Missing(routes chi.Routes) ([]string, error) {
+	var missing []string
+	mounts := map[string]bool{}
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if method == http.MethodOptions {
+			return nil
+		}
+		route = stripVarPatterns(route)
+		if prefix, ok := strings.CutSuffix(route, "/*"); ok {
+			// Mount đăng ký mọi method, chỉ báo một lần
+			if !mounts[route] && !d.hasPrefix(prefix+"/") {
+				missing = append(missing, "* "+route)
+			}
+			mounts[route] = true
+			return nil
+		}
+		if !d.Has(method, route) {
+			missing = append(missing, method+" "+route)
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	return missing, err
+}
+
+func (d *Document) hasPrefix(prefix string) bool {
+	for p := range d.Paths {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestMissing(t *testing.T) {
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	r := chi.NewRouter()
+	r.Get("/documented", ok)
+	r.Post("/posts/{id:[0-9]+}", ok)
+	r.Options("/posts/{id:[0-9]+}", ok) // preflight: bỏ qua
+	r.Delete("/undocumented", ok)
+	r.Mount("/v1", ok)
+	r.Mount("/v2", ok)
+
+	doc := New("test", "1")
+	doc.Add(http.MethodGet, "/documented", &Operation{})
+	doc.Add(http.MethodPost, "/posts/{id}", &Operation{})
+	doc.Add(http.MethodPost, "/v1/contact", &Operation{})
+
+	missing, err := doc.Missing(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"* /v2/*", "DELETE /undocumented"}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("Missing = %v, want %v", missing, want)
+	}
+}
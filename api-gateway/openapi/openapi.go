@@ -0,0 +1,223 @@
+// Package openapi dựng tài liệu OpenAPI 3 cho các route của gateway.
+// Schema lấy từ struct Go (json tag) hoặc từ descriptor proto (tên field proto,
+// giống JSON mà gateway trả ra).
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	byMethod   map[string]*Operation `json:"-"` // "POST /auth/login"
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem: method viết thường -> operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path | query | header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`   // apiKey: header | query | cookie
+	Name         string `json:"name,omitempty"` // apiKey: tên header/query/cookie
+	Description  string `json:"description,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version},
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}, SecuritySchemes: map[string]SecurityScheme{}},
+		byMethod:   map[string]*Operation{},
+	}
+}
+
+// Add khai báo một operation; path dùng cú pháp chi/OpenAPI ("/users/{id}")
+func (d *Document) Add(method, path string, op *Operation) {
+	method = strings.ToUpper(method)
+	path = stripVarPatterns(path)
+	if op.Responses == nil {
+		op.Responses = map[string]Response{}
+	}
+	for _, name := range pathParams(path) {
+		if !hasParam(op.Parameters, name) {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	item := d.Paths[path]
+	if item == nil {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+	d.byMethod[method+" "+path] = op
+}
+
+// Has cho biết method + path đã có trong tài liệu chưa
+func (d *Document) Has(method, path string) bool {
+	_, ok := d.byMethod[strings.ToUpper(method)+" "+path]
+	return ok
+}
+
+func (d *Document) AddTag(name, desc string) {
+	d.Tags = append(d.Tags, Tag{Name: name, Description: desc})
+}
+
+func (d *Document) AddSecurity(name string, s SecurityScheme) {
+	d.Components.SecuritySchemes[name] = s
+}
+
+// Handler trả tài liệu dạng JSON (đã encode sẵn một lần)
+func (d *Document) Handler() (http.Handler, error) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write(raw)
+	}), nil
+}
+
+// JSONBody là request body application/json bắt buộc
+func JSONBody(s *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: s}}}
+}
+
+func JSONResponse(desc string, s *Schema) Response {
+	return Response{Description: desc, Content: map[string]MediaType{"application/json": {Schema: s}}}
+}
+
+// ProblemResponse là response lỗi application/problem+json
+func ProblemResponse(desc string, s *Schema) Response {
+	return Response{Description: desc, Content: map[string]MediaType{"application/problem+json": {Schema: s}}}
+}
+
+// "/users/{id}/{name}" -> [id name]
+func pathParams(path string) []string {
+	var out []string
+	for {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			return out
+		}
+		j := strings.IndexByte(path[i:], '}')
+		if j < 0 {
+			return out
+		}
+		out = append(out, varName(path[i+1:i+j]))
+		path = path[i+j+1:]
+	}
+}
+
+// stripVarPatterns bỏ phần pattern của biến trên path:
+// chi "{id:[0-9]+}" và google.api.http "{name=users/*}" -> "{id}", "{name}"
+func stripVarPatterns(path string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			b.WriteString(path)
+			return b.String()
+		}
+		j := strings.IndexByte(path[i:], '}')
+		if j < 0 {
+			b.WriteString(path)
+			return b.String()
+		}
+		b.WriteString(path[:i] + "{" + varName(path[i+1:i+j]) + "}")
+		path = path[i+j+1:]
+	}
+}
+
+func varName(v string) string {
+	if k := strings.IndexAny(v, ":="); k >= 0 {
+		return v[:k]
+	}
+	return v
+}
+
+func hasParam(ps []Parameter, name string) bool {
+	for _, p := range ps {
+		if p.In == "path" && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
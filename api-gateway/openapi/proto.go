@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// AddHTTPRules khai báo các RPC có option google.api.http của service
+// (chính là các route mà transcoder /v1 phục vụ). errs được gộp vào responses.
+func (d *Document) AddHTTPRules(service protoreflect.FullName, tag string, errs map[string]Response) error {
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(service)
+	if err != nil {
+		return fmt.Errorf("openapi: service %s: %w", service, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return fmt.Errorf("openapi: %s is not a service", service)
+	}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+			method, path := httpRule(r)
+			if method == "" {
+				continue
+			}
+			op := &Operation{
+				Summary:     string(md.Name()),
+				OperationID: string(sd.Name()) + "_" + string(md.Name()),
+				Tags:        []string{tag},
+				Responses:   map[string]Response{"200": JSONResponse("OK", d.responseSchema(md.Output(), r.GetResponseBody()))},
+			}
+			if body := r.GetBody(); body != "" {
+				op.RequestBody = JSONBody(d.bodySchema(md.Input(), body))
+			} else {
+				op.Parameters = d.queryParams(md.Input(), path)
+			}
+			for code, resp := range errs {
+				op.Responses[code] = resp
+			}
+			d.Add(method, path, op)
+		}
+	}
+	return nil
+}
+
+// httpRule trả method + path dạng OpenAPI
+func httpRule(r *annotations.HttpRule) (string, string) {
+	var method, path string
+	switch p := r.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		method, path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		method, path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		method, path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Patch:
+		method, path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Delete:
+		method, path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Custom:
+		method, path = strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	default:
+		return "", ""
+	}
+	return method, stripVarPatterns(path)
+}
+
+// body "*" = cả message; "field" = chỉ field đó
+func (d *Document) bodySchema(md protoreflect.MessageDescriptor, body string) *Schema {
+	if body == "*" {
+		return d.ProtoSchema(md)
+	}
+	if fd := md.Fields().ByName(protoreflect.Name(body)); fd != nil {
+		return d.protoField(fd)
+	}
+	return d.ProtoSchema(md)
+}
+
+func (d *Document) responseSchema(md protoreflect.MessageDescriptor, field string) *Schema {
+	if field != "" {
+		if fd := md.Fields().ByName(protoreflect.Name(field)); fd != nil {
+			return d.protoField(fd)
+		}
+	}
+	return d.ProtoSchema(md)
+}
+
+// các field scalar không nằm trên path được truyền qua query string
+func (d *Document) queryParams(md protoreflect.MessageDescriptor, path string) []Parameter {
+	inPath := map[string]bool{}
+	for _, p := range pathParams(path) {
+		inPath[p] = true
+	}
+	var out []Parameter
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if inPath[string(fd.Name())] || fd.Kind() == protoreflect.MessageKind || fd.IsMap() {
+			continue
+		}
+		out = append(out, Parameter{Name: string(fd.Name()), In: "query", Schema: d.protoField(fd)})
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Schema đăng ký struct v vào components.schemas với tên name và trả về $ref.
+// Field lấy theo json tag; field không có omitempty (hoặc có validate:"required") được coi
+// là bắt buộc. Rule email/min/max/maxbytes của tag validate (util.Validate) được ghi vào schema.
+func (d *Document) Schema(name string, v any) *Schema {
+	if _, ok := d.Components.Schemas[name]; !ok {
+		d.Components.Schemas[name] = reflectSchema(reflect.TypeOf(v))
+	}
+	return ref(name)
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func reflectSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: reflectSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: reflectSchema(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop := reflectSchema(f.Type)
+			required := applyValidateTag(prop, f.Tag.Get("validate"))
+			s.Properties[name] = prop
+			if required || !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+// applyValidateTag ghi rule của tag validate vào schema, trả true nếu có "required"
+func applyValidateTag(s *Schema, tag string) (required bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case s.Type == "string" && key == "min":
+				s.MinLength = ptr(int(n))
+			case s.Type == "string":
+				s.MaxLength = ptr(int(n))
+			case key == "min":
+				s.Minimum = &n
+			default:
+				s.Maximum = &n
+			}
+		case "maxbytes":
+			// JSON Schema không có giới hạn theo byte; N byte UTF-8 thì không quá N ký tự
+			if n, err := strconv.Atoi(arg); err == nil && s.MaxLength == nil {
+				s.MaxLength = ptr(n)
+			}
+		}
+	}
+	return required
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// ProtoSchema đăng ký message proto (và các message lồng bên trong) vào
+// components.schemas theo full name, trả về $ref. Field dùng tên proto
+// (snake_case) và kiểu theo quy ước protojson (int64 là string).
+func (d *Document) ProtoSchema(md protoreflect.MessageDescriptor) *Schema {
+	name := string(md.FullName())
+	if _, ok := d.Components.Schemas[name]; ok {
+		return ref(name)
+	}
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	d.Components.Schemas[name] = s // đặt trước để message đệ quy không lặp vô hạn
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		s.Properties[string(fd.Name())] = d.protoField(fd)
+	}
+	return ref(name)
+}
+
+func (d *Document) protoField(fd protoreflect.FieldDescriptor) *Schema {
+	if fd.IsMap() {
+		return &Schema{Type: "object", AdditionalProperties: d.protoKind(fd.MapValue())}
+	}
+	if fd.IsList() {
+		return &Schema{Type: "array", Items: d.protoKind(fd)}
+	}
+	return d.protoKind(fd)
+}
+
+func (d *Document) protoKind(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		vals := fd.Enum().Values()
+		s := &Schema{Type: "string"}
+		for i := 0; i < vals.Len(); i++ {
+			s.Enum = append(s.Enum, string(vals.Get(i).Name()))
+		}
+		return s
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if fd.Message().FullName().Parent() == "google.protobuf" {
+			return wellKnown(fd.Message().FullName())
+		}
+		return d.ProtoSchema(fd.Message())
+	default:
+		return &Schema{}
+	}
+}
+
+// well-known types có dạng JSON riêng
+func wellKnown(name protoreflect.FullName) *Schema {
+	switch name.Name() {
+	case "Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "Duration", "FieldMask":
+		return &Schema{Type: "string"}
+	case "StringValue":
+		return &Schema{Type: "string"}
+	case "BoolValue":
+		return &Schema{Type: "boolean"}
+	case "Int32Value", "UInt32Value":
+		return &Schema{Type: "integer"}
+	case "Int64Value", "UInt64Value":
+		return &Schema{Type: "string", Format: "int64"}
+	case "FloatValue", "DoubleValue":
+		return &Schema{Type: "number"}
+	default: // Struct, Value, Any, Empty...
+		return &Schema{Type: "object"}
+	}
+}
@@ -0,0 +1,30 @@
+package openapi
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+)
+
+//go:embed swagger.html
+var swaggerHTML string
+
+var swaggerTmpl = template.Must(template.New("swagger").Parse(swaggerHTML))
+
+// UI trả trang Swagger UI đọc tài liệu tại specURL (vd "/openapi.json").
+// Trang HTML được nhúng trong binary; JS/CSS của swagger-ui lấy từ CDN.
+// CSP riêng của trang (thay CSP mặc định của gateway) chỉ cho CDN đó và script inline có nonce.
+func UI(specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		nonce := base64.StdEncoding.EncodeToString(b)
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; "+
+			"script-src 'nonce-"+nonce+"' https://unpkg.com; style-src 'unsafe-inline' https://unpkg.com; "+
+			"img-src 'self' data: https:; connect-src 'self'; frame-ancestors 'none'; base-uri 'none'")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = swaggerTmpl.Execute(w, struct{ SpecURL, Nonce string }{specURL, nonce})
+	})
+}
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// IsJSONContentType: application/json hoặc application/*+json (bỏ qua charset)
+func IsJSONContentType(v string) bool {
+	mt, _, err := mime.ParseMediaType(v)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasPrefix(mt, "application/") && strings.HasSuffix(mt, "+json")
+}
+
+// DecodeJSON đọc body JSON vào dst một cách chặt chẽ: bắt buộc Content-Type JSON,
+// từ chối field lạ, body rỗng và dữ liệu thừa sau object. Giới hạn kích thước do
+// middleware.BodyLimit đặt. Lỗi được ghi ra w (400 / 413 / 415) và trả false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if !IsJSONContentType(r.Header.Get("Content-Type")) {
+		Error(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		writeDecodeError(w, err)
+		return false
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeDecodeError(w, err)
+			return false
+		}
+		Error(w, http.StatusBadRequest, "request body must contain a single JSON object")
+		return false
+	}
+	return true
+}
+
+// BindJSON = DecodeJSON + Validate; vi phạm validate trả 400 kèm danh sách field
+func BindJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if !DecodeJSON(w, r, dst) {
+		return false
+	}
+	v, err := Validate(dst)
+	if err != nil {
+		// tag hỏng của kiểu chưa qua RegisterValidation: lỗi cấu hình, không phải lỗi client
+		slog.Error("validate rules", "type", fmt.Sprintf("%T", dst), "error", err)
+		Error(w, http.StatusInternalServerError, "internal error")
+		return false
+	}
+	if len(v) > 0 {
+		ValidationError(w, v)
+		return false
+	}
+	return true
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var (
+		tooLarge  *http.MaxBytesError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit))
+	case errors.Is(err, io.EOF):
+		Error(w, http.StatusBadRequest, "request body must not be empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		Error(w, http.StatusBadRequest, "malformed JSON")
+	case errors.As(err, &syntaxErr):
+		Error(w, http.StatusBadRequest, fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		ValidationError(w, []FieldViolation{{Field: typeErr.Field, Description: "must be a " + jsonKind(typeErr.Type.Kind().String())}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json không có kiểu lỗi riêng cho field lạ
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		ValidationError(w, []FieldViolation{{Field: field, Description: "unknown field"}})
+	default:
+		Error(w, http.StatusBadRequest, "invalid JSON")
+	}
+}
+
+func jsonKind(goKind string) string {
+	switch {
+	case goKind == "string":
+		return "string"
+	case goKind == "bool":
+		return "boolean"
+	case strings.HasPrefix(goKind, "int"), strings.HasPrefix(goKind, "uint"), strings.HasPrefix(goKind, "float"):
+		return "number"
+	case goKind == "slice", goKind == "array":
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -11,6 +11,7 @@ func JSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// Error trả lỗi dạng problem+json (RFC 7807), xem problem.go
 func Error(w http.ResponseWriter, status int, msg string) {
-	JSON(w, status, map[string]string{"error": msg})
+	WriteProblem(w, Problem{Status: status, Detail: msg})
 }
@@ -0,0 +1,257 @@
+package util
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validate kiểm tra struct theo tag `validate`, tên field lấy theo json tag:
+//
+//	required   không rỗng (string được trim khoảng trắng)
+//	email      địa chỉ email hợp lệ, không kèm tên hiển thị
+//	min=N      string: ít nhất N ký tự (sau trim); số: >= N
+//	max=N      string: nhiều nhất N ký tự; số: <= N
+//	maxbytes=N string: nhiều nhất N byte UTF-8 (vd. mật khẩu bcrypt, chỉ dùng 72 byte đầu)
+//
+// Field rỗng không bắt buộc thì bỏ qua các rule khác. Struct lồng nhau được kiểm tra
+// đệ quy ("user.email"). Tag sai cú pháp trả lỗi; RegisterValidation kiểm tra trước
+// lúc khởi động để lỗi này không xảy ra khi đang phục vụ request.
+func Validate(v any) ([]FieldViolation, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	return validateStruct(rv, "")
+}
+
+// RegisterValidation phân tích tag validate của các kiểu (và struct lồng bên trong) một lần,
+// lưu lại cho Validate. Gọi lúc dựng route với mọi kiểu input để tag sai làm gateway
+// không khởi động được thay vì lỗi ở request đầu tiên.
+func RegisterValidation(samples ...any) error {
+	for _, v := range samples {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("validate: %T is not a struct", v)
+		}
+		if _, err := structRules(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fieldRules struct {
+	index    int
+	name     string
+	required bool
+	email    bool
+	min, max *float64
+	maxBytes int // 0 = không giới hạn
+	nested   bool
+}
+
+// structEntry là kết quả phân tích một kiểu, kể cả lỗi (để không phân tích lại mỗi request)
+type structEntry struct {
+	rules []fieldRules
+	err   error
+}
+
+var rulesCache sync.Map // reflect.Type -> structEntry
+
+func validateStruct(rv reflect.Value, prefix string) ([]FieldViolation, error) {
+	rules, err := structRules(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	var out []FieldViolation
+	for _, fr := range rules {
+		fv := rv.Field(fr.index)
+		name := prefix + fr.name
+		if fr.nested {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				nested, err := validateStruct(fv, name+".")
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, nested...)
+			}
+			continue
+		}
+		if msg := fr.check(fv); msg != "" {
+			out = append(out, FieldViolation{Field: name, Description: msg})
+		}
+	}
+	return out, nil
+}
+
+func (fr fieldRules) check(fv reflect.Value) string {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			if fr.required {
+				return "is required"
+			}
+			return ""
+		}
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		s := strings.TrimSpace(fv.String())
+		if s == "" {
+			if fr.required {
+				return "is required"
+			}
+			return ""
+		}
+		n := float64(utf8.RuneCountInString(s))
+		if fr.min != nil && n < *fr.min {
+			return fmt.Sprintf("must be at least %g characters", *fr.min)
+		}
+		if fr.max != nil && float64(utf8.RuneCountInString(fv.String())) > *fr.max {
+			return fmt.Sprintf("must be at most %g characters", *fr.max)
+		}
+		if fr.maxBytes > 0 && len(fv.String()) > fr.maxBytes {
+			return fmt.Sprintf("must be at most %d bytes", fr.maxBytes)
+		}
+		if fr.email {
+			if a, err := mail.ParseAddress(s); err != nil || a.Address != s {
+				return "must be a valid email address"
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if fv.IsZero() && fr.required {
+			return "is required"
+		}
+		n := numberOf(fv)
+		if fr.min != nil && n < *fr.min {
+			return fmt.Sprintf("must be >= %g", *fr.min)
+		}
+		if fr.max != nil && n > *fr.max {
+			return fmt.Sprintf("must be <= %g", *fr.max)
+		}
+	default:
+		if fr.required && fv.IsZero() {
+			return "is required"
+		}
+	}
+	return ""
+}
+
+func numberOf(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func structRules(t reflect.Type) ([]fieldRules, error) {
+	if e, ok := rulesCache.Load(t); ok {
+		return e.(structEntry).rules, e.(structEntry).err
+	}
+	rules, err := parseStruct(t, map[reflect.Type]bool{t: true})
+	e, _ := rulesCache.LoadOrStore(t, structEntry{rules: rules, err: err})
+	return e.(structEntry).rules, e.(structEntry).err
+}
+
+// parseStruct phân tích rule của t và cache luôn rule của struct lồng; seen chặn kiểu tự tham chiếu
+func parseStruct(t reflect.Type, seen map[reflect.Type]bool) ([]fieldRules, error) {
+	var rules []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		tag, hasTag := f.Tag.Lookup("validate")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if !hasTag {
+			if ft.Kind() == reflect.Struct {
+				if !seen[ft] {
+					seen[ft] = true
+					nested, err := parseStruct(ft, seen)
+					if err != nil {
+						return nil, err
+					}
+					rulesCache.LoadOrStore(ft, structEntry{rules: nested})
+				}
+				rules = append(rules, fieldRules{index: i, name: name, nested: true})
+			}
+			continue
+		}
+		fr, err := parseRules(i, name, tag, ft)
+		if err != nil {
+			return nil, fmt.Errorf("validate: %s.%s: %w", t, name, err)
+		}
+		rules = append(rules, fr)
+	}
+	return rules, nil
+}
+
+func parseRules(index int, name, tag string, ft reflect.Type) (fieldRules, error) {
+	fr := fieldRules{index: index, name: name}
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "":
+		case "required":
+			fr.required = true
+		case "email":
+			fr.email = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fr, fmt.Errorf("bad %s value %q", key, arg)
+			}
+			if key == "min" {
+				fr.min = &n
+			} else {
+				fr.max = &n
+			}
+		case "maxbytes":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return fr, fmt.Errorf("bad maxbytes value %q", arg)
+			}
+			if ft.Kind() != reflect.String {
+				return fr, fmt.Errorf("maxbytes needs a string field, not %s", ft)
+			}
+			fr.maxBytes = n
+		default:
+			return fr, fmt.Errorf("unknown rule %q", key)
+		}
+	}
+	return fr, nil
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required,max=10"`
+}
+
+type validateInput struct {
+	Email    string           `json:"email" validate:"required,email"`
+	Password string           `json:"password" validate:"required,min=6,maxbytes=72"`
+	Age      *int             `json:"age,omitempty" validate:"min=18,max=130"`
+	Nick     *string          `json:"nick,omitempty" validate:"max=5"`
+	Address  *validateAddress `json:"address,omitempty"`
+	Next     *validateInput   `json:"next,omitempty"` // kiểu tự tham chiếu
+}
+
+func fields(v []FieldViolation) string {
+	var out []string
+	for _, f := range v {
+		out = append(out, f.Field+": "+f.Description)
+	}
+	return strings.Join(out, "; ")
+}
+
+func TestValidate(t *testing.T) {
+	long, young := "abcdef", 12
+	tests := []struct {
+		name string
+		in   validateInput
+		want string
+	}{
+		{"ok", validateInput{Email: "a@b.vn", Password: "secret"}, ""},
+		{"required", validateInput{Email: "  ", Password: "secret"}, "email: is required"},
+		{"email with display name", validateInput{Email: "Loc <a@b.vn>", Password: "secret"}, "email: must be a valid email address"},
+		{"min characters", validateInput{Email: "a@b.vn", Password: "abc"}, "password: must be at least 6 characters"},
+		// 36 ký tự nhưng 72 byte: vừa đủ
+		{"maxbytes boundary", validateInput{Email: "a@b.vn", Password: strings.Repeat("é", 36)}, ""},
+		// 40 ký tự (dưới max ký tự thông thường) nhưng 80 byte: bcrypt sẽ cắt mất phần sau
+		{"maxbytes multibyte", validateInput{Email: "a@b.vn", Password: strings.Repeat("é", 40)}, "password: must be at most 72 bytes"},
+		{"number range", validateInput{Email: "a@b.vn", Password: "secret", Age: &young}, "age: must be >= 18"},
+		{"pointer", validateInput{Email: "a@b.vn", Password: "secret", Nick: &long}, "nick: must be at most 5 characters"},
+		{"nested", validateInput{Email: "a@b.vn", Password: "secret", Address: &validateAddress{}}, "address.city: is required"},
+		{"recursive", validateInput{Email: "a@b.vn", Password: "secret", Next: &validateInput{Email: "x"}},
+			"next.email: must be a valid email address; next.password: is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Validate(&tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fields(v); got != tt.want {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type badRule struct {
+	Name string `json:"name" validate:"required,lenght=3"`
+}
+
+type badMaxBytes struct {
+	Count int `json:"count" validate:"maxbytes=10"`
+}
+
+type badNumber struct {
+	Name string `json:"name" validate:"max=ten"`
+}
+
+type badNested struct {
+	Inner badNumber `json:"inner"`
+}
+
+func TestRegisterValidation(t *testing.T) {
+	if err := RegisterValidation(validateInput{}, &validateAddress{}); err != nil {
+		t.Fatalf("RegisterValidation: %v", err)
+	}
+	for _, v := range []any{badRule{}, badMaxBytes{}, badNumber{}, badNested{}, "not a struct", nil} {
+		if err := RegisterValidation(v); err == nil {
+			t.Errorf("RegisterValidation(%T) = nil, want error", v)
+		}
+	}
+	err := RegisterValidation(badNested{})
+	if err == nil || !strings.Contains(err.Error(), "util.badNumber.name") {
+		t.Errorf("error = %v, want it to name the field", err)
+	}
+}
+
+func TestValidateBadTagReturnsError(t *testing.T) {
+	// kiểu chưa đăng ký có tag sai: trả lỗi (không panic), lần sau vẫn lỗi như cũ
+	for range 2 {
+		if _, err := Validate(&badRule{Name: "x"}); err == nil {
+			t.Fatal("Validate with unknown rule = nil error")
+		}
+	}
+}
+
+func TestBindJSONBadTag(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	if BindJSON(w, r, &badRule{}) {
+		t.Fatal("BindJSON succeeded")
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	var p Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &p)
+	if strings.Contains(p.Detail, "lenght") {
+		t.Errorf("detail leaks rule error: %q", p.Detail)
+	}
+}
@@ -2,17 +2,24 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/internal/grpcserver"
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/proto/authpb"
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/repository"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type App struct {
@@ -20,10 +27,18 @@ type App struct {
 	rdb    *redis.Client // Used for session key storage
 	mgdb   *mongo.Database
 	config Config
+	health *platform.Health       // /readyz + grpc.health.v1
+	ips    *platform.IPResolver   // IP client theo TRUSTED_PROXIES
+	tls    *platform.CertReloader // nil = gRPC plaintext
 }
 
 func New(ctx context.Context, config Config) (*App, error) {
-	mongoClient, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(config.MongoURI))
+	ips, err := platform.LoadIPResolver(config.TrustedProxies, config.TrustedProxiesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoClient, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(config.MongoURI).SetMonitor(platform.MongoMonitor()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
@@ -36,54 +51,76 @@ func New(ctx context.Context, config Config) (*App, error) {
 		}),
 		mgdb:   mongoClient.Database("demo_db"),
 		config: config,
+		ips:    ips,
+	}
+	if config.GRPCTLS.Enabled() {
+		if config.GRPCTLS.CertFile == "" {
+			return nil, errors.New("gRPC TLS requires GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+		}
+		if app.tls, err = platform.NewCertReloader(config.GRPCTLS); err != nil {
+			return nil, err
+		}
 	}
+	app.health = app.newHealth()
 	app.loadRoutes()
 
 	return app, nil
 }
 
 func (a *App) Start(ctx context.Context) error {
-	httpServer := &http.Server{
+	// mở port gRPC trước để lỗi "address in use" được báo ngay
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", a.config.GRPCPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen on gRPC port %d: %w", a.config.GRPCPort, err)
+	}
+	grpcServer, healthSrv := a.newGRPCServer()
+
+	lc := platform.NewLifecycle(a.config.ShutdownTimeout)
+	lc.AddHTTP("http", &http.Server{
 		Addr:    fmt.Sprintf(":%d", a.config.ServerPort),
 		Handler: a.router,
+	})
+	lc.AddGRPC("grpc", grpcServer, lis)
+	lc.Go(func(ctx context.Context) {
+		a.health.WatchGRPC(ctx, healthSrv, 10*time.Second, authpb.UserService_ServiceDesc.ServiceName)
+	})
+	if a.tls != nil {
+		lc.Go(a.tls.Watch)
 	}
+	lc.OnStop("mongodb", a.mgdb.Client().Disconnect)
+	lc.OnStop("redis", func(context.Context) error { return a.rdb.Close() })
 
-	fmt.Println("Starting server")
-
-	ch := make(chan error, 1)
-
-	// Running HTTP server
-	go func() {
-		err := httpServer.ListenAndServe()
-		if err != nil {
-			ch <- fmt.Errorf("failed to start server: %w", err)
-		}
-		close(ch)
-	}()
-
-	go func() {
-		if err := a.startGRPCServer(); err != nil {
-			ch <- fmt.Errorf("grpc server error: %w", err)
-		}
-	}()
+	return lc.Run(ctx)
+}
 
-	select {
-	case err := <-ch:
-		return err
-	case <-ctx.Done():
-		return httpServer.Shutdown(ctx)
+// newHealth: Mongo luôn được kiểm tra, Redis chỉ khi có cấu hình REDIS_ADDR
+func (a *App) newHealth() *platform.Health {
+	h := platform.NewHealth("auth-service", platform.HealthCheck{
+		Name:  "mongodb",
+		Check: func(ctx context.Context) error { return a.mgdb.Client().Ping(ctx, readpref.Primary()) },
+	})
+	if a.config.RedisAddress != "" {
+		h.Add(platform.HealthCheck{
+			Name:  "redis",
+			Check: func(ctx context.Context) error { return a.rdb.Ping(ctx).Err() },
+		})
 	}
-
-	return nil
+	return h
 }
 
-func (a *App) startGRPCServer() error {
-	listen, err := net.Listen("tcp", ":50051")
-	if err != nil {
-		return fmt.Errorf("failed to listen on port 50051: %w", err)
+func (a *App) newGRPCServer() (*grpc.Server, *health.Server) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			platform.UnaryRequestIDInterceptor,
+			platform.UnaryLoggingInterceptor,
+			platform.UnaryMetricsInterceptor,
+		),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	}
-
-	grpcServer := grpc.NewServer()
+	if a.tls != nil {
+		opts = append(opts, grpc.Creds(a.tls.ServerCredentials()))
+	}
+	grpcServer := grpc.NewServer(opts...)
 
 	authpb.RegisterUserServiceServer(grpcServer, &grpcserver.UserGRPCHandler{
 		Repo: &repository.RedisMongo{
@@ -91,6 +128,9 @@ func (a *App) startGRPCServer() error {
 			JwtSecret:  a.config.JwtSecret,
 		},
 	})
-	fmt.Println("gRPC server started on port 50051")
-	return grpcServer.Serve(listen)
+
+	// grpc.health.v1: trạng thái cập nhật theo cùng các check của /readyz
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthSrv)
+	return grpcServer, healthSrv
 }
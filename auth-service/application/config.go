@@ -1,10 +1,12 @@
 package application
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/joho/godotenv"
 )
 
@@ -14,14 +16,30 @@ type Config struct {
 	RedisPassword string // mật khẩu login
 	MongoURI      string
 	ServerPort    uint16 // cổng lắng nghe của backend
+	GRPCPort      uint16 // cổng gRPC (GRPC_PORT, mặc định 50051)
 	JwtSecret     string // Secret JWT
+	MetricsToken  string // bearer token cho /metrics (rỗng = không yêu cầu)
+
+	ShutdownTimeout time.Duration // thời gian drain request khi dừng (SHUTDOWN_TIMEOUT)
+
+	// Proxy tin cậy được phép đặt X-Forwarded-For (CIDR, phân tách bằng dấu phẩy, mặc định loopback) + file CIDR
+	TrustedProxies     string
+	TrustedProxiesFile string
+
+	// TLS cho gRPC server (GRPC_TLS_CERT_FILE, _KEY_FILE, _CA_FILE, _CLIENT_AUTH, _ALLOWED_SANS);
+	// không cấu hình = plaintext
+	GRPCTLS platform.TLSOptions
 }
 
 func LoadConfig() Config {
 	_ = godotenv.Load()
 	cfg := Config{
-		ServerPort: 3000, // default server port
+		ServerPort:      3000,  // default server port
+		GRPCPort:        50051, // default gRPC port
+		ShutdownTimeout: 15 * time.Second,
 	}
+	// Secret đọc qua *_FILE (Docker/Kubernetes), vault hoặc env
+	secrets := platform.NewSecretProvider()
 
 	if redisAddr, exist := os.LookupEnv("REDIS_ADDR"); exist {
 		cfg.RedisAddress = redisAddr
@@ -35,7 +53,7 @@ func LoadConfig() Config {
 		cfg.RedisPassword = redisPass
 	}
 
-	if mongoURI, exist := os.LookupEnv("MONGODB_URI"); exist {
+	if mongoURI, err := secrets.Get("MONGODB_URI"); err == nil {
 		cfg.MongoURI = mongoURI
 	}
 
@@ -46,13 +64,37 @@ func LoadConfig() Config {
 		}
 	}
 
-	if jwtSecret, exist := os.LookupEnv("JWT_SECRET_KEY"); exist {
+	if grpcPort, exist := os.LookupEnv("GRPC_PORT"); exist {
+		if port, err := strconv.ParseUint(grpcPort, 10, 16); err == nil {
+			cfg.GRPCPort = uint16(port)
+		}
+	}
+
+	if timeout, exist := os.LookupEnv("SHUTDOWN_TIMEOUT"); exist {
+		if d, err := time.ParseDuration(timeout); err == nil {
+			cfg.ShutdownTimeout = d
+		}
+	}
+
+	cfg.TrustedProxies = platform.DefaultTrustedProxies
+	if v, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		cfg.TrustedProxies = v
+	}
+	cfg.TrustedProxiesFile = os.Getenv("TRUSTED_PROXIES_FILE")
+	cfg.GRPCTLS = platform.TLSOptionsFromEnv("GRPC_TLS")
+
+	if jwtSecret, err := secrets.Get("JWT_SECRET_KEY"); err == nil {
 		cfg.JwtSecret = jwtSecret
 	}
 
+	if token, err := secrets.Get("METRICS_TOKEN"); err == nil {
+		cfg.MetricsToken = token
+	}
+
 	// Kiểm tra các trường bắt buộc
 	if cfg.JwtSecret == "" || cfg.MongoURI == "" {
-		log.Fatal("Missing required env: JWT_SECRET_KEY or MONGODB_URI")
+		slog.Error("missing required env: JWT_SECRET_KEY or MONGODB_URI")
+		os.Exit(1)
 	}
 
 	return cfg
@@ -5,19 +5,28 @@ import (
 
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/handler"
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/repository"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
 )
 
 func (a *App) loadRoutes() {
 	router := chi.NewRouter()
 
-	router.Use(middleware.Logger)
+	router.Use(platform.RequestID)
+	router.Use(a.ips.Middleware)
+	router.Use(platform.HTTPTracing("auth-service"))
+	router.Use(platform.RequestLogger)
+	router.Use(platform.HTTPMetrics)
 
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	router.Get("/livez", a.health.Livez)
+	router.Get("/readyz", a.health.Readyz)
+
+	router.Method(http.MethodGet, "/metrics", platform.MetricsHandler(a.config.MetricsToken))
+
 	router.Route("/auth", a.loadUserLogin)
 
 	a.router = router
@@ -2,7 +2,7 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
 
 	repository "github.com/RibunLoc/WebPersonalBackend/auth-service/repository"
@@ -20,26 +20,26 @@ func (h *UserLogin) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, "Invalid Json", http.StatusBadRequest)
+		util.Error(w, http.StatusBadRequest, "Invalid Json")
 		return
 	}
 
 	// Lấy user từ DB
 	user, err := h.Repo.FindByEmail(r.Context(), body.Email)
 	if err != nil || user == nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		util.Error(w, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
 	// so sánh password
 	if !util.CheckPasswordHash(body.Password, user.Password) {
-		http.Error(w, "Invaled email or passowrd", http.StatusUnauthorized)
+		util.Error(w, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
 	token, err := util.GenerateJWT(user.ID.Hex(), h.Repo.JwtSecret)
 	if err != nil {
-		http.Error(w, "failed to generate token", http.StatusInternalServerError)
+		util.Error(w, http.StatusInternalServerError, "failed to generate token")
 		return
 	}
 
@@ -60,8 +60,8 @@ func (h *UserLogin) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	res, err := json.Marshal(resBody)
 	if err != nil {
-		fmt.Println("failed to connect login response to json: ", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "failed to encode login response", "error", err)
+		util.Error(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
@@ -2,7 +2,7 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -24,13 +24,13 @@ func (h *UserRegister) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		util.Error(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	passwordHash, err := util.HashPassword(body.Password)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		util.Error(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
@@ -48,21 +48,21 @@ func (h *UserRegister) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 	//check email
 	existingUser, err := h.Repo.FindByEmail(r.Context(), userNew.Email)
 	if err == nil && existingUser != nil {
-		fmt.Printf("User exists: %v\n", existingUser.Email)
-		w.WriteHeader(http.StatusConflict)
+		slog.InfoContext(r.Context(), "register rejected, user exists", "email", existingUser.Email)
+		util.Error(w, http.StatusConflict, "email already registered")
 		return
 	}
 
 	if err := h.Repo.CreateUser(r.Context(), userNew); err != nil {
-		fmt.Println("failed to create new user: ", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "failed to create user", "error", err)
+		util.Error(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	res, err := json.Marshal(userNew)
 	if err != nil {
-		fmt.Println("failed to convert user to Json: ", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "failed to encode user", "error", err)
+		util.Error(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
@@ -3,9 +3,16 @@ package grpcserver
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/RibunLoc/WebPersonalBackend/auth-service/model"
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/proto/authpb"
 	repository "github.com/RibunLoc/WebPersonalBackend/auth-service/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/util"
 )
@@ -17,18 +24,22 @@ type UserGRPCHandler struct {
 
 // Đăng nhập người dùng
 func (h *UserGRPCHandler) Login(ctx context.Context, req *authpb.LoginRequest) (*authpb.LoginResponse, error) {
+	// Không phân biệt "không có user" và "sai mật khẩu" để tránh dò email
 	user, err := h.Repo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errors.New("user not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+		}
+		return nil, status.Error(codes.Internal, "failed to look up user")
 	}
 
 	if !util.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.New("invalid password")
+		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
 	}
 
 	token, err := util.GenerateJWT(user.ID.Hex(), h.Repo.JwtSecret)
 	if err != nil {
-		return nil, err
+		return nil, status.Error(codes.Internal, "failed to generate token")
 	}
 
 	return &authpb.LoginResponse{
@@ -41,3 +52,49 @@ func (h *UserGRPCHandler) Login(ctx context.Context, req *authpb.LoginRequest) (
 		},
 	}, nil
 }
+
+// Đăng ký người dùng (cùng logic với POST /auth/register)
+func (h *UserGRPCHandler) Register(ctx context.Context, req *authpb.RegisterRequest) (*authpb.UserResponse, error) {
+	email := strings.TrimSpace(req.Email)
+	br := &errdetails.BadRequest{}
+	if email == "" {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: "email", Description: "is required"})
+	}
+	if len(req.Password) < 6 {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: "password", Description: "must be at least 6 characters"})
+	}
+	if len(br.FieldViolations) > 0 {
+		st, _ := status.New(codes.InvalidArgument, "invalid registration data").WithDetails(br)
+		return nil, st.Err()
+	}
+
+	// check email
+	if existing, err := h.Repo.FindByEmail(ctx, email); err == nil && existing != nil {
+		return nil, status.Error(codes.AlreadyExists, "email already registered")
+	}
+
+	passwordHash, err := util.HashPassword(req.Password)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to hash password")
+	}
+
+	now := util.CustomTime(time.Now())
+	user := &model.User{
+		Email:     email,
+		Password:  passwordHash,
+		Fullname:  req.Fullname,
+		Role:      "user",
+		IsActive:  true,
+		CreatedAt: &now,
+	}
+	if err := h.Repo.CreateUser(ctx, user); err != nil {
+		return nil, status.Error(codes.Internal, "failed to create user")
+	}
+
+	return &authpb.UserResponse{
+		Id:       user.ID.Hex(),
+		Email:    user.Email,
+		Fullname: user.Fullname,
+		Role:     user.Role,
+	}, nil
+}
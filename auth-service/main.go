@@ -2,28 +2,40 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
+	"time"
 
 	"github.com/RibunLoc/WebPersonalBackend/auth-service/application"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	slog.SetDefault(platform.NewLogger("auth-service"))
 
-	app, err := application.New(ctx, application.LoadConfig())
+	// SIGINT/SIGTERM được xử lý trong App.Start (platform.Lifecycle) để drain có thứ tự
+	ctx := context.Background()
 
+	shutdownTracing, err := platform.InitTracing(ctx, "auth-service")
 	if err != nil {
-		fmt.Println("failed to load config server: ", err)
-		return
+		slog.Error("failed to init tracing", "error", err)
+		os.Exit(1)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
+
+	app, err := application.New(ctx, application.LoadConfig())
 
-	defer cancel()
+	if err != nil {
+		slog.Error("failed to init app", "error", err)
+		os.Exit(1)
+	}
 
-	errStart := app.Start(ctx)
-	if errStart != nil {
-		fmt.Println("failed to start app: ", err)
+	if err := app.Start(ctx); err != nil {
+		slog.Error("failed to start app", "error", err)
+		os.Exit(1)
 	}
-	cancel()
 }
@@ -23,8 +23,14 @@ func NewUserRepo(db *mongo.Database) *RedisMongo {
 }
 
 func (r *RedisMongo) CreateUser(ctx context.Context, user *model.User) error {
-	_, err := r.Collection.InsertOne(ctx, user)
-	return err
+	res, err := r.Collection.InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		user.ID = oid
+	}
+	return nil
 }
 
 func (r *RedisMongo) FindByEmail(ctx context.Context, email string) (*model.User, error) {
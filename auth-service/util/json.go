@@ -0,0 +1,23 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Error trả {"error": msg, "request_id": ...}; request id lấy từ header do platform.RequestID gắn
+func Error(w http.ResponseWriter, status int, msg string) {
+	body := map[string]string{"error": msg}
+	if id := w.Header().Get(platform.RequestIDHeader); id != "" {
+		body["request_id"] = id
+	}
+	JSON(w, status, body)
+}
@@ -2,19 +2,26 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"time"
 
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/handler"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/internal/grpcserver"
+	"github.com/RibunLoc/WebPersonalBackend/contact-service/proto/contactpb"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/repository"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type App struct {
@@ -22,14 +29,30 @@ type App struct {
 	router      http.Handler
 	repo        *repository.ContactMongo
 	emailer     util.EmailSender
+	verifier    util.Verifier
 	mongoClient *mongo.Client
+	health      *platform.Health           // /readyz + grpc.health.v1
+	ips         *platform.IPResolver       // IP client theo TRUSTED_PROXIES
+	tls         *platform.CertReloader     // nil = gRPC plaintext
+	secrets     []*platform.RotatingSecret // đọc lại định kỳ trong Start
 }
 
-func New(ctx context.Context, config Config) (*App, error) {
-	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(config.MongoURI))
+func New(ctx context.Context, config Config) (app *App, err error) {
+	ips, err := platform.LoadIPResolver(config.TrustedProxies, config.TrustedProxiesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(config.MongoURI).SetMonitor(platform.MongoMonitor()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
+	// New lỗi ở bất kỳ bước nào sau đây thì đóng kết nối Mongo đã mở
+	defer func() {
+		if err != nil {
+			_ = mongoClient.Disconnect(context.WithoutCancel(ctx))
+		}
+	}()
 	// ping để chắc kết nối
 	if err := mongoClient.Ping(ctx, nil); err != nil {
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
@@ -37,31 +60,63 @@ func New(ctx context.Context, config Config) (*App, error) {
 
 	db := mongoClient.Database("contact_db")
 
+	// secret có thể xoay vòng: đọc lại định kỳ (trong Start), không cần restart
+	turnstileSecret := platform.NewRotatingSecret(config.Secrets, "TURNSTILE_SECRET")
+	secrets := []*platform.RotatingSecret{turnstileSecret}
+
 	// khởi tạo emailer nếu đủ cấu hình
-	var emailer util.EmailSender
+	var (
+		emailer util.EmailSender
+		smtp    *util.SMTPSender
+	)
 	if config.SMTPHost != "" && config.SMTPPort != 0 && config.FromEmail != "" && config.NotifyEmail != "" {
-		emailer = util.NewSMTPSender(util.SMTPConfig{
-			Host:     config.SMTPHost,
-			Port:     int(config.SMTPPort),
-			Username: config.SMTPUser,
-			Password: config.SMTPPassword,
-			From:     config.FromEmail,
-			To:       []string{config.NotifyEmail},
+		smtpPassword := platform.NewRotatingSecret(config.Secrets, "SMTP_PASSWORD")
+		secrets = append(secrets, smtpPassword)
+
+		smtp = util.NewSMTPSender(util.SMTPConfig{
+			Host:           config.SMTPHost,
+			Port:           int(config.SMTPPort),
+			Username:       config.SMTPUser,
+			Password:       config.SMTPPassword,
+			PasswordSource: smtpPassword,
+			From:           config.FromEmail,
+			To:             []string{config.NotifyEmail},
 		})
+		emailer = smtp
 	}
 
 	if emailer == nil {
-		log.Println("[email] DISABLED")
+		slog.Info("email notifications disabled")
 	} else {
-		log.Printf("[email] ENABLED host=%s port=%d from=%s to=%s",
-			config.SMTPHost, config.SMTPPort, config.FromEmail, config.NotifyEmail)
+		slog.Info("email notifications enabled",
+			"host", config.SMTPHost, "port", config.SMTPPort, "from_email", config.FromEmail, "notify_email", config.NotifyEmail)
 	}
 
-	app := &App{
+	app = &App{
 		cfg:         config,
 		repo:        repository.NewContactRepo(db),
 		emailer:     emailer,
+		verifier:    util.NewRotatingVerifier(turnstileSecret, config.TurnstileDisable),
 		mongoClient: mongoClient,
+		ips:         ips,
+		secrets:     secrets,
+		health: platform.NewHealth("contact-service", platform.HealthCheck{
+			Name:  "mongodb",
+			Check: func(ctx context.Context) error { return mongoClient.Ping(ctx, readpref.Primary()) },
+		}),
+	}
+	// SMTP lỗi không chặn form liên hệ (submission vẫn được lưu) nên chỉ là check optional
+	if smtp != nil {
+		app.health.Add(platform.HealthCheck{Name: "smtp", Optional: true, Check: smtp.Ping})
+	}
+
+	if config.GRPCTLS.Enabled() {
+		if config.GRPCTLS.CertFile == "" {
+			return nil, errors.New("gRPC TLS requires GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+		}
+		if app.tls, err = platform.NewCertReloader(config.GRPCTLS); err != nil {
+			return nil, err
+		}
 	}
 
 	app.loadRoutes()
@@ -69,56 +124,56 @@ func New(ctx context.Context, config Config) (*App, error) {
 }
 
 func (a *App) Start(ctx context.Context) error {
-	httpSrv := &http.Server{
+	// mở port gRPC trước để lỗi "address in use" được báo ngay
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", a.cfg.GRPCPort))
+	if err != nil {
+		return fmt.Errorf("grpc listen: %w", err)
+	}
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			platform.UnaryRequestIDInterceptor,
+			platform.UnaryLoggingInterceptor,
+			platform.UnaryMetricsInterceptor,
+		),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	}
+	if a.tls != nil {
+		opts = append(opts, grpc.Creds(a.tls.ServerCredentials()))
+	}
+	grpcSrv := grpc.NewServer(opts...)
+	grpcserver.Register(grpcSrv, a.repo, a.verifier, a.emailer, a.ips)
+
+	// grpc.health.v1: trạng thái cập nhật theo cùng các check của /readyz
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+
+	lc := platform.NewLifecycle(a.cfg.ShutdownTimeout)
+	lc.AddHTTP("http", &http.Server{
 		Addr:         fmt.Sprintf(":%d", a.cfg.ServerPort),
 		Handler:      a.router,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  15 * time.Second,
+	})
+	lc.AddGRPC("grpc", grpcSrv, lis)
+	lc.Go(func(ctx context.Context) {
+		a.health.WatchGRPC(ctx, healthSrv, 10*time.Second, contactpb.ContactService_ServiceDesc.ServiceName)
+	})
+	if a.tls != nil {
+		lc.Go(a.tls.Watch)
 	}
-
-	fmt.Println("Starting contact service server")
-
-	errCh := make(chan error, 2)
-
-	go func() {
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- fmt.Errorf("http: %w", err)
-		}
-	}()
-
-	// gRPC
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", a.cfg.GRPCPort))
-	if err != nil {
-		return fmt.Errorf("grpc listen: %w", err)
+	for _, s := range a.secrets {
+		lc.Go(func(ctx context.Context) { s.Watch(ctx, a.cfg.SecretRefresh) })
 	}
-	grpcSrv := grpc.NewServer()
-	verifier := util.NewVerifier(a.cfg.TurnstileSecret, a.cfg.TurnstileDisable)
-	grpcserver.Register(grpcSrv, a.repo, verifier, a.emailer)
-
-	go func() {
-		if err := grpcSrv.Serve(lis); err != nil {
-			errCh <- fmt.Errorf("grpc: %w", err)
-		}
-	}()
+	lc.OnStop("mongodb", a.mongoClient.Disconnect)
 
-	select {
-	case err := <-errCh:
-		return err
-	case <-ctx.Done():
-		_ = httpSrv.Shutdown(ctx)
-		grpcSrv.GracefulStop()
-		if a.mongoClient != nil {
-			_ = a.mongoClient.Disconnect(ctx)
-		}
-		return nil
-	}
+	return lc.Run(ctx)
 }
 
 func (a *App) buildHandlers() *handler.ContactHandler {
 	return &handler.ContactHandler{
 		Repo:     a.repo,
-		Verifier: util.NewVerifier(a.cfg.TurnstileSecret, a.cfg.TurnstileDisable),
+		Verifier: a.verifier,
 		Emailer:  a.emailer,
 	}
 }
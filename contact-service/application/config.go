@@ -1,10 +1,12 @@
 package application
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/joho/godotenv"
 )
 
@@ -22,15 +24,36 @@ type Config struct {
 	SMTPPassword string
 	FromEmail    string
 	NotifyEmail  string
+
+	MetricsToken string // bearer token cho /metrics (rỗng = không yêu cầu)
+
+	// Nguồn secret (*_FILE, vault, env) + chu kỳ đọc lại SMTP/Turnstile secret
+	Secrets       platform.SecretProvider
+	SecretRefresh time.Duration
+
+	ShutdownTimeout time.Duration // thời gian drain request khi dừng (SHUTDOWN_TIMEOUT)
+
+	// Proxy tin cậy được phép đặt X-Forwarded-For / CF-Connecting-IP / remote_ip (gRPC):
+	// CIDR phân tách bằng dấu phẩy (mặc định loopback) + file CIDR (vd. cloudflare-ips.txt)
+	TrustedProxies     string
+	TrustedProxiesFile string
+
+	// TLS cho gRPC server (GRPC_TLS_CERT_FILE, _KEY_FILE, _CA_FILE, _CLIENT_AUTH, _ALLOWED_SANS);
+	// không cấu hình = plaintext
+	GRPCTLS platform.TLSOptions
 }
 
 func LoadConfig() Config {
 	// Dev-only: giúp chạy `go run` đọc .env; trong Docker không cần
 	_ = godotenv.Load()
 	cfg := Config{
-		ServerPort: 8082,
-		GRPCPort:   50052,
-		SMTPPort:   587,
+		ServerPort:    8082,
+		GRPCPort:      50052,
+		SMTPPort:      587,
+		Secrets:       platform.NewSecretProvider(),
+		SecretRefresh: 5 * time.Minute,
+
+		ShutdownTimeout: 15 * time.Second,
 	}
 	// Load server port from env
 	if v := os.Getenv("SERVER_PORT"); v != "" {
@@ -44,14 +67,20 @@ func LoadConfig() Config {
 			cfg.GRPCPort = uint16(p)
 		}
 	}
-	// Load MongoDB URI from env
-	if v := os.Getenv("MONGODB_URI"); v != "" {
+	// Load MongoDB URI from secret provider (MONGODB_URI_FILE, vault, env)
+	if v, err := cfg.Secrets.Get("MONGODB_URI"); err == nil {
 		cfg.MongoURI = v
 	}
-	// Load Turnstile secret from env
-	cfg.TurnstileSecret = os.Getenv("TURNSTILE_SECRET")
+	// Load Turnstile secret from secret provider
+	if v, err := cfg.Secrets.Get("TURNSTILE_SECRET"); err == nil {
+		cfg.TurnstileSecret = v
+	}
+	if v, err := cfg.Secrets.Get("METRICS_TOKEN"); err == nil {
+		cfg.MetricsToken = v
+	}
 	if cfg.MongoURI == "" || cfg.TurnstileSecret == "" {
-		log.Fatal("Missing required env: MONGODB_URI or TURNSTILE_SECRET")
+		slog.Error("missing required env: MONGODB_URI or TURNSTILE_SECRET")
+		os.Exit(1)
 	}
 	// Load Turnstile disable from env
 	if v := os.Getenv("TURNSTILE_DISABLE"); v != "" {
@@ -67,14 +96,32 @@ func LoadConfig() Config {
 		}
 	}
 	// Load SMTP user from env
-	if v := os.Getenv("SMTP_USER"); v != "" {
-		cfg.SMTPUser = v
-	}
-	// Load SMTP password from env
 	cfg.SMTPUser = os.Getenv("SMTP_USER")
-	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	// Load SMTP password from secret provider
+	if v, err := cfg.Secrets.Get("SMTP_PASSWORD"); err == nil {
+		cfg.SMTPPassword = v
+	}
 	cfg.FromEmail = os.Getenv("FROM_EMAIL")
 	cfg.NotifyEmail = os.Getenv("NOTIFY_EMAIL")
+	// Load secret refresh interval from env (0 = tắt)
+	if v := os.Getenv("SECRET_REFRESH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.SecretRefresh = d
+		}
+	}
+	// Load trusted proxies from env
+	cfg.TrustedProxies = platform.DefaultTrustedProxies
+	if v, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		cfg.TrustedProxies = v
+	}
+	cfg.TrustedProxiesFile = os.Getenv("TRUSTED_PROXIES_FILE")
+	cfg.GRPCTLS = platform.TLSOptionsFromEnv("GRPC_TLS")
+	// Load shutdown drain timeout from env
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ShutdownTimeout = d
+		}
+	}
 
 	return cfg
 }
@@ -3,19 +3,26 @@ package application
 import (
 	"net/http"
 
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
 )
 
 func (a *App) loadRoutes() {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(platform.RequestID)
+	r.Use(a.ips.Middleware)
+	r.Use(platform.HTTPTracing("contact-service"))
+	r.Use(platform.RequestLogger)
+	r.Use(platform.HTTPMetrics)
 	r.Use(middleware.Recoverer)
 
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// /healthz giữ lại cho healthcheck cũ, tương đương /livez
+	r.Get("/healthz", a.health.Livez)
+	r.Get("/livez", a.health.Livez)
+	r.Get("/readyz", a.health.Readyz)
+
+	r.Method(http.MethodGet, "/metrics", platform.MetricsHandler(a.cfg.MetricsToken))
 
 	ch := a.buildHandlers()
 	r.Post("/contact", ch.Submit)
@@ -2,7 +2,7 @@ package handler
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +10,7 @@ import (
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/model"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/repository"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 )
 
 type ContactHandler struct {
@@ -38,7 +39,7 @@ func (h *ContactHandler) Submit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Lấy ip
-	ip := util.ClientIP(r)
+	ip := platform.ClientIP(r)
 	token := strings.TrimSpace(in.Token)
 
 	if token == "" {
@@ -65,19 +66,19 @@ func (h *ContactHandler) Submit(w http.ResponseWriter, r *http.Request) {
 
 	// Gửi email
 	if h.Emailer != nil {
-		if err := h.Emailer.Send("[Contact] "+c.Name,
+		if err := h.Emailer.Send(r.Context(), "[Contact] "+c.Name,
 			"New contact message:\r\n"+
 				"Name: "+c.Name+"\r\n"+
 				"Email: "+c.Email+"\r\n"+
 				"Message:\r\n"+c.Message+"\r\n"+
 				"Time: "+c.CreatedAt.String()+"\r\n",
 		); err != nil {
-			log.Printf("[warn] send mail failed: %v", err)
+			slog.WarnContext(r.Context(), "send mail failed", "error", err)
 		} else {
-			log.Printf("[email] sent notification")
+			slog.InfoContext(r.Context(), "contact notification sent")
 		}
 	} else {
-		log.Println("[email] DISABLED (no emailer configured)")
+		slog.DebugContext(r.Context(), "emailer not configured, notification skipped")
 	}
 
 	util.JSON(w, http.StatusCreated, c)
@@ -12,7 +12,7 @@ func (h *ContactHandler) DevSendMail(w http.ResponseWriter, r *http.Request) {
 		util.Error(w, http.StatusBadRequest, "emailer disabled")
 		return
 	}
-	if err := h.Emailer.Send("[Contact][DEV] test", "This is a test email."); err != nil {
+	if err := h.Emailer.Send(r.Context(), "[Contact][DEV] test", "This is a test email."); err != nil {
 		util.Error(w, http.StatusBadGateway, "send failed: "+err.Error())
 		return
 	}
@@ -2,7 +2,7 @@ package grpcserver
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -10,6 +10,8 @@ import (
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/proto/contactpb"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/repository"
 	"github.com/RibunLoc/WebPersonalBackend/contact-service/util"
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -18,15 +20,17 @@ import (
 type ContactGRPC struct {
 	contactpb.UnimplementedContactServiceServer
 	Repo     *repository.ContactMongo
-	Verifier util.Verifier    // có thể là NoopVerifier khi disable
-	Emailer  util.EmailSender // có thể nil nếu chưa cấu hình SMTP
+	Verifier util.Verifier        // có thể là NoopVerifier khi disable
+	Emailer  util.EmailSender     // có thể nil nếu chưa cấu hình SMTP
+	IPs      *platform.IPResolver // remote_ip chỉ được tin khi peer là proxy tin cậy (gateway)
 }
 
-func Register(s *grpc.Server, repo *repository.ContactMongo, v util.Verifier, emailer util.EmailSender) {
+func Register(s *grpc.Server, repo *repository.ContactMongo, v util.Verifier, emailer util.EmailSender, ips *platform.IPResolver) {
 	contactpb.RegisterContactServiceServer(s, &ContactGRPC{
 		Repo:     repo,
 		Verifier: v,
 		Emailer:  emailer,
+		IPs:      ips,
 	})
 }
 
@@ -36,15 +40,19 @@ func (h *ContactGRPC) Submit(ctx context.Context, req *contactpb.ContactRequest)
 	email := strings.TrimSpace(req.Email)
 	message := strings.TrimSpace(req.Message)
 	if name == "" || email == "" || len(message) < 5 {
-		return nil, status.Error(codes.InvalidArgument, "name/email/message too short")
+		return nil, validationError(name, email, message)
 	}
 
 	// 2) Verify Turnstile nếu có Verifier
 	if h.Verifier != nil {
 		token := strings.TrimSpace(req.TurnstileToken)
-		remoteIP := strings.TrimSpace(req.RemoteIp)
+		remoteIP := h.IPs.ResolvePeer(ctx, req.RemoteIp)
 		if _, err := h.Verifier.Verify(ctx, token, remoteIP); err != nil {
-			return nil, status.Error(codes.InvalidArgument, "turnstile verification failed")
+			st, _ := status.New(codes.InvalidArgument, "turnstile verification failed").WithDetails(&errdetails.ErrorInfo{
+				Reason: "TURNSTILE_FAILED",
+				Domain: "contact.holoc.id.vn",
+			})
+			return nil, st.Err()
 		}
 	}
 
@@ -59,7 +67,8 @@ func (h *ContactGRPC) Submit(ctx context.Context, req *contactpb.ContactRequest)
 		// IP: req.RemoteIp,
 	}
 	if err := h.Repo.Create(ctx, c); err != nil {
-		return nil, status.Errorf(codes.Internal, "db error: %v", err)
+		slog.ErrorContext(ctx, "save contact failed", "error", err)
+		return nil, status.Error(codes.Internal, "failed to save contact")
 	}
 
 	// 4) Gửi email + log kết quả (đừng nuốt lỗi)
@@ -70,15 +79,34 @@ func (h *ContactGRPC) Submit(ctx context.Context, req *contactpb.ContactRequest)
 			"Message:\r\n" + c.Message + "\r\n" +
 			"Time: " + c.CreatedAt.String() + "\r\n"
 
-		if err := h.Emailer.Send("[Contact] "+c.Name, body); err != nil {
-			log.Printf("[warn] gRPC send mail failed: %v", err)
+		if err := h.Emailer.Send(ctx, "[Contact] "+c.Name, body); err != nil {
+			slog.WarnContext(ctx, "send mail failed", "error", err)
 			// Không fail request — tuỳ policy của bạn
 		} else {
-			log.Printf("[email] gRPC sent notification to configured recipient")
+			slog.InfoContext(ctx, "contact notification sent")
 		}
 	} else {
-		log.Println("[email] gRPC emailer is nil (disabled/not configured)")
+		slog.DebugContext(ctx, "emailer not configured, notification skipped")
 	}
 
 	return &contactpb.ContactResponse{Status: "ok"}, nil
 }
+
+// validationError trả InvalidArgument kèm BadRequest field violations
+func validationError(name, email, message string) error {
+	br := &errdetails.BadRequest{}
+	if name == "" {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: "name", Description: "is required"})
+	}
+	if email == "" {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: "email", Description: "is required"})
+	}
+	if len(message) < 5 {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: "message", Description: "must be at least 5 characters"})
+	}
+	st, err := status.New(codes.InvalidArgument, "name/email/message too short").WithDetails(br)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "name/email/message too short")
+	}
+	return st.Err()
+}
@@ -2,19 +2,28 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"mime"
 	"mime/quotedprintable"
 	"net"
 	"net/smtp"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EmailSender là interface để handler gọi  gửi email
 type EmailSender interface {
-	Send(subject, body string) error
+	Send(ctx context.Context, subject, body string) error
 }
 
 // Cấu hình SMTP
@@ -26,8 +35,23 @@ type SMTPConfig struct {
 	From     string   //địa chỉ gửi
 	To       []string // danh sách người nhận
 	Timeout  time.Duration
+
+	PasswordSource *platform.RotatingSecret // nếu có thì ưu tiên hơn Password (hỗ trợ xoay vòng)
 }
 
+var (
+	smtpSends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "smtp_send_total",
+		Help: "SMTP send attempts by outcome (success | error).",
+	}, []string{"outcome"})
+
+	smtpDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "smtp_send_duration_seconds",
+		Help:    "Time spent sending one email over SMTP.",
+		Buckets: []float64{.1, .25, .5, 1, 2, 4, 8, 15},
+	})
+)
+
 // Triển khai EmailSender với SMTP
 type SMTPSender struct {
 	cfg SMTPConfig
@@ -68,11 +92,44 @@ func htmlEscape(s string) string {
 	return s
 }
 
-func (s *SMTPSender) Send(subject, body string) error {
+// Send gửi mail và ghi metrics + span "smtp.send" (ctx chỉ dùng cho tracing;
+// timeout của kết nối SMTP theo SMTPConfig.Timeout)
+func (s *SMTPSender) Send(ctx context.Context, subject, body string) error {
+	_, span := platform.Tracer().Start(ctx, "smtp.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("smtp.host", s.cfg.Host),
+			attribute.Int("smtp.port", s.cfg.Port),
+			attribute.Int("smtp.recipients", len(s.cfg.To)),
+		))
+	defer span.End()
+
+	start := time.Now()
+	err := s.send(subject, body)
+	smtpDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		smtpSends.WithLabelValues("error").Inc()
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		smtpSends.WithLabelValues("success").Inc()
+	}
+	return err
+}
+
+// Ping chỉ mở kết nối TCP tới SMTP server (dùng cho /readyz, không gửi lệnh SMTP)
+func (s *SMTPSender) Ping(ctx context.Context) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	conn, err := (&net.Dialer{Timeout: s.cfg.Timeout}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (s *SMTPSender) send(subject, body string) error {
 	if len(s.cfg.To) == 0 {
 		return fmt.Errorf("no recipients")
 	}
-	addr := fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
 
 	// ----- Build MIME multipart/alternative (giống Mailtrap) -----
 	boundary := fmt.Sprintf("boundary-%d", time.Now().UnixNano())
@@ -219,5 +276,12 @@ func (s *SMTPSender) sendImplicitTLS(addr string, msg string) error {
 
 func (s *SMTPSender) smtpAuth() smtp.Auth {
 	// PLAIN auth: phổ biến với Gmail/App Password, Mailtrap...
-	return smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	return smtp.PlainAuth("", s.cfg.Username, s.password(), s.cfg.Host)
+}
+
+func (s *SMTPSender) password() string {
+	if s.cfg.PasswordSource != nil {
+		return s.cfg.PasswordSource.Value()
+	}
+	return s.cfg.Password
 }
@@ -3,6 +3,8 @@ package util
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
 )
 
 func JSON(w http.ResponseWriter, status int, v any) {
@@ -11,6 +13,11 @@ func JSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// Error trả {"error": msg, "request_id": ...}; request id lấy từ header do platform.RequestID gắn
 func Error(w http.ResponseWriter, status int, msg string) {
-	JSON(w, status, map[string]string{"error": msg})
+	body := map[string]string{"error": msg}
+	if id := w.Header().Get(platform.RequestIDHeader); id != "" {
+		body["request_id"] = id
+	}
+	JSON(w, status, body)
 }
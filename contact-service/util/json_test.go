@@ -0,0 +1,26 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+)
+
+func TestRequestIDInErrorBody(t *testing.T) {
+	h := platform.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, http.StatusConflict, "email already registered")
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(platform.RequestIDHeader, "gw-456")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	var body map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Header().Get(platform.RequestIDHeader) != "gw-456" || body["request_id"] != "gw-456" {
+		t.Errorf("header %q, body %v", w.Header().Get(platform.RequestIDHeader), body)
+	}
+}
@@ -0,0 +1,165 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// samples là số lần quan sát của một histogram (hoặc một series của HistogramVec)
+func samples(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := o.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// siteverify trả body cố định (hoặc lỗi mạng khi body rỗng)
+func siteverify(body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		if body == "" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})}
+}
+
+func TestTurnstileMetrics(t *testing.T) {
+	tests := []struct {
+		outcome string
+		v       Verifier
+		token   string
+		timed   bool // có đo latency (không gọi Cloudflare khi thiếu token)
+	}{
+		{"success", TurnstileVerifier{Secret: "s", Client: siteverify(`{"success":true}`)}, "tok", true},
+		{"rejected", TurnstileVerifier{Secret: "s", Client: siteverify(`{"success":false,"error-codes":["invalid-input-response"]}`)}, "tok", true},
+		{"error", TurnstileVerifier{Secret: "s", Client: siteverify("")}, "tok", true},
+		{"missing_token", TurnstileVerifier{Secret: "s", Client: siteverify(`{"success":true}`)}, "", false},
+		{"skipped", NoopVerifier{}, "", false},
+	}
+	for _, tt := range tests {
+		before := testutil.ToFloat64(turnstileVerifications.WithLabelValues(tt.outcome))
+		timedBefore := samples(t, turnstileDuration)
+		_, _ = tt.v.Verify(context.Background(), tt.token, "203.0.113.7")
+		if d := testutil.ToFloat64(turnstileVerifications.WithLabelValues(tt.outcome)) - before; d != 1 {
+			t.Errorf("%s: counter += %v, want 1", tt.outcome, d)
+		}
+		if d := samples(t, turnstileDuration) - timedBefore; (d == 1) != tt.timed {
+			t.Errorf("%s: latency samples += %d, want timed=%v", tt.outcome, d, tt.timed)
+		}
+	}
+}
+
+func TestSMTPMetricsOnError(t *testing.T) {
+	before := testutil.ToFloat64(smtpSends.WithLabelValues("error"))
+	timedBefore := samples(t, smtpDuration)
+	s := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@holoc.id.vn"}) // không có người nhận
+	if err := s.Send(context.Background(), "subject", "body"); err == nil {
+		t.Fatal("Send without recipients succeeded")
+	}
+	if d := testutil.ToFloat64(smtpSends.WithLabelValues("error")) - before; d != 1 {
+		t.Errorf("smtp_send_total{outcome=error} += %v, want 1", d)
+	}
+	if d := samples(t, smtpDuration) - timedBefore; d != 1 {
+		t.Errorf("smtp duration samples += %d, want 1", d)
+	}
+}
+
+// recordSpans đặt TracerProvider ghi span vào bộ nhớ (khôi phục provider cũ khi test xong)
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	return sr
+}
+
+func spanNamed(sr *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, s := range sr.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestTurnstileSpan(t *testing.T) {
+	for _, tt := range []struct {
+		body, outcome string
+		failed        bool
+	}{
+		{`{"success":true}`, "success", false},
+		{`{"success":false}`, "rejected", true},
+	} {
+		sr := recordSpans(t)
+		var traceparent string
+		client := &http.Client{Transport: platform.TracingTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			traceparent = r.Header.Get("Traceparent")
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(tt.body)), Header: http.Header{}}, nil
+		}))}
+		_, _ = TurnstileVerifier{Secret: "s", Client: client}.Verify(context.Background(), "tok", "203.0.113.7")
+
+		span := spanNamed(sr, "turnstile.verify")
+		if span == nil {
+			t.Fatalf("%s: no turnstile.verify span", tt.outcome)
+		}
+		var outcome string
+		for _, kv := range span.Attributes() {
+			if kv.Key == "turnstile.outcome" {
+				outcome = kv.Value.AsString()
+			}
+		}
+		if outcome != tt.outcome || (span.Status().Code == codes.Error) != tt.failed {
+			t.Errorf("%s: span outcome %q status %v", tt.outcome, outcome, span.Status())
+		}
+		// lời gọi siteverify là span con, mang traceparent cùng trace
+		if !strings.Contains(traceparent, span.SpanContext().TraceID().String()) {
+			t.Errorf("%s: siteverify traceparent = %q, want trace %s", tt.outcome, traceparent, span.SpanContext().TraceID())
+		}
+	}
+}
+
+func TestSMTPSpan(t *testing.T) {
+	sr := recordSpans(t)
+	s := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@holoc.id.vn"})
+	_ = s.Send(context.Background(), "subject", "body")
+
+	span := spanNamed(sr, "smtp.send")
+	if span == nil {
+		t.Fatal("no smtp.send span")
+	}
+	if span.Status().Code != codes.Error || !strings.Contains(span.Status().Description, "no recipients") {
+		t.Errorf("status = %v", span.Status())
+	}
+	for _, kv := range span.Attributes() {
+		if strings.Contains(kv.Value.Emit(), "subject") || strings.Contains(kv.Value.Emit(), "body") {
+			t.Errorf("span leaks message content: %s", kv.Key)
+		}
+	}
+}
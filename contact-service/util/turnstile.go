@@ -8,6 +8,12 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type Verifier interface {
@@ -23,27 +29,75 @@ type TurnstileResp struct {
 	CData       string   `json:"cdata"`
 }
 
+// outcome: success | rejected (Cloudflare trả success=false) | missing_token | error | skipped (Noop)
+var (
+	turnstileVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "turnstile_verify_total",
+		Help: "Turnstile verifications by outcome.",
+	}, []string{"outcome"})
+
+	turnstileDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "turnstile_verify_duration_seconds",
+		Help:    "Latency of Cloudflare siteverify calls.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2, 4},
+	})
+)
+
 /********** NOOP (bypass) **********/
 type NoopVerifier struct{}
 
 func (NoopVerifier) Verify(ctx context.Context, token, remoteIP string) (*TurnstileResp, error) {
+	turnstileVerifications.WithLabelValues("skipped").Inc()
 	return &TurnstileResp{Success: true}, nil
 }
 
 /********** Cloudflare **********/
 type TurnstileVerifier struct {
-	Secret string
-	Client *http.Client
+	Secret       string
+	SecretSource *platform.RotatingSecret // nếu có thì ưu tiên hơn Secret (hỗ trợ xoay vòng)
+	Client       *http.Client
 }
 
 const siteVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
 
+var errMissingToken = errors.New("missing token")
+
 func (v TurnstileVerifier) Verify(ctx context.Context, token, remoteIP string) (*TurnstileResp, error) {
+	ctx, span := platform.Tracer().Start(ctx, "turnstile.verify")
+	defer span.End()
+
+	start := time.Now()
+	out, err := v.verify(ctx, token, remoteIP)
+	outcome := "success"
+	switch {
+	case errors.Is(err, errMissingToken):
+		outcome = "missing_token"
+	case out != nil && !out.Success:
+		outcome = "rejected"
+	case err != nil:
+		outcome = "error"
+	}
+	if outcome != "missing_token" {
+		turnstileDuration.Observe(time.Since(start).Seconds())
+	}
+	turnstileVerifications.WithLabelValues(outcome).Inc()
+	span.SetAttributes(attribute.String("turnstile.outcome", outcome))
+	if outcome != "success" {
+		span.SetStatus(codes.Error, outcome)
+	}
+	return out, err
+}
+
+func (v TurnstileVerifier) verify(ctx context.Context, token, remoteIP string) (*TurnstileResp, error) {
 	if token == "" {
-		return nil, errors.New("missing token")
+		return nil, errMissingToken
+	}
+	secret := v.Secret
+	if v.SecretSource != nil {
+		secret = v.SecretSource.Value()
 	}
 	form := url.Values{}
-	form.Set("secret", v.Secret)
+	form.Set("secret", secret)
 	form.Set("response", token)
 	if remoteIP != "" {
 		form.Set("remoteip", remoteIP)
@@ -53,7 +107,7 @@ func (v TurnstileVerifier) Verify(ctx context.Context, token, remoteIP string) (
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	c := v.Client
 	if c == nil {
-		c = &http.Client{Timeout: 4 * time.Second}
+		c = &http.Client{Timeout: 4 * time.Second, Transport: platform.TracingTransport(nil)}
 	}
 	resp, err := c.Do(req)
 	if err != nil {
@@ -78,3 +132,10 @@ func NewVerifier(secret string, disabled bool) Verifier {
 	}
 	return TurnstileVerifier{Secret: secret}
 }
+
+func NewRotatingVerifier(src *platform.RotatingSecret, disabled bool) Verifier {
+	if disabled {
+		return NoopVerifier{}
+	}
+	return TurnstileVerifier{SecretSource: src}
+}
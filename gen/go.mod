@@ -3,6 +3,8 @@ module github.com/RibunLoc/WebPersonalBackend/gen
 go 1.23.4
 
 require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.1
+	google.golang.org/genproto/googleapis/api v0.0.0-20250707201910-8d1bb00bc6a7
 	google.golang.org/grpc v1.75.0
 	google.golang.org/protobuf v1.36.7
 )
@@ -0,0 +1,206 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/peer"
+)
+
+// IPResolver xác định IP client thật. Header forward (X-Forwarded-For, CF-Connecting-IP,
+// X-Real-IP) chỉ được tin khi kết nối tới từ proxy tin cậy; X-Forwarded-For được đọc
+// từ phải sang trái và dừng ở hop đầu tiên không phải proxy tin cậy.
+// Resolver rỗng (không cấu hình proxy) luôn dùng địa chỉ kết nối.
+type IPResolver struct {
+	trusted []netip.Prefix
+
+	warnOnce sync.Once // cảnh báo một lần khi remote_ip bị bỏ qua vì peer không tin cậy
+}
+
+// DefaultTrustedProxies dùng khi TRUSTED_PROXIES không được đặt: chỉ loopback (gateway
+// chạy cùng máy). Sau docker/k8s cần khai báo IP/CIDR của gateway, xem UPGRADING.md.
+const DefaultTrustedProxies = "127.0.0.1,::1"
+
+// NewIPResolver nhận danh sách CIDR hoặc IP đơn ("10.0.0.0/8", "127.0.0.1", "::1")
+func NewIPResolver(cidrs []string) (*IPResolver, error) {
+	res := &IPResolver{}
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			addr, err := netip.ParseAddr(c)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", c, err)
+			}
+			res.trusted = append(res.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(c)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", c, err)
+		}
+		res.trusted = append(res.trusted, p.Masked())
+	}
+	return res, nil
+}
+
+// LoadIPResolver dựng resolver từ danh sách CIDR (phân tách bằng dấu phẩy, vd TRUSTED_PROXIES)
+// và các file (phân tách bằng dấu phẩy, vd TRUSTED_PROXIES_FILE=cloudflare-ips.txt):
+// mỗi dòng một CIDR, dòng trống và phần sau # được bỏ qua.
+func LoadIPResolver(list, files string) (*IPResolver, error) {
+	cidrs := strings.Split(list, ",")
+	for _, path := range strings.Split(files, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		lines, err := readCIDRFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, lines...)
+	}
+	return NewIPResolver(cidrs)
+}
+
+func readCIDRFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies file: %w", err)
+	}
+	defer f.Close()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("trusted proxies file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// Trusted: addr có nằm trong danh sách proxy tin cậy không
+func (res *IPResolver) Trusted(addr netip.Addr) bool {
+	if res == nil || !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range res.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve trả IP client của request
+func (res *IPResolver) Resolve(r *http.Request) string {
+	remote := parseHostAddr(r.RemoteAddr)
+	if !res.Trusted(remote) {
+		return addrString(remote, r.RemoteAddr)
+	}
+
+	// X-Forwarded-For: các proxy nối thêm vào cuối, nên đi từ phải sang trái
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseHostAddr(strings.TrimSpace(hops[i]))
+		if !hop.IsValid() {
+			break // giá trị rác: dừng ở hop hợp lệ gần nhất
+		}
+		client = hop
+		if !res.Trusted(hop) {
+			return client.String()
+		}
+	}
+	if client != remote {
+		return client.String() // mọi hop đều là proxy tin cậy
+	}
+
+	// không có X-Forwarded-For: header một giá trị do proxy đặt
+	for _, h := range []string{"CF-Connecting-IP", "X-Real-IP"} {
+		if a := parseHostAddr(strings.TrimSpace(r.Header.Get(h))); a.IsValid() {
+			return a.String()
+		}
+	}
+	return remote.String()
+}
+
+// ResolvePeer trả IP client của lời gọi gRPC: claimed (vd. field remote_ip do gateway gán)
+// chỉ được dùng khi peer là proxy tin cậy, ngược lại dùng IP của peer.
+func (res *IPResolver) ResolvePeer(ctx context.Context, claimed string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := parseHostAddr(p.Addr.String())
+	if res.Trusted(addr) {
+		if c := parseHostAddr(strings.TrimSpace(claimed)); c.IsValid() {
+			return c.String()
+		}
+	} else if claimed != "" && res != nil {
+		res.warnOnce.Do(func() {
+			slog.WarnContext(ctx, "client IP from untrusted peer ignored, add the gateway to TRUSTED_PROXIES",
+				"peer", addr.String())
+		})
+	}
+	return addrString(addr, p.Addr.String())
+}
+
+type clientIPKey struct{}
+
+// Middleware resolve IP một lần cho mỗi request; handler phía sau đọc bằng ClientIP(r)
+func (res *IPResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey{}, res.Resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientIP trả IP client đã được IPResolver.Middleware resolve;
+// nếu request không đi qua middleware thì dùng địa chỉ kết nối (không tin header nào)
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return (*IPResolver)(nil).Resolve(r)
+}
+
+// parseHostAddr đọc "ip", "ip:port" hoặc "[ipv6]:port"
+func parseHostAddr(s string) netip.Addr {
+	if s == "" {
+		return netip.Addr{}
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func addrString(a netip.Addr, raw string) string {
+	if a.IsValid() {
+		return a.String()
+	}
+	return raw
+}
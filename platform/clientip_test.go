@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/peer"
+)
+
+func TestIPResolverResolve(t *testing.T) {
+	res, err := NewIPResolver([]string{"10.0.0.0/8", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		header map[string]string
+		want   string
+	}{
+		{name: "untrusted peer ignores headers", remote: "203.0.113.7:1234", xff: []string{"1.2.3.4"}, want: "203.0.113.7"},
+		{name: "trusted peer uses xff", remote: "10.1.2.3:80", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "right to left stops at first untrusted hop", remote: "10.1.2.3:80", xff: []string{"6.6.6.6, 198.51.100.1, 10.9.9.9"}, want: "198.51.100.1"},
+		{name: "multiple xff headers", remote: "10.1.2.3:80", xff: []string{"6.6.6.6", "198.51.100.2"}, want: "198.51.100.2"},
+		{name: "garbage hop stops at last valid", remote: "10.1.2.3:80", xff: []string{"garbage, 10.2.2.2"}, want: "10.2.2.2"},
+		{name: "cf-connecting-ip without xff", remote: "127.0.0.1:80", header: map[string]string{"CF-Connecting-IP": "192.0.2.44"}, want: "192.0.2.44"},
+		{name: "ipv4-mapped remote", remote: "[::ffff:10.0.0.1]:80", xff: []string{"192.0.2.9"}, want: "192.0.2.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if got := res.Resolve(r); got != tt.want {
+				t.Errorf("Resolve = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPResolverResolvePeer(t *testing.T) {
+	res, err := LoadIPResolver(DefaultTrustedProxies, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withPeer := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+	}
+	if got := res.ResolvePeer(withPeer("127.0.0.1"), "198.51.100.7"); got != "198.51.100.7" {
+		t.Errorf("trusted peer: got %q", got)
+	}
+	if got := res.ResolvePeer(withPeer("172.28.0.10"), "198.51.100.7"); got != "172.28.0.10" {
+		t.Errorf("untrusted peer: got %q, want peer address", got)
+	}
+	if got := res.ResolvePeer(withPeer("127.0.0.1"), "not-an-ip"); got != "127.0.0.1" {
+		t.Errorf("invalid claim: got %q", got)
+	}
+}
+
+func TestClientIPWithoutMiddleware(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:999"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	if got := ClientIP(r); got != "192.0.2.1" {
+		t.Errorf("ClientIP = %q", got)
+	}
+}
+
+func TestNewIPResolverInvalid(t *testing.T) {
+	if _, err := NewIPResolver([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("want error for bad CIDR")
+	}
+	if _, err := NewIPResolver([]string{"nope"}); err == nil {
+		t.Error("want error for bad IP")
+	}
+}
@@ -0,0 +1,113 @@
+// vault tạo / cập nhật file vault cho SECRETS_VAULT_FILE (xem platform/secret.go).
+// Passphrase lấy từ SECRETS_VAULT_KEY hoặc SECRETS_VAULT_KEY_FILE giống như service.
+//
+//	SECRETS_VAULT_KEY=... go run ./cmd/vault -out secrets.vault < secrets.env
+//	SECRETS_VAULT_KEY=... go run ./cmd/vault -out secrets.vault -update -in rotated.env
+//	SECRETS_VAULT_KEY=... go run ./cmd/vault -list secrets.vault
+//
+// File đầu vào theo định dạng .env (NAME=value). -update giữ các secret cũ trong vault và
+// ghi đè những tên có trong đầu vào; không có -update thì vault chỉ chứa nội dung đầu vào.
+// -list chỉ in tên secret, không in giá trị.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/RibunLoc/WebPersonalBackend/platform"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	out := flag.String("out", "", "file vault cần ghi")
+	in := flag.String("in", "-", "file .env chứa secret (- = stdin)")
+	update := flag.Bool("update", false, "giữ secret cũ trong -out, chỉ ghi đè tên có trong đầu vào")
+	list := flag.String("list", "", "in tên secret trong file vault")
+	flag.Parse()
+
+	key, err := (platform.ChainProvider{platform.FileProvider{}, platform.EnvProvider{}}).Get("SECRETS_VAULT_KEY")
+	if err != nil {
+		log.Fatal("SECRETS_VAULT_KEY or SECRETS_VAULT_KEY_FILE is required")
+	}
+
+	if *list != "" {
+		secrets, err := openFile(*list, key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range sortedKeys(secrets) {
+			fmt.Println(name)
+		}
+		return
+	}
+	if *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	secrets := map[string]string{}
+	if *update {
+		if secrets, err = openFile(*out, key); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		r = f
+	}
+	input, err := godotenv.Parse(r)
+	if err != nil {
+		log.Fatalf("parse %s: %v", *in, err)
+	}
+	for k, v := range input {
+		secrets[k] = v
+	}
+
+	data, err := platform.SealVault(key, secrets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// ghi file tạm rồi rename để service đang đọc không thấy file ghi dở
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".vault-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		log.Fatal(err)
+	}
+	if err := tmp.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Rename(tmp.Name(), *out); err != nil {
+		_ = os.Remove(tmp.Name())
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %d secrets\n", *out, len(secrets))
+}
+
+func openFile(path, key string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return platform.OpenVault(key, data)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,5 @@
+// Package platform chứa phần hạ tầng dùng chung cho api-gateway, auth-service và
+// contact-service (IP client, request id, metrics, tracing, lifecycle, logger, health,
+// secret, TLS) để mỗi bản sửa chỉ cần làm ở một chỗ. Code riêng của từng service vẫn
+// nằm trong util của service đó.
+package platform
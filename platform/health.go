@@ -0,0 +1,143 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Trạng thái trong báo cáo health
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded" // chỉ check Optional lỗi, vẫn nhận traffic
+	HealthFail     = "fail"
+)
+
+// HealthCheck kiểm tra một dependency (Mongo, Redis, SMTP, gRPC backend, ...)
+type HealthCheck struct {
+	Name     string
+	Optional bool // lỗi chỉ làm báo cáo "degraded", không làm /readyz trả 503
+	Check    func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Optional  bool    `json:"optional,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+type HealthReport struct {
+	Service string                 `json:"service"`
+	Status  string                 `json:"status"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Ready: true nếu mọi check bắt buộc đều ok
+func (r HealthReport) Ready() bool { return r.Status != HealthFail }
+
+// Health gom các check của service; dùng cho /livez, /readyz và grpc.health.v1
+type Health struct {
+	Service string
+	Timeout time.Duration // timeout cho mỗi check (mặc định 2s)
+	checks  []HealthCheck
+}
+
+func NewHealth(service string, checks ...HealthCheck) *Health {
+	return &Health{Service: service, Timeout: 2 * time.Second, checks: checks}
+}
+
+func (h *Health) Add(c HealthCheck) { h.checks = append(h.checks, c) }
+
+// Run chạy song song mọi check, mỗi check có timeout riêng
+func (h *Health) Run(ctx context.Context) HealthReport {
+	rep := HealthReport{Service: h.Service, Status: HealthOK, Checks: make(map[string]CheckResult, len(h.checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c HealthCheck) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, h.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.Check(cctx)
+			res := CheckResult{Status: HealthOK, Optional: c.Optional, LatencyMS: msSince(start)}
+			if err != nil {
+				res.Status, res.Error = HealthFail, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			rep.Checks[c.Name] = res
+			switch {
+			case err == nil:
+			case !c.Optional:
+				rep.Status = HealthFail
+			case rep.Status == HealthOK:
+				rep.Status = HealthDegraded
+			}
+		}(c)
+	}
+	wg.Wait()
+	return rep
+}
+
+// Livez: process còn sống (không kiểm tra dependency để tránh restart dây chuyền)
+func (h *Health) Livez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, HealthReport{Service: h.Service, Status: HealthOK})
+}
+
+// Readyz chạy mọi check; 503 nếu một check bắt buộc lỗi
+func (h *Health) Readyz(w http.ResponseWriter, r *http.Request) {
+	rep := h.Run(r.Context())
+	status := http.StatusOK
+	if !rep.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, rep)
+}
+
+// WatchGRPC chạy check định kỳ và cập nhật trạng thái grpc.health.v1 cho server tổng ("")
+// và các service truyền vào. Khi ctx kết thúc thì chuyển hết sang NOT_SERVING.
+func (h *Health) WatchGRPC(ctx context.Context, srv *health.Server, interval time.Duration, services ...string) {
+	update := func() {
+		st := healthpb.HealthCheckResponse_SERVING
+		if !h.Run(ctx).Ready() {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		srv.SetServingStatus("", st)
+		for _, s := range services {
+			srv.SetServingStatus(s, st)
+		}
+	}
+
+	update()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			srv.Shutdown()
+			return
+		case <-t.C:
+			update()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
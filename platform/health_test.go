@@ -0,0 +1,113 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func check(name string, optional bool, err error) HealthCheck {
+	return HealthCheck{Name: name, Optional: optional, Check: func(context.Context) error { return err }}
+}
+
+func TestHealthRun(t *testing.T) {
+	down := errors.New("down")
+	tests := []struct {
+		name   string
+		checks []HealthCheck
+		want   string
+	}{
+		{"all ok", []HealthCheck{check("mongo", false, nil), check("smtp", true, nil)}, HealthOK},
+		{"optional failing", []HealthCheck{check("mongo", false, nil), check("smtp", true, down)}, HealthDegraded},
+		{"required failing", []HealthCheck{check("mongo", false, down), check("smtp", true, down)}, HealthFail},
+		{"no checks", nil, HealthOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := NewHealth("svc", tt.checks...).Run(context.Background())
+			if rep.Status != tt.want {
+				t.Errorf("status = %s, want %s (%+v)", rep.Status, tt.want, rep.Checks)
+			}
+			if len(rep.Checks) != len(tt.checks) {
+				t.Errorf("checks = %v", rep.Checks)
+			}
+		})
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	h := NewHealth("svc", HealthCheck{Name: "slow", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	h.Timeout = 20 * time.Millisecond
+
+	start := time.Now()
+	rep := h.Run(context.Background())
+	if time.Since(start) > time.Second {
+		t.Fatal("check not bounded by Timeout")
+	}
+	if rep.Status != HealthFail || rep.Checks["slow"].Error == "" {
+		t.Errorf("report = %+v", rep)
+	}
+}
+
+func TestHealthHandlers(t *testing.T) {
+	h := NewHealth("contact-service", check("mongo", false, errors.New("no primary")), check("smtp", true, nil))
+
+	w := httptest.NewRecorder()
+	h.Livez(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("livez = %d, must not depend on checks", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var rep HealthReport
+	_ = json.Unmarshal(w.Body.Bytes(), &rep)
+	if w.Code != http.StatusServiceUnavailable || rep.Checks["mongo"].Error != "no primary" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("readyz = %d %+v", w.Code, rep)
+	}
+}
+
+func TestHealthWatchGRPC(t *testing.T) {
+	h := NewHealth("svc", check("mongo", false, nil))
+	srv := health.NewServer()
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return resp.GetStatus()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { h.WatchGRPC(ctx, srv, time.Hour, "userpb.UserService"); close(done) }()
+	waitFor(t, func() bool { return status("userpb.UserService") == healthpb.HealthCheckResponse_SERVING })
+
+	cancel()
+	<-done
+	// dừng: báo NOT_SERVING để client chuyển sang instance khác
+	if s := status(""); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("after shutdown status = %s", s)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Lifecycle chạy các server (HTTP, gRPC) + goroutine nền và dừng chúng có thứ tự:
+//  1. nhận SIGINT/SIGTERM (hoặc một server lỗi) -> huỷ context của goroutine nền và chờ chúng thoát
+//  2. drain mọi server song song trong ShutdownTimeout (gRPC: GracefulStop, quá hạn thì Stop)
+//  3. gọi các hàm OnStop theo thứ tự ngược lúc đăng ký (đóng Mongo, Redis, ...)
+type Lifecycle struct {
+	ShutdownTimeout time.Duration
+
+	servers []lifecycleServer
+	tasks   []func(ctx context.Context)
+	stops   []namedStop
+}
+
+type lifecycleServer struct {
+	name  string
+	addr  string
+	serve func() error
+	stop  func(ctx context.Context) error
+}
+
+type namedStop struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func NewLifecycle(shutdownTimeout time.Duration) *Lifecycle {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 15 * time.Second
+	}
+	return &Lifecycle{ShutdownTimeout: shutdownTimeout}
+}
+
+// AddHTTP đăng ký HTTP server (ListenAndServe theo srv.Addr)
+func (l *Lifecycle) AddHTTP(name string, srv *http.Server) {
+	l.addHTTP(name, srv, srv.ListenAndServe)
+}
+
+// AddHTTPS đăng ký HTTPS server; cert lấy từ srv.TLSConfig (GetCertificate), HTTP/2 bật sẵn
+func (l *Lifecycle) AddHTTPS(name string, srv *http.Server) {
+	l.addHTTP(name, srv, func() error { return srv.ListenAndServeTLS("", "") })
+}
+
+func (l *Lifecycle) addHTTP(name string, srv *http.Server, listen func() error) {
+	l.servers = append(l.servers, lifecycleServer{
+		name: name,
+		addr: srv.Addr,
+		serve: func() error {
+			if err := listen(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		stop: srv.Shutdown,
+	})
+}
+
+// AddGRPC đăng ký gRPC server trên listener đã mở sẵn (lỗi port được báo trước khi Run)
+func (l *Lifecycle) AddGRPC(name string, srv *grpc.Server, lis net.Listener) {
+	l.servers = append(l.servers, lifecycleServer{
+		name:  name,
+		addr:  lis.Addr().String(),
+		serve: func() error { return srv.Serve(lis) },
+		stop: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				srv.Stop() // cắt các RPC còn lại khi hết hạn drain
+				<-done
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Go chạy fn trong goroutine nền; ctx bị huỷ khi bắt đầu shutdown
+func (l *Lifecycle) Go(fn func(ctx context.Context)) {
+	l.tasks = append(l.tasks, fn)
+}
+
+// OnStop đăng ký hàm dọn dẹp chạy sau khi mọi server đã dừng
+func (l *Lifecycle) OnStop(name string, fn func(ctx context.Context) error) {
+	l.stops = append(l.stops, namedStop{name: name, fn: fn})
+}
+
+// Run chặn tới khi có tín hiệu dừng, ctx bị huỷ hoặc một server lỗi.
+// Trả lỗi fatal đầu tiên; nếu dừng bình thường thì trả lỗi của quá trình shutdown (nếu có).
+func (l *Lifecycle) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, len(l.servers))
+	for _, s := range l.servers {
+		slog.Info("server started", "server", s.name, "addr", s.addr)
+		go func(s lifecycleServer) {
+			if err := s.serve(); err != nil {
+				errCh <- fmt.Errorf("%s server: %w", s.name, err)
+			}
+		}(s)
+	}
+
+	var tasks sync.WaitGroup
+	for _, fn := range l.tasks {
+		tasks.Add(1)
+		go func(fn func(context.Context)) {
+			defer tasks.Done()
+			fn(ctx)
+		}(fn)
+	}
+
+	var fatal error
+	select {
+	case fatal = <-errCh:
+		slog.Error("server failed, shutting down", "error", fatal)
+	case <-ctx.Done():
+		slog.Info("shutdown signal received", "timeout", l.ShutdownTimeout.String())
+	}
+	cancel()
+
+	// context gốc đã bị huỷ nên drain dùng context mới có deadline riêng
+	sctx, scancel := context.WithTimeout(context.Background(), l.ShutdownTimeout)
+	defer scancel()
+
+	// chờ goroutine nền thoát trước (vd. health chuyển NOT_SERVING rồi mới drain)
+	tasksDone := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(tasksDone)
+	}()
+	select {
+	case <-tasksDone:
+	case <-sctx.Done():
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	record := func(name string, err error) {
+		if err == nil {
+			return
+		}
+		slog.Error("shutdown step failed", "step", name, "error", err)
+		mu.Lock()
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		mu.Unlock()
+	}
+	for _, s := range l.servers {
+		wg.Add(1)
+		go func(s lifecycleServer) {
+			defer wg.Done()
+			record(s.name, s.stop(sctx))
+		}(s)
+	}
+	wg.Wait()
+
+	for i := len(l.stops) - 1; i >= 0; i-- {
+		record(l.stops[i].name, l.stops[i].fn(sctx))
+	}
+
+	if fatal != nil {
+		return fatal
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	slog.Info("shutdown complete")
+	return nil
+}
@@ -0,0 +1,119 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLifecycleDrainsInFlightRequests(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	})}
+
+	l := NewLifecycle(5 * time.Second)
+	l.servers = append(l.servers, lifecycleServer{
+		name:  "http",
+		addr:  lis.Addr().String(),
+		serve: func() error { return ignoreClosed(srv.Serve(lis)) },
+		stop:  srv.Shutdown,
+	})
+	var order []string
+	var mu sync.Mutex
+	for _, name := range []string{"mongo", "redis"} {
+		l.OnStop(name, func(context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		})
+	}
+	taskStopped := make(chan struct{})
+	l.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(taskStopped)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- l.Run(ctx) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + lis.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request = %q, want drained response", got)
+	}
+	select {
+	case <-taskStopped:
+	default:
+		t.Error("background task context not cancelled")
+	}
+	if strings.Join(order, ",") != "redis,mongo" {
+		t.Errorf("OnStop order = %v, want reverse registration order", order)
+	}
+}
+
+func TestLifecycleServerErrorIsFatal(t *testing.T) {
+	l := NewLifecycle(time.Second)
+	boom := errors.New("bind: address in use")
+	l.servers = append(l.servers, lifecycleServer{
+		name:  "grpc",
+		serve: func() error { return boom },
+		stop:  func(context.Context) error { return nil },
+	})
+	stopped := false
+	l.OnStop("db", func(context.Context) error { stopped = true; return nil })
+
+	err := l.Run(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("Run = %v, want server error", err)
+	}
+	if !stopped {
+		t.Error("OnStop not called after fatal server error")
+	}
+}
+
+func TestLifecycleShutdownErrors(t *testing.T) {
+	l := NewLifecycle(time.Second)
+	l.OnStop("flush", func(context.Context) error { return errors.New("flush failed") })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Run(ctx); err == nil || !strings.Contains(err.Error(), "flush failed") {
+		t.Fatalf("Run = %v, want shutdown error", err)
+	}
+}
+
+func ignoreClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewLogger tạo slog.Logger theo env:
+//   - LOG_LEVEL:  debug | info (mặc định) | warn | error
+//   - LOG_FORMAT: json (mặc định) | text
+//
+// Mọi bản ghi có thêm service, request_id (nếu context có) và được che các
+// field nhạy cảm (password, token, secret, ...; email bị mask một phần).
+func NewLogger(service string) *slog.Logger {
+	return newLogger(os.Stdout, service, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+}
+
+func newLogger(w io.Writer, service, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: parseLevel(level), ReplaceAttr: redactAttr}
+	var h slog.Handler
+	if strings.EqualFold(format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h}).With(slog.String("service", service))
+}
+
+func parseLevel(v string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler thêm request_id, trace_id, span_id từ context vào mỗi bản ghi (dùng slog.*Context)
+type contextHandler struct{ slog.Handler }
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+/********** Redaction **********/
+
+const redacted = "[REDACTED]"
+
+// key chứa một trong các từ này bị che toàn bộ
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey"}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	if strings.Contains(key, "email") {
+		switch a.Value.Kind() {
+		case slog.KindString:
+			return slog.String(a.Key, MaskEmail(a.Value.String()))
+		case slog.KindAny:
+			if list, ok := a.Value.Any().([]string); ok {
+				masked := make([]string, len(list))
+				for i, e := range list {
+					masked[i] = MaskEmail(e)
+				}
+				return slog.Any(a.Key, masked)
+			}
+		}
+	}
+	return a
+}
+
+// MaskEmail: "john.doe@example.com" -> "j***@example.com"
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redacted
+	}
+	return local[:1] + "***@" + domain
+}
+
+/********** HTTP **********/
+
+// RequestLogger ghi một dòng log cho mỗi request HTTP (thay chi middleware.Logger).
+// Đặt sau RequestID để có request_id. Không log query string (có thể chứa token).
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+			slog.LogAttrs(r.Context(), level, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Float64("latency_ms", msSince(start)),
+				slog.String("client_ip", ClientIP(r)),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// msSince: thời gian đã trôi qua tính bằng ms (số thực, dễ query hơn nanosecond)
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
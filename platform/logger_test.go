@@ -0,0 +1,141 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// useLogger đặt logger ghi vào buffer làm slog mặc định trong thời gian test
+func useLogger(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(newLogger(&buf, "test", "debug", "json"))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	log := newLogger(&buf, "auth-service", "info", "json")
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	log.InfoContext(ctx, "login",
+		"password", "hunter2",
+		"access_token", "eyJ...",
+		"Authorization", "Bearer x",
+		"email", "john.doe@example.com",
+		"emails", []string{"a@b.vn", "broken"},
+		slog.Group("user", "password", "p", "name", "Loc"),
+		"status", 200,
+	)
+
+	m := decodeLines(t, &buf)[0]
+	want := map[string]any{
+		"service":       "auth-service",
+		"request_id":    "req-1",
+		"password":      redacted,
+		"access_token":  redacted,
+		"Authorization": redacted,
+		"email":         "j***@example.com",
+		"status":        float64(200),
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s = %v, want %v", k, m[k], v)
+		}
+	}
+	if e, _ := m["emails"].([]any); len(e) != 2 || e[0] != "a***@b.vn" || e[1] != redacted {
+		t.Errorf("emails = %v", m["emails"])
+	}
+	if u, _ := m["user"].(map[string]any); u["password"] != redacted || u["name"] != "Loc" {
+		t.Errorf("group user = %v", m["user"])
+	}
+}
+
+func TestLoggerLevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := newLogger(&buf, "svc", "warn", "text")
+	log.Info("dropped")
+	log.Warn("kept", "token", "abc")
+	out := buf.String()
+	if strings.Contains(out, "dropped") || !strings.Contains(out, "msg=kept") || !strings.Contains(out, "token="+redacted) {
+		t.Errorf("text output = %q", out)
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	buf := useLogger(t)
+	h := RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("down"))
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/reset?token=secret", nil)
+	r.RemoteAddr = "203.0.113.9:1234"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	m := decodeLines(t, buf)[0]
+	if m["level"] != "ERROR" || m["status"] != float64(503) || m["bytes"] != float64(4) || m["path"] != "/reset" {
+		t.Errorf("log = %v", m)
+	}
+	if m["client_ip"] != "203.0.113.9" {
+		t.Errorf("client_ip = %v", m["client_ip"])
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Error("query string logged")
+	}
+}
+
+func TestUnaryLoggingInterceptor(t *testing.T) {
+	tests := []struct {
+		err   error
+		code  string
+		level string
+	}{
+		{nil, "OK", "INFO"},
+		{status.Error(codes.AlreadyExists, "email already registered"), "AlreadyExists", "WARN"},
+		{status.Error(codes.Internal, "db down"), "Internal", "ERROR"},
+		{errors.New("plain"), "Unknown", "ERROR"},
+	}
+	for _, tt := range tests {
+		buf := useLogger(t)
+		info := &grpc.UnaryServerInfo{FullMethod: "/userpb.UserService/Login"}
+		_, err := UnaryLoggingInterceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+			return nil, tt.err
+		})
+		if err != tt.err {
+			t.Errorf("error not returned unchanged: %v", err)
+		}
+		m := decodeLines(t, buf)[0]
+		if m["code"] != tt.code || m["level"] != tt.level || m["method"] != info.FullMethod {
+			t.Errorf("log = %v, want code %s level %s", m, tt.code, tt.level)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	chiv5 "github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.mongodb.org/mongo-driver/event"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// samples là số lần quan sát của một series histogram
+func samples(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := o.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if g := testutil.ToFloat64(httpInFlight); g < 1 {
+			t.Errorf("in flight = %v during request", g)
+		}
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		io.WriteString(w, "ok") // không gọi WriteHeader: status 200
+	}
+	v1 := chi.NewRouter()
+	v1.Use(HTTPMetrics)
+	v1.Get("/metrics-test/v1/{id}", handler)
+	v1.Post("/metrics-test/v1", handler)
+	v5 := chiv5.NewRouter()
+	v5.Use(HTTPMetrics)
+	v5.Get("/metrics-test/v5/{id}", handler)
+
+	series := []struct {
+		method, route, status string
+		want                  float64
+	}{
+		// label route là pattern, không phải path thật (id không làm tăng số series)
+		{"GET", "/metrics-test/v1/{id}", "200", 2},
+		{"POST", "/metrics-test/v1", "201", 1},
+		{"OTHER", "unmatched", "405", 1}, // method lạ không thành label riêng
+		{"GET", "/metrics-test/v5/{id}", "200", 1},
+		{"GET", "unmatched", "404", 2},
+	}
+	before := make([]float64, len(series))
+	for i, s := range series {
+		before[i] = testutil.ToFloat64(httpRequests.WithLabelValues(s.method, s.route, s.status))
+	}
+	for _, req := range []struct {
+		h            http.Handler
+		method, path string
+	}{
+		{v1, http.MethodGet, "/metrics-test/v1/1"},
+		{v1, http.MethodGet, "/metrics-test/v1/2"},
+		{v1, http.MethodPost, "/metrics-test/v1"},
+		{v1, "PURGE", "/metrics-test/v1"},
+		{v1, http.MethodGet, "/metrics-test/nope/1"},
+		{v5, http.MethodGet, "/metrics-test/v5/1"},
+		{v5, http.MethodGet, "/metrics-test/nope/1"},
+	} {
+		req.h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+	for i, s := range series {
+		if d := testutil.ToFloat64(httpRequests.WithLabelValues(s.method, s.route, s.status)) - before[i]; d != s.want {
+			t.Errorf("%s %s %s: http_requests_total += %v, want %v", s.method, s.route, s.status, d, s.want)
+		}
+	}
+	if testutil.ToFloat64(httpRequests.WithLabelValues("PURGE", "unmatched", "405")) != 0 {
+		t.Error("unknown method used as label")
+	}
+	if g := testutil.ToFloat64(httpInFlight); g != 0 {
+		t.Errorf("in flight = %v after requests", g)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	for _, tt := range []struct {
+		token, auth string
+		want        int
+	}{
+		{"", "", http.StatusOK},
+		{"s3cret", "", http.StatusUnauthorized},
+		{"s3cret", "Bearer wrong", http.StatusUnauthorized},
+		{"s3cret", "s3cret", http.StatusUnauthorized},
+		{"s3cret", "Bearer s3cret", http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		MetricsHandler(tt.token).ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("token %q, Authorization %q: status = %d, want %d", tt.token, tt.auth, w.Code, tt.want)
+		}
+		if w.Code == http.StatusOK && !strings.Contains(w.Body.String(), "http_requests_in_flight") {
+			t.Errorf("exposition does not contain HTTP metrics")
+		}
+	}
+}
+
+func TestUnaryMetricsInterceptor(t *testing.T) {
+	const method = "/userpb.UserService/MetricsTest"
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	okBefore := testutil.ToFloat64(grpcHandled.WithLabelValues(method, "OK"))
+	nfBefore := testutil.ToFloat64(grpcHandled.WithLabelValues(method, "NotFound"))
+	latBefore := samples(t, grpcDuration.WithLabelValues(method))
+
+	for _, err := range []error{nil, status.Error(codes.NotFound, "user not found")} {
+		_, got := UnaryMetricsInterceptor(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, err })
+		if got != err {
+			t.Errorf("interceptor changed error: %v", got)
+		}
+	}
+	if d := testutil.ToFloat64(grpcHandled.WithLabelValues(method, "OK")) - okBefore; d != 1 {
+		t.Errorf("OK += %v, want 1", d)
+	}
+	if d := testutil.ToFloat64(grpcHandled.WithLabelValues(method, "NotFound")) - nfBefore; d != 1 {
+		t.Errorf("NotFound += %v, want 1", d)
+	}
+	if d := samples(t, grpcDuration.WithLabelValues(method)) - latBefore; d != 2 {
+		t.Errorf("latency samples += %d, want 2", d)
+	}
+}
+
+func TestMongoMonitorMetrics(t *testing.T) {
+	m := MongoMonitor()
+	okBefore := samples(t, mongoDuration.WithLabelValues("metricsTestFind", "success"))
+	errBefore := samples(t, mongoDuration.WithLabelValues("metricsTestInsert", "error"))
+
+	ctx := context.Background()
+	m.Started(ctx, &event.CommandStartedEvent{CommandName: "metricsTestFind", DatabaseName: "auth", RequestID: 1})
+	m.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+		CommandName: "metricsTestFind", RequestID: 1, Duration: 3 * time.Millisecond}})
+	m.Started(ctx, &event.CommandStartedEvent{CommandName: "metricsTestInsert", DatabaseName: "auth", RequestID: 2})
+	m.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+		CommandName: "metricsTestInsert", RequestID: 2, Duration: time.Millisecond}, Failure: "E11000 duplicate key"})
+
+	if d := samples(t, mongoDuration.WithLabelValues("metricsTestFind", "success")) - okBefore; d != 1 {
+		t.Errorf("success samples += %d, want 1", d)
+	}
+	if d := samples(t, mongoDuration.WithLabelValues("metricsTestInsert", "error")) - errBefore; d != 1 {
+		t.Errorf("error samples += %d, want 1", d)
+	}
+}
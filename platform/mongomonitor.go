@@ -0,0 +1,57 @@
+package platform
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "mongodb_command_duration_seconds",
+	Help:    "MongoDB command latency by command name and outcome.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+}, []string{"command", "outcome"})
+
+// MongoMonitor trả CommandMonitor ghi latency (Prometheus) và span (OpenTelemetry)
+// cho mỗi lệnh Mongo, dùng với options.Client().SetMonitor(platform.MongoMonitor()).
+// Span không chứa nội dung lệnh (có thể có email / password hash).
+func MongoMonitor() *event.CommandMonitor {
+	var spans sync.Map // RequestID -> trace.Span
+
+	end := func(id int64, err string) {
+		if v, ok := spans.LoadAndDelete(id); ok {
+			span := v.(trace.Span)
+			if err != "" {
+				span.SetStatus(codes.Error, err)
+			}
+			span.End()
+		}
+	}
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			_, span := Tracer().Start(ctx, "mongodb."+e.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", "mongodb"),
+					attribute.String("db.name", e.DatabaseName),
+					attribute.String("db.operation", e.CommandName),
+				),
+			)
+			spans.Store(e.RequestID, span)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "success").Observe(e.Duration.Seconds())
+			end(e.RequestID, "")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+			end(e.RequestID, e.Failure)
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"generated when missing", "", false},
+		{"client id accepted", "req-123_abc.DEF:1/2+3=", true},
+		{"too long", strings.Repeat("a", 129), false},
+		{"log injection", "abc\ninjected=1", false},
+		{"space", "abc def", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inCtx string
+			h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inCtx = RequestIDFromContext(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				r.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != inCtx {
+				t.Fatalf("header %q, context %q", got, inCtx)
+			}
+			if (got == tt.incoming) != tt.keep {
+				t.Errorf("id = %q, incoming %q, keep = %v", got, tt.incoming, tt.keep)
+			}
+		})
+	}
+}
+
+func TestUnaryRequestIDInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string // rỗng = id mới sinh
+	}{
+		{"from gateway", metadata.Pairs(RequestIDMetadata, "gw-123"), "gw-123"},
+		{"missing", nil, ""},
+		{"invalid", metadata.Pairs(RequestIDMetadata, "bad id\n"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			var got string
+			_, _ = UnaryRequestIDInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+				got = RequestIDFromContext(ctx)
+				return nil, nil
+			})
+			if !validRequestID(got) || (tt.want != "" && got != tt.want) {
+				t.Errorf("request id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryClientRequestIDInterceptor(t *testing.T) {
+	var got []string
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = md.Get(RequestIDMetadata)
+		return nil
+	}
+
+	_ = UnaryClientRequestIDInterceptor(WithRequestID(context.Background(), "req-1"), "/svc/M", nil, nil, nil, invoker)
+	if len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("metadata %s = %v, want [req-1]", RequestIDMetadata, got)
+	}
+
+	_ = UnaryClientRequestIDInterceptor(context.Background(), "/svc/M", nil, nil, nil, invoker)
+	if len(got) != 0 {
+		t.Errorf("metadata without request id = %v", got)
+	}
+}
@@ -0,0 +1,353 @@
+package platform
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrSecretNotFound trả về khi provider không có secret với tên yêu cầu
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider đọc secret theo tên biến môi trường (vd: "SMTP_PASSWORD")
+type SecretProvider interface {
+	Get(name string) (string, error)
+}
+
+// LookupFunc đọc một biến cấu hình (os.LookupEnv hoặc map đọc từ file .env); nil = os.LookupEnv
+type LookupFunc func(key string) (string, bool)
+
+func (f LookupFunc) lookup(key string) (string, bool) {
+	if f == nil {
+		return os.LookupEnv(key)
+	}
+	return f(key)
+}
+
+func (f LookupFunc) get(key string) string {
+	v, _ := f.lookup(key)
+	return v
+}
+
+/********** Env **********/
+type EnvProvider struct {
+	Lookup LookupFunc // nil = os.LookupEnv
+}
+
+func (p EnvProvider) Get(name string) (string, error) {
+	if v, ok := p.Lookup.lookup(name); ok && v != "" {
+		return v, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+/********** File (Docker/Kubernetes secrets) **********/
+// Đọc từ đường dẫn trong NAME_FILE, nếu không có thì thử Dir/NAME
+type FileProvider struct {
+	Dir    string     // vd: /run/secrets (có thể rỗng)
+	Lookup LookupFunc // đọc NAME_FILE, nil = os.LookupEnv
+}
+
+func (p FileProvider) Get(name string) (string, error) {
+	path := p.Lookup.get(name + "_FILE")
+	if path == "" && p.Dir != "" {
+		path = filepath.Join(p.Dir, name)
+		if _, err := os.Stat(path); err != nil {
+			return "", ErrSecretNotFound
+		}
+	}
+	if path == "" {
+		return "", ErrSecretNotFound
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %w", name, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+/********** Vault (file JSON mã hoá AES-GCM) **********/
+// Định dạng file (tạo bằng go run ./cmd/vault trong platform):
+//
+//	"pbvault1" || logN || r || p || salt (16 byte) || nonce (12 byte) || AES-256-GCM(JSON map[string]string)
+//
+// Key = scrypt(passphrase, salt, 2^logN, r, p), passphrase lấy từ SECRETS_VAULT_KEY(_FILE).
+// Header (magic, tham số scrypt, salt) là additional data của GCM nên không sửa được.
+const vaultMagic = "pbvault1"
+
+const (
+	vaultSaltSize   = 16
+	vaultHeaderSize = len(vaultMagic) + 3 + vaultSaltSize
+
+	// tham số scrypt mặc định khi seal (khuyến nghị cho dữ liệu tương tác: N=2^15, r=8, p=1)
+	vaultLogN = 15
+	vaultR    = 8
+	vaultP    = 1
+)
+
+// VaultProvider đọc secret từ file vault. Nội dung đã giải mã được giữ lại tới khi file đổi
+// (mod time hoặc kích thước), nên secret xoay vòng vẫn được nhận mà không giải mã lại mỗi lần Get.
+type VaultProvider struct {
+	Path       string
+	passphrase string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	secrets map[string]string
+	keySalt string // salt của key đã dẫn xuất (scrypt chậm, chỉ chạy lại khi salt đổi)
+	key     []byte
+	loads   int // số lần giải mã file (test)
+}
+
+func NewVaultProvider(path, passphrase string) *VaultProvider {
+	return &VaultProvider{Path: path, passphrase: passphrase}
+}
+
+func (v *VaultProvider) Get(name string) (string, error) {
+	secrets, err := v.load()
+	if err != nil {
+		return "", err
+	}
+	s, ok := secrets[name]
+	if !ok || s == "" {
+		return "", ErrSecretNotFound
+	}
+	return s, nil
+}
+
+func (v *VaultProvider) load() (map[string]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fi, err := os.Stat(v.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read vault: %w", err)
+	}
+	if v.secrets != nil && fi.ModTime().Equal(v.modTime) && fi.Size() == v.size {
+		return v.secrets, nil
+	}
+	data, err := os.ReadFile(v.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read vault: %w", err)
+	}
+	hdr, err := parseVaultHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if v.key == nil || v.keySalt != string(hdr.salt) {
+		if v.key, err = hdr.deriveKey(v.passphrase); err != nil {
+			return nil, err
+		}
+		v.keySalt = string(hdr.salt)
+	}
+	secrets, err := openVault(v.key, data)
+	if err != nil {
+		return nil, err
+	}
+	v.secrets, v.modTime, v.size = secrets, fi.ModTime(), fi.Size()
+	v.loads++
+	return secrets, nil
+}
+
+type vaultHeader struct {
+	logN, r, p uint8
+	salt       []byte
+}
+
+func parseVaultHeader(data []byte) (vaultHeader, error) {
+	if len(data) < vaultHeaderSize || string(data[:len(vaultMagic)]) != vaultMagic {
+		return vaultHeader{}, errors.New("not a vault file (missing pbvault1 header), re-create it with platform/cmd/vault")
+	}
+	b := data[len(vaultMagic):]
+	h := vaultHeader{logN: b[0], r: b[1], p: b[2], salt: b[3 : 3+vaultSaltSize]}
+	if h.logN < 10 || h.logN > 22 || h.r == 0 || h.p == 0 {
+		return vaultHeader{}, errors.New("vault header: invalid scrypt parameters")
+	}
+	return h, nil
+}
+
+func (h vaultHeader) deriveKey(passphrase string) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), h.salt, 1<<h.logN, int(h.r), int(h.p), 32)
+}
+
+// SealVault mã hoá map secret thành nội dung file vault (salt ngẫu nhiên mỗi lần seal)
+func SealVault(passphrase string, secrets map[string]string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("vault passphrase must not be empty")
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	hdr := vaultHeader{logN: vaultLogN, r: vaultR, p: vaultP, salt: make([]byte, vaultSaltSize)}
+	if _, err := rand.Read(hdr.salt); err != nil {
+		return nil, err
+	}
+	key, err := hdr.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(vaultMagic), hdr.logN, hdr.r, hdr.p)
+	out = append(out, hdr.salt...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, out[:vaultHeaderSize]), nil
+}
+
+// OpenVault giải mã nội dung file vault
+func OpenVault(passphrase string, data []byte) (map[string]string, error) {
+	hdr, err := parseVaultHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hdr.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return openVault(key, data)
+}
+
+func openVault(key, data []byte) (map[string]string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < vaultHeaderSize+gcm.NonceSize() {
+		return nil, errors.New("vault file too short")
+	}
+	nonce, ct := data[vaultHeaderSize:vaultHeaderSize+gcm.NonceSize()], data[vaultHeaderSize+gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ct, data[:vaultHeaderSize])
+	if err != nil {
+		return nil, fmt.Errorf("decrypt vault (wrong SECRETS_VAULT_KEY?): %w", err)
+	}
+	out := map[string]string{}
+	if err := json.Unmarshal(plain, &out); err != nil {
+		return nil, fmt.Errorf("decode vault: %w", err)
+	}
+	return out, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/********** Chain **********/
+// Provider đầu tiên có secret sẽ thắng; lỗi khác ErrSecretNotFound được trả về ngay
+type ChainProvider []SecretProvider
+
+func (c ChainProvider) Get(name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Get(name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return "", err
+		}
+	}
+	return "", ErrSecretNotFound
+}
+
+/********** Factory **********/
+// Thứ tự: *_FILE / SECRETS_DIR -> vault (SECRETS_VAULT_FILE) -> env
+func NewSecretProvider() SecretProvider {
+	return NewSecretProviderFrom(nil)
+}
+
+// NewSecretProviderFrom giống NewSecretProvider nhưng đọc cấu hình qua lookup
+// (vd. file .env chưa áp vào process env khi reload)
+func NewSecretProviderFrom(lookup LookupFunc) SecretProvider {
+	file := FileProvider{Dir: lookup.get("SECRETS_DIR"), Lookup: lookup}
+	env := EnvProvider{Lookup: lookup}
+	chain := ChainProvider{file}
+
+	if path := lookup.get("SECRETS_VAULT_FILE"); path != "" {
+		if key, err := (ChainProvider{file, env}).Get("SECRETS_VAULT_KEY"); err == nil {
+			chain = append(chain, NewVaultProvider(path, key))
+		} else {
+			slog.Warn("SECRETS_VAULT_FILE set but SECRETS_VAULT_KEY missing, vault disabled")
+		}
+	}
+	return append(chain, env)
+}
+
+/********** Rotating **********/
+// RotatingSecret giữ giá trị hiện tại và đọc lại định kỳ qua Watch
+type RotatingSecret struct {
+	name     string
+	provider SecretProvider
+
+	mu    sync.RWMutex
+	value string
+}
+
+func NewRotatingSecret(p SecretProvider, name string) *RotatingSecret {
+	s := &RotatingSecret{name: name, provider: p}
+	_ = s.Refresh()
+	return s
+}
+
+func (s *RotatingSecret) Value() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// Refresh đọc lại secret, trả về lỗi nếu không đọc được (giữ giá trị cũ)
+func (s *RotatingSecret) Refresh() error {
+	v, err := s.provider.Get(s.name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	changed := s.value != "" && s.value != v
+	s.value = v
+	s.mu.Unlock()
+	if changed {
+		slog.Info("secret rotated", "name", s.name)
+	}
+	return nil
+}
+
+// Watch chạy tới khi ctx bị huỷ
+func (s *RotatingSecret) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.Refresh(); err != nil {
+				slog.Warn("secret refresh failed", "name", s.name, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVaultSealOpen(t *testing.T) {
+	want := map[string]string{"SMTP_PASSWORD": "s3cret", "JWT_SECRET": "jwt"}
+	data, err := SealVault("passphrase", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenVault("passphrase", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["SMTP_PASSWORD"] != "s3cret" || got["JWT_SECRET"] != "jwt" {
+		t.Errorf("OpenVault = %v", got)
+	}
+
+	again, err := SealVault("passphrase", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := data[len(vaultMagic)+3 : vaultHeaderSize]
+	if bytes.Equal(salt, again[len(vaultMagic)+3:vaultHeaderSize]) {
+		t.Error("salt reused across seals")
+	}
+
+	if _, err := OpenVault("wrong", data); err == nil {
+		t.Error("wrong passphrase accepted")
+	}
+	tampered := bytes.Clone(data)
+	tampered[vaultHeaderSize-1] ^= 1 // salt là additional data của GCM
+	if _, err := OpenVault("passphrase", tampered); err == nil {
+		t.Error("tampered header accepted")
+	}
+	if _, err := OpenVault("passphrase", []byte("legacy-format")); err == nil {
+		t.Error("file without header accepted")
+	}
+	if _, err := SealVault("", want); err == nil {
+		t.Error("empty passphrase accepted")
+	}
+}
+
+func TestVaultProviderCachesUntilFileChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.vault")
+	writeVault(t, path, map[string]string{"SMTP_PASSWORD": "v1"})
+
+	v := NewVaultProvider(path, "pw")
+	for range 3 {
+		if got, err := v.Get("SMTP_PASSWORD"); err != nil || got != "v1" {
+			t.Fatalf("Get = %q, %v", got, err)
+		}
+	}
+	if _, err := v.Get("MISSING"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("missing secret: %v", err)
+	}
+	if v.loads != 1 {
+		t.Errorf("vault decrypted %d times, want 1 while the file is unchanged", v.loads)
+	}
+
+	writeVault(t, path, map[string]string{"SMTP_PASSWORD": "v2"})
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := v.Get("SMTP_PASSWORD"); err != nil || got != "v2" {
+		t.Fatalf("after rotation Get = %q, %v", got, err)
+	}
+	if v.loads != 2 {
+		t.Errorf("loads = %d, want 2 after the file changed", v.loads)
+	}
+}
+
+func TestChainProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "FROM_DIR"), []byte("dir-value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FROM_DIR", "env-value")
+	t.Setenv("ONLY_ENV", "env-only")
+
+	chain := ChainProvider{FileProvider{Dir: dir}, EnvProvider{}}
+	if v, _ := chain.Get("FROM_DIR"); v != "dir-value" {
+		t.Errorf("file should win over env, got %q", v)
+	}
+	if v, _ := chain.Get("ONLY_ENV"); v != "env-only" {
+		t.Errorf("env fallback = %q", v)
+	}
+	if _, err := chain.Get("NOPE"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("missing = %v", err)
+	}
+
+	t.Setenv("BROKEN_FILE", filepath.Join(dir, "does-not-exist"))
+	if _, err := chain.Get("BROKEN"); err == nil || errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("unreadable *_FILE should be a hard error, got %v", err)
+	}
+}
+
+func TestRotatingSecretKeepsLastValueOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	t.Setenv("TOKEN_FILE", path)
+	if err := os.WriteFile(path, []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewRotatingSecret(FileProvider{}, "TOKEN")
+	if s.Value() != "a" {
+		t.Fatalf("Value = %q", s.Value())
+	}
+	if err := os.WriteFile(path, []byte("b"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refresh(); err != nil || s.Value() != "b" {
+		t.Fatalf("Refresh = %v, Value = %q", err, s.Value())
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refresh(); err == nil {
+		t.Error("Refresh should fail when the file is gone")
+	}
+	if s.Value() != "b" {
+		t.Errorf("Value = %q, want previous value kept", s.Value())
+	}
+}
+
+func writeVault(t *testing.T, path string, secrets map[string]string) {
+	t.Helper()
+	data, err := SealVault("pw", secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
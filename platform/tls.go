@@ -0,0 +1,299 @@
+package platform
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSOptions cấu hình TLS cho gRPC, đọc từ env <PREFIX>_CERT_FILE, _KEY_FILE, _CA_FILE,
+// _CLIENT_AUTH, _ALLOWED_SANS, _RELOAD_INTERVAL (vd. GRPC_TLS_CERT_FILE)
+type TLSOptions struct {
+	CertFile string // cert của chính mình (server cert, hoặc client cert khi dùng mTLS)
+	KeyFile  string
+	CAFile   string // CA bundle để verify peer (rỗng = CA hệ thống)
+
+	// Server: bắt buộc client cert ký bởi CAFile (mTLS); bắt buộc có CAFile
+	ClientAuth bool
+	// Identity của peer được chấp nhận: DNS SAN, IP SAN hoặc URI SAN (vd. spiffe://holoc.id.vn/api-gateway).
+	// Rỗng: server không giới hạn client (chỉ cần cert hợp lệ), client kiểm tra hostname của địa chỉ dial.
+	AllowedSANs []string
+
+	ReloadInterval time.Duration // chu kỳ kiểm tra file cert/key/CA thay đổi
+}
+
+func TLSOptionsFromEnv(prefix string) TLSOptions {
+	return TLSOptionsFrom(prefix, nil)
+}
+
+// TLSOptionsFrom giống TLSOptionsFromEnv nhưng đọc qua lookup
+func TLSOptionsFrom(prefix string, lookup LookupFunc) TLSOptions {
+	o := TLSOptions{
+		CertFile:       lookup.get(prefix + "_CERT_FILE"),
+		KeyFile:        lookup.get(prefix + "_KEY_FILE"),
+		CAFile:         lookup.get(prefix + "_CA_FILE"),
+		ReloadInterval: time.Minute,
+	}
+	if v := lookup.get(prefix + "_CLIENT_AUTH"); v != "" {
+		o.ClientAuth, _ = strconv.ParseBool(v)
+	}
+	for _, s := range strings.Split(lookup.get(prefix+"_ALLOWED_SANS"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			o.AllowedSANs = append(o.AllowedSANs, s)
+		}
+	}
+	if v := lookup.get(prefix + "_RELOAD_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			o.ReloadInterval = d
+		}
+	}
+	return o
+}
+
+// Enabled: có cấu hình TLS (không có = plaintext như trước)
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || o.CAFile != ""
+}
+
+// CertReloader giữ cert + CA hiện tại và đọc lại khi file đổi, không cần restart.
+// Chain của peer được verify trong VerifyConnection với CA hiện tại (tls.Config không
+// cho đổi RootCAs/ClientCAs sau khi tạo), nên kết nối mới luôn dùng file mới nhất.
+type CertReloader struct {
+	opts TLSOptions
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+func NewCertReloader(opts TLSOptions) (*CertReloader, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("tls: cert file and key file must be set together")
+	}
+	if opts.ClientAuth && opts.CAFile == "" {
+		// CA hệ thống sẽ nhận client cert của bất kỳ ai mua được cert public
+		return nil, errors.New("tls: client auth requires a CA file")
+	}
+	r := &CertReloader{opts: opts}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) files() []string {
+	var out []string
+	for _, f := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.CAFile} {
+		if f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (r *CertReloader) load() error {
+	mod := map[string]time.Time{}
+	for _, f := range r.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		mod[f] = fi.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if r.opts.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: load key pair: %w", err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.opts.CAFile != "" {
+		pem, err := os.ReadFile(r.opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificate found in %s", r.opts.CAFile)
+		}
+	} else {
+		var err error
+		if pool, err = x509.SystemCertPool(); err != nil {
+			return fmt.Errorf("tls: system CA: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = cert, pool, mod
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *CertReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.files() {
+		fi, err := os.Stat(f)
+		if err != nil || !fi.ModTime().Equal(r.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch đọc lại cert/key/CA khi file đổi tới khi ctx bị huỷ.
+// File lỗi (vd. đang ghi dở) thì giữ cert cũ và thử lại ở chu kỳ sau.
+func (r *CertReloader) Watch(ctx context.Context) {
+	interval := r.opts.ReloadInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				slog.Warn("tls reload failed, keeping previous certificates", "error", err)
+				continue
+			}
+			slog.Info("tls certificates reloaded", "cert", r.opts.CertFile, "ca", r.opts.CAFile)
+		}
+	}
+}
+
+func (r *CertReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+// ServerConfig: cert của server; ClientAuth thì bắt buộc client cert hợp lệ và đúng AllowedSANs
+func (r *CertReloader) ServerConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return nil, errors.New("tls: no server certificate configured")
+			}
+			return cert, nil
+		},
+	}
+	if r.opts.ClientAuth {
+		// chain được verify trong VerifyConnection với CA hiện tại
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return r.verify(cs.PeerCertificates, x509.ExtKeyUsageClientAuth, r.opts.AllowedSANs, "")
+		}
+	}
+	return cfg
+}
+
+// ClientConfig: verify server theo identities (rỗng = AllowedSANs, rồi tới hostname
+// của địa chỉ dial) và gửi client cert nếu có (mTLS). Không có identity lẫn hostname
+// thì từ chối kết nối thay vì nhận mọi cert hợp lệ; dial bằng IP cũng rơi vào trường hợp
+// này (Go không đưa IP vào ServerName) nên phải khai identity, vd. IP SAN của server.
+func (r *CertReloader) ClientConfig(identities ...string) *tls.Config {
+	if len(identities) == 0 {
+		identities = r.opts.AllowedSANs
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// verify trong VerifyConnection để CA đọc lại được khi file đổi
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert, _ := r.current(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(identities) == 0 && cs.ServerName == "" {
+				return errors.New("tls: no server identity to verify (set ALLOWED_SANS or dial by hostname)")
+			}
+			return r.verify(cs.PeerCertificates, x509.ExtKeyUsageServerAuth, identities, cs.ServerName)
+		},
+	}
+}
+
+// ServerCredentials / ClientCredentials dùng cho grpc.Creds / grpc.WithTransportCredentials
+func (r *CertReloader) ServerCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(r.ServerConfig())
+}
+
+func (r *CertReloader) ClientCredentials(identities ...string) credentials.TransportCredentials {
+	return credentials.NewTLS(r.ClientConfig(identities...))
+}
+
+func (r *CertReloader) verify(certs []*x509.Certificate, usage x509.ExtKeyUsage, allowed []string, host string) error {
+	if len(certs) == 0 {
+		return errors.New("tls: peer sent no certificate")
+	}
+	_, roots := r.current()
+	inter := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		inter.AddCert(c)
+	}
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inter,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}); err != nil {
+		return fmt.Errorf("tls: verify peer: %w", err)
+	}
+	if len(allowed) > 0 {
+		if !matchSAN(leaf, allowed) {
+			return fmt.Errorf("tls: peer identity %v not in %v", certSANs(leaf), allowed)
+		}
+		return nil
+	}
+	if host != "" {
+		return leaf.VerifyHostname(host)
+	}
+	return nil
+}
+
+func matchSAN(leaf *x509.Certificate, allowed []string) bool {
+	for _, want := range allowed {
+		for _, san := range certSANs(leaf) {
+			if strings.EqualFold(san, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// certSANs: DNS, IP và URI SAN của cert
+func certSANs(c *x509.Certificate) []string {
+	out := append([]string{}, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		out = append(out, ip.String())
+	}
+	for _, u := range c.URIs {
+		out = append(out, u.String())
+	}
+	return out
+}
@@ -0,0 +1,271 @@
+package platform
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA là CA tự ký sinh trong test, cấp cert cho server/client
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{cert: cert, key: key, dir: t.TempDir()}
+	writePEM(t, ca.path("ca.pem"), "CERTIFICATE", der)
+	return ca
+}
+
+func (ca *testCA) path(name string) string { return filepath.Join(ca.dir, name) }
+
+// issue ghi <name>.pem / <name>-key.pem với SAN: tên có "://" là URI, IP là IP SAN, còn lại DNS
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage, sans ...string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	for _, s := range sans {
+		switch {
+		case strings.Contains(s, "://"):
+			u, _ := url.Parse(s)
+			tmpl.URIs = append(tmpl.URIs, u)
+		case net.ParseIP(s) != nil:
+			tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(s))
+		default:
+			tmpl.DNSNames = append(tmpl.DNSNames, s)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = ca.path(name+".pem"), ca.path(name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustReloader(t *testing.T, o TLSOptions) *CertReloader {
+	t.Helper()
+	r, err := NewCertReloader(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// handshake bắt tay TLS qua loopback, trả về lỗi phía client và phía server.
+// TLS 1.3 client xong bắt tay trước khi server verify client cert nên phải xem cả hai.
+func handshake(t *testing.T, client, server *tls.Config) (clientErr, serverErr error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	done := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		done <- tls.Server(conn, server).Handshake()
+	}()
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	clientErr = tls.Client(conn, client).Handshake()
+	return clientErr, <-done
+}
+
+func TestNewCertReloaderValidation(t *testing.T) {
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, "auth-service")
+
+	cases := map[string]TLSOptions{
+		"cert without key":        {CertFile: cert},
+		"key without cert":        {KeyFile: key},
+		"client auth without CA":  {CertFile: cert, KeyFile: key, ClientAuth: true},
+		"missing CA file":         {CAFile: ca.path("missing.pem")},
+		"CA file without certs":   {CAFile: key},
+		"cert and key mismatched": {CertFile: cert, KeyFile: ca.path("missing-key.pem")},
+	}
+	for name, o := range cases {
+		if _, err := NewCertReloader(o); err == nil {
+			t.Errorf("%s: NewCertReloader succeeded", name)
+		}
+	}
+	if _, err := NewCertReloader(TLSOptions{CertFile: cert, KeyFile: key, CAFile: ca.path("ca.pem"), ClientAuth: true}); err != nil {
+		t.Errorf("valid mTLS options: %v", err)
+	}
+}
+
+func TestTLSOptionsFrom(t *testing.T) {
+	env := map[string]string{
+		"GRPC_TLS_CERT_FILE":       "c.pem",
+		"GRPC_TLS_KEY_FILE":        "k.pem",
+		"GRPC_TLS_CA_FILE":         "ca.pem",
+		"GRPC_TLS_CLIENT_AUTH":     "true",
+		"GRPC_TLS_ALLOWED_SANS":    " spiffe://holoc.id.vn/api-gateway , ,gateway",
+		"GRPC_TLS_RELOAD_INTERVAL": "5s",
+	}
+	o := TLSOptionsFrom("GRPC_TLS", func(k string) (string, bool) { v, ok := env[k]; return v, ok })
+	if !o.Enabled() || !o.ClientAuth || o.ReloadInterval != 5*time.Second ||
+		strings.Join(o.AllowedSANs, ",") != "spiffe://holoc.id.vn/api-gateway,gateway" {
+		t.Errorf("TLSOptionsFrom = %+v", o)
+	}
+	if (TLSOptions{}).Enabled() {
+		t.Error("empty options enabled")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	srvCert, srvKey := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, "auth-service", "spiffe://holoc.id.vn/auth-service")
+	gwCert, gwKey := ca.issue(t, "gateway", x509.ExtKeyUsageClientAuth, "spiffe://holoc.id.vn/api-gateway")
+	evilCert, evilKey := ca.issue(t, "evil", x509.ExtKeyUsageClientAuth, "spiffe://holoc.id.vn/evil")
+	strayCert, strayKey := other.issue(t, "stray", x509.ExtKeyUsageClientAuth, "spiffe://holoc.id.vn/api-gateway")
+
+	server := mustReloader(t, TLSOptions{
+		CertFile: srvCert, KeyFile: srvKey, CAFile: ca.path("ca.pem"),
+		ClientAuth: true, AllowedSANs: []string{"spiffe://holoc.id.vn/api-gateway"},
+	}).ServerConfig()
+	client := func(cert, key string) *CertReloader {
+		return mustReloader(t, TLSOptions{CertFile: cert, KeyFile: key, CAFile: ca.path("ca.pem")})
+	}
+	withName := func(cfg *tls.Config, name string) *tls.Config { cfg.ServerName = name; return cfg }
+
+	cases := []struct {
+		name   string
+		client *tls.Config
+		ok     bool
+	}{
+		{"identity match", client(gwCert, gwKey).ClientConfig("spiffe://holoc.id.vn/auth-service"), true},
+		{"hostname match", withName(client(gwCert, gwKey).ClientConfig(), "auth-service"), true},
+		{"hostname mismatch", withName(client(gwCert, gwKey).ClientConfig(), "contact-service"), false},
+		{"identity mismatch", client(gwCert, gwKey).ClientConfig("spiffe://holoc.id.vn/contact-service"), false},
+		{"no identity no hostname", client(gwCert, gwKey).ClientConfig(), false},
+		{"client SAN not allowed", client(evilCert, evilKey).ClientConfig("auth-service"), false},
+		{"client cert from other CA", client(strayCert, strayKey).ClientConfig("auth-service"), false},
+		{"no client cert", mustReloader(t, TLSOptions{CAFile: ca.path("ca.pem")}).ClientConfig("auth-service"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cerr, serr := handshake(t, c.client, server.Clone())
+			if ok := cerr == nil && serr == nil; ok != c.ok {
+				t.Errorf("handshake ok = %v, want %v (client: %v, server: %v)", ok, c.ok, cerr, serr)
+			}
+		})
+	}
+}
+
+func TestServerWithoutAllowedSANsAcceptsAnyClientOfCA(t *testing.T) {
+	ca := newTestCA(t)
+	srvCert, srvKey := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, "127.0.0.1")
+	cliCert, cliKey := ca.issue(t, "client", x509.ExtKeyUsageClientAuth, "anything")
+
+	server := mustReloader(t, TLSOptions{CertFile: srvCert, KeyFile: srvKey, CAFile: ca.path("ca.pem"), ClientAuth: true}).ServerConfig()
+	r := mustReloader(t, TLSOptions{CertFile: cliCert, KeyFile: cliKey, CAFile: ca.path("ca.pem")})
+	if cerr, serr := handshake(t, r.ClientConfig("127.0.0.1"), server); cerr != nil || serr != nil {
+		t.Errorf("handshake: client %v, server %v", cerr, serr)
+	}
+
+	// dial bằng IP không có identity: ServerName rỗng nên không có gì để so, phải từ chối
+	byIP := r.ClientConfig()
+	byIP.ServerName = "127.0.0.1"
+	if cerr, _ := handshake(t, byIP, server); cerr == nil {
+		t.Error("handshake by IP without identity succeeded")
+	}
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	ca := newTestCA(t)
+	srvCert, srvKey := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, "old-name")
+	r := mustReloader(t, TLSOptions{CertFile: srvCert, KeyFile: srvKey, CAFile: ca.path("ca.pem"), ReloadInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx)
+
+	client := mustReloader(t, TLSOptions{CAFile: ca.path("ca.pem")})
+	if cerr, _ := handshake(t, client.ClientConfig("new-name"), r.ServerConfig()); cerr == nil {
+		t.Fatal("handshake with new-name succeeded before rotation")
+	}
+
+	// cert mới ghi đè file cũ (mtime đổi), kết nối mới dùng cert mới mà không restart
+	ca.issue(t, "server", x509.ExtKeyUsageServerAuth, "new-name")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(srvCert, future, future)
+	waitFor(t, func() bool {
+		cerr, _ := handshake(t, client.ClientConfig("new-name"), r.ServerConfig())
+		return cerr == nil
+	})
+
+	// file hỏng: giữ cert đang dùng
+	os.WriteFile(srvKey, []byte("garbage"), 0o600)
+	time.Sleep(50 * time.Millisecond)
+	if cerr, _ := handshake(t, client.ClientConfig("new-name"), r.ServerConfig()); cerr != nil {
+		t.Errorf("handshake after broken key file: %v", cerr)
+	}
+}
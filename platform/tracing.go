@@ -0,0 +1,108 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InitTracing cấu hình OpenTelemetry tracing theo env:
+//   - OTEL_TRACES_EXPORTER: none (mặc định) | otlp | stdout | file
+//   - OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_TRACES_ENDPOINT: collector (OTLP/HTTP)
+//   - OTEL_TRACES_FILE: file JSON lines cho exporter "file" (mặc định traces.jsonl)
+//   - OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG: sampler chuẩn của SDK
+//
+// W3C trace context luôn được propagate kể cả khi không export.
+// Hàm trả về shutdown để flush span còn trong buffer.
+func InitTracing(ctx context.Context, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exp     sdktrace.SpanExporter
+		closeFn = func() error { return nil }
+		err     error
+	)
+	switch kind := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER"))); kind {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	case "stdout", "console":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "file":
+		path := os.Getenv("OTEL_TRACES_FILE")
+		if path == "" {
+			path = "traces.jsonl"
+		}
+		var f *os.File
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err == nil {
+			closeFn = f.Close
+			exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		}
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q (want otlp, stdout, file or none)", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME / OTEL_RESOURCE_ATTRIBUTES (nếu có) ghi đè service mặc định
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), closeFn())
+	}, nil
+}
+
+// Tracer dùng chung cho span tự tạo (Mongo, SMTP, Turnstile, ...)
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/RibunLoc/WebPersonalBackend")
+}
+
+// HTTPTracing tạo span server cho mỗi request (đọc traceparent từ header)
+// và đặt tên span theo route pattern của chi (v1 hoặc v5) sau khi route được match.
+func HTTPTracing(service string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			if route := RoutePattern(r); route != "" {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(attribute.String("http.route", route))
+			}
+		})
+		return otelhttp.NewHandler(named, service,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return r.Method }),
+		)
+	}
+}
+
+// TracingTransport gắn traceparent vào request HTTP gửi ra và tạo span client
+func TracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
@@ -0,0 +1,225 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	chiv5 "github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceparent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+// recordSpans đặt TracerProvider ghi span vào bộ nhớ (khôi phục provider cũ khi test xong)
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	return sr
+}
+
+func attr(attrs []attribute.KeyValue, key string) string {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestHTTPTracing(t *testing.T) {
+	sr := recordSpans(t)
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("Traceparent")
+	}))
+	defer upstream.Close()
+
+	r := chi.NewRouter()
+	r.Use(HTTPTracing("api-gateway"))
+	r.Get("/v1/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// request tới upstream mang trace hiện tại
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := (&http.Client{Transport: TracingTransport(nil)}).Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/posts/42", nil)
+	req.Header.Set("Traceparent", testTraceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	var server, client sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		switch s.SpanKind() {
+		case trace.SpanKindServer:
+			server = s
+		case trace.SpanKindClient:
+			client = s
+		}
+	}
+	if server == nil || client == nil {
+		t.Fatalf("spans = %d, want a server and a client span", len(spans))
+	}
+	// tên theo route pattern, không theo path thật
+	if server.Name() != "GET /v1/posts/{id}" || attr(server.Attributes(), "http.route") != "/v1/posts/{id}" {
+		t.Errorf("server span %q http.route %q", server.Name(), attr(server.Attributes(), "http.route"))
+	}
+	if server.SpanContext().TraceID().String() != testTraceID || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span not joined to incoming traceparent: trace %s parent %s",
+			server.SpanContext().TraceID(), server.Parent().SpanID())
+	}
+	if client.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("client span is not a child of the server span")
+	}
+	if !strings.Contains(upstreamTraceparent, testTraceID+"-"+client.SpanContext().SpanID().String()) {
+		t.Errorf("upstream traceparent = %q, want client span %s", upstreamTraceparent, client.SpanContext().SpanID())
+	}
+}
+
+func TestHTTPTracingUnmatchedRoute(t *testing.T) {
+	sr := recordSpans(t)
+	r := chi.NewRouter()
+	r.Use(HTTPTracing("api-gateway"))
+	r.Get("/a", func(http.ResponseWriter, *http.Request) {})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/secret-token-in-path", nil))
+	if spans := sr.Ended(); len(spans) != 1 || spans[0].Name() != "GET" {
+		t.Errorf("spans = %v, want one span named by method only", spans)
+	}
+}
+
+func TestHTTPTracingChiV5(t *testing.T) {
+	sr := recordSpans(t)
+	r := chiv5.NewRouter()
+	r.Use(HTTPTracing("contact-service"))
+	r.Post("/contact/{form}", func(http.ResponseWriter, *http.Request) {})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/contact/newsletter", nil))
+	if spans := sr.Ended(); len(spans) != 1 || spans[0].Name() != "POST /contact/{form}" {
+		t.Errorf("spans = %v, want one span named by chi v5 route pattern", spans)
+	}
+}
+
+func TestInitTracing(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	ctx := context.Background()
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	if _, err := InitTracing(ctx, "api-gateway"); err == nil {
+		t.Error("unknown exporter accepted")
+	}
+
+	// none: không export nhưng vẫn propagate traceparent
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	shutdown, err := InitTracing(ctx, "api-gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = shutdown(ctx)
+	if fields := otel.GetTextMapPropagator().Fields(); !strings.Contains(strings.Join(fields, ","), "traceparent") {
+		t.Errorf("propagator fields = %v", fields)
+	}
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	t.Setenv("OTEL_TRACES_EXPORTER", "file")
+	t.Setenv("OTEL_TRACES_FILE", path)
+	t.Setenv("OTEL_SERVICE_NAME", "gateway-test")
+	if shutdown, err = InitTracing(ctx, "api-gateway"); err != nil {
+		t.Fatal(err)
+	}
+	_, span := Tracer().Start(ctx, "init-tracing-test")
+	span.End()
+	if err := shutdown(ctx); err != nil { // flush batcher + đóng file
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"init-tracing-test"`) || !strings.Contains(string(b), "gateway-test") {
+		t.Errorf("trace file = %s", b)
+	}
+}
+
+func TestMongoMonitorSpans(t *testing.T) {
+	sr := recordSpans(t)
+	m := MongoMonitor()
+
+	ctx, parent := Tracer().Start(context.Background(), "Login")
+	m.Started(ctx, &event.CommandStartedEvent{CommandName: "find", DatabaseName: "auth", RequestID: 11,
+		Command: bson.Raw(mustBSON(t, bson.M{"find": "users", "filter": bson.M{"email": "a@b.vn"}}))})
+	m.Started(ctx, &event.CommandStartedEvent{CommandName: "insert", DatabaseName: "auth", RequestID: 12})
+	m.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "insert", RequestID: 12},
+		Failure: "E11000 duplicate key"})
+	m.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 11}})
+	// sự kiện kết thúc không có Started tương ứng: bỏ qua
+	m.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 99}})
+	parent.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range sr.Ended() {
+		spans[s.Name()] = s
+	}
+	if len(spans) != 3 {
+		t.Fatalf("ended spans = %d, want Login + 2 commands", len(spans))
+	}
+	find, insert := spans["mongodb.find"], spans["mongodb.insert"]
+	if find == nil || insert == nil {
+		t.Fatalf("spans = %v", spans)
+	}
+	if find.SpanKind() != trace.SpanKindClient || find.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("command span is not a client child span of the caller")
+	}
+	if find.Status().Code == codes.Error || insert.Status().Code != codes.Error || insert.Status().Description != "E11000 duplicate key" {
+		t.Errorf("status find=%v insert=%v", find.Status(), insert.Status())
+	}
+	for _, kv := range find.Attributes() {
+		if v := kv.Value.Emit(); kv.Key == "db.statement" || v == "a@b.vn" {
+			t.Errorf("span leaks command content: %s=%s", kv.Key, v)
+		}
+	}
+	if got := attr(find.Attributes(), "db.name") + "/" + attr(find.Attributes(), "db.operation"); got != "auth/find" {
+		t.Errorf("db.name/db.operation = %q", got)
+	}
+}
+
+func mustBSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}